@@ -34,6 +34,8 @@ func main() {
 		handleEnvCommand(args)
 	case "install":
 		handleInstallCommand(args)
+	case "remove", "uninstall":
+		handleRemoveCommand(args)
 	case "run":
 		handleRunCommand(args)
 	case "shell":
@@ -42,6 +44,8 @@ func main() {
 		handleInfoCommand(args)
 	case "search":
 		handleSearchCommand(args)
+	case "provides":
+		handleProvidesCommand(args)
 	case "list":
 		handleListCommand(args)
 	case "version", "--version", "-v":
@@ -66,8 +70,12 @@ Setup (run once):
 
 Environment Management:
   env create <name> [--backend apt|apk|dpkg|brew|nix|winget|...]
+             [--release <rel>] [--arch <arch>] [--mirror <url>] [--repos <a,b,...>]
+             [--gpg-keys <key,...>] [--relocate] [--set-interpreter]
                                 Create new isolated environment
                                 If no --backend is set, auto mode is used
+                                Settings are stored in env.json and used by
+                                every install, info and search in the env
   env list                      List all environments
   env activate <name>           Activate an environment (modifies shell)
   env deactivate                Deactivate current environment
@@ -76,9 +84,11 @@ Environment Management:
 
 Package Management:
   install <package> [--debug]   Install package to active environment
+  remove <package> [--debug]    Remove package from active environment (nix, guix, brew)
   search <query>                Search for packages
   list                          List installed packages in active environment
   info <package>                Show package information
+  provides <path>               Show which package ships a file (dnf, el, zypper)
   run <command> [args...]       Run command in active environment
 
 Options:
@@ -111,6 +121,15 @@ Examples:
   # Or explicitly set a backend
   upkg env create myproject --backend apt
   upkg env activate myproject
+
+  # Pin a release and architecture (e.g. a jammy arm64 sysroot)
+  upkg env create jammy-arm --backend apt --release jammy --arch arm64 --repos main,universe
+
+  # Reproducible binaries from the Guix build farms
+  upkg env create guixenv --backend guix
+
+  # MinGW libraries for Windows cross builds (any host)
+  upkg env create win64 --backend msys2 --release ucrt64
   
   # Install packages with debug output
   upkg install gcc --debug
@@ -435,33 +454,66 @@ func handleEnvCommand(args []string) {
 
 func handleEnvCreate(args []string) {
 	if len(args) < 1 {
-		fmt.Fprintf(os.Stderr, "Usage: upkg env create <name> [--backend apt|apk|dpkg|brew|nix|winget|...]\n")
+		fmt.Fprintf(os.Stderr, "Usage: upkg env create <name> [--backend apt|apk|dpkg|brew|nix|winget|...] [--release <rel>] [--arch <arch>] [--mirror <url>] [--repos <a,b,...>] [--gpg-keys <key,...>] [--relocate] [--set-interpreter]\n")
 		os.Exit(1)
 	}
 
 	name := args[0]
 	backendName := "" // empty means auto
+	var settings env.EnvSettings
 
-	// Parse --backend flag
+	// Parse --backend and backend settings flags
 	for i := 1; i < len(args); i++ {
-		if args[i] == "--backend" && i+1 < len(args) {
+		if args[i] == "--relocate" {
+			settings.Relocate = true
+			continue
+		}
+		if args[i] == "--set-interpreter" {
+			settings.SetInterpreter = true
+			continue
+		}
+		if i+1 >= len(args) {
+			break
+		}
+		switch args[i] {
+		case "--backend":
 			backendName = args[i+1]
-			i++
+		case "--release":
+			settings.Release = args[i+1]
+		case "--arch":
+			settings.Arch = args[i+1]
+		case "--mirror":
+			settings.Mirror = strings.TrimSuffix(args[i+1], "/")
+		case "--repos":
+			for _, repo := range strings.Split(args[i+1], ",") {
+				if repo = strings.TrimSpace(repo); repo != "" {
+					settings.Repos = append(settings.Repos, repo)
+				}
+			}
+		case "--gpg-keys":
+			for _, key := range strings.Split(args[i+1], ",") {
+				if key = strings.TrimSpace(key); key != "" {
+					settings.GPGKeys = append(settings.GPGKeys, key)
+				}
+			}
+		default:
+			continue
 		}
+		i++
 	}
 
 	// If a backend was explicitly set, validate it
 	if backendName != "" {
 		validBackends := map[string]bool{
-			"apt": true, "brew": true, "nix": true,
-			"dnf": true, "pacman": true, "apk": true,
+			"apt": true, "brew": true, "nix": true, "guix": true,
+			"dnf": true, "el": true, "pacman": true, "apk": true,
 			"zypper": true, "choco": true, "dpkg": true,
-			"winget": true,
+			"winget": true, "msys2": true,
 		}
 
 		if !validBackends[backendName] {
 			fmt.Fprintf(os.Stderr, "Error: invalid backend '%s'\n", backendName)
-			fmt.Fprintf(os.Stderr, "Valid backends: apt, apk, dpkg, brew, nix, dnf, pacman, zypper, choco, winget\n")
+			fmt.Fprintf(os.Stderr, "Valid backends: apt, apk, dpkg, brew, nix, guix, dnf, el, pacman, zypper, choco, winget, msys2\n")
 			os.Exit(1)
 		}
 	} else {
@@ -469,7 +521,7 @@ func handleEnvCreate(args []string) {
 		backendName = "auto"
 	}
 
-	envSpec, err := envManager.CreateEnv(name, backendName)
+	envSpec, err := envManager.CreateEnvWithSettings(name, backendName, settings)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -477,6 +529,7 @@ func handleEnvCreate(args []string) {
 
 	fmt.Printf("✓ Created environment: %s\n", envSpec.Name)
 	fmt.Printf("  Backend: %s\n", envSpec.Backend)
+	printEnvSettings(envSpec)
 	fmt.Printf("  Path: %s\n", envSpec.InstallPath)
 	fmt.Printf("\nNext steps:\n")
 	fmt.Printf("  upkg env activate %s\n", name)
@@ -599,6 +652,7 @@ func handleEnvInfo(args []string) {
 	}
 	fmt.Println()
 	fmt.Printf("  Backend: %s\n", envSpec.Backend)
+	printEnvSettings(envSpec)
 	fmt.Printf("  Path: %s\n", envSpec.InstallPath)
 	fmt.Printf("  Created: %s\n", envSpec.CreatedAt)
 	fmt.Printf("  Packages: %d\n", len(envSpec.Packages))
@@ -660,9 +714,8 @@ func handleInstallCommand(args []string) {
 		os.Exit(1)
 	}
 
-	// Create upkg manager with environment's install path
-	config := upkg.DefaultConfig()
-	config.InstallPath = envSpec.InstallPath
+	// Create upkg manager with environment's install path and settings
+	config := newEnvConfig(envSpec)
 	config.Debug = debug
 
 	if debug {
@@ -714,6 +767,58 @@ func handleInstallCommand(args []string) {
 	}
 }
 
+func handleRemoveCommand(args []string) {
+	var packages []string
+	debug := false
+
+	for _, arg := range args {
+		if arg == "--debug" || arg == "-d" {
+			debug = true
+		} else {
+			packages = append(packages, arg)
+		}
+	}
+
+	if len(packages) == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: upkg remove <package> [package...] [--debug]\n")
+		os.Exit(1)
+	}
+
+	envSpec, err := envManager.GetActiveEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: No active environment\n")
+		os.Exit(1)
+	}
+
+	config := newEnvConfig(envSpec)
+	config.Debug = debug
+	if debug {
+		config.Logger = log.New(os.Stderr, "[DEBUG] ", log.LstdFlags)
+	}
+
+	manager, err := upkg.NewManager(mapBackendName(envSpec.Backend), config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating manager: %v\n", err)
+		os.Exit(1)
+	}
+	defer manager.Close()
+
+	for _, packageName := range packages {
+		fmt.Printf("Removing %s...\n", packageName)
+
+		pkg := &backend.Package{Name: packageName}
+		if err := manager.Remove(context.Background(), pkg); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ Error removing %s: %v\n", packageName, err)
+			continue
+		}
+
+		envSpec.RemovePackage(packageName)
+		fmt.Printf("✓ %s removed\n", packageName)
+	}
+
+	envManager.UpdateEnv(envSpec)
+}
+
 func listDirectory(path string, indent string) {
 	entries, err := os.ReadDir(path)
 	if err != nil {
@@ -804,8 +909,7 @@ func handleInfoCommand(args []string) {
 		os.Exit(1)
 	}
 
-	config := upkg.DefaultConfig()
-	config.InstallPath = envSpec.InstallPath
+	config := newEnvConfig(envSpec)
 	config.Debug = debug
 
 	if debug {
@@ -856,8 +960,7 @@ func handleSearchCommand(args []string) {
 
 	query := strings.Join(args, " ")
 
-	config := upkg.DefaultConfig()
-	config.InstallPath = envSpec.InstallPath
+	config := newEnvConfig(envSpec)
 
 	backendType := mapBackendName(envSpec.Backend)
 	manager, err := upkg.NewManager(backendType, config)
@@ -898,6 +1001,42 @@ func handleSearchCommand(args []string) {
 	}
 }
 
+func handleProvidesCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: upkg provides <path>\n")
+		os.Exit(1)
+	}
+
+	envSpec, err := envManager.GetActiveEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: No active environment\n")
+		os.Exit(1)
+	}
+
+	config := newEnvConfig(envSpec)
+
+	backendType := mapBackendName(envSpec.Backend)
+	manager, err := upkg.NewManager(backendType, config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer manager.Close()
+
+	owners, err := manager.FindFileOwners(context.Background(), args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, pkg := range owners {
+		fmt.Printf("%s (%s)\n", pkg.Name, pkg.Version)
+		if pkg.Description != "" {
+			fmt.Printf("  %s\n", pkg.Description)
+		}
+	}
+}
+
 func handleListCommand(args []string) {
 	envSpec, err := envManager.GetActiveEnv()
 	if err != nil {
@@ -917,6 +1056,46 @@ func handleListCommand(args []string) {
 	fmt.Printf("\nTotal: %d packages\n", len(envSpec.Packages))
 }
 
+// newEnvConfig builds a manager configuration from an environment's install path and settings
+func newEnvConfig(envSpec *env.EnvSpec) *upkg.Config {
+	config := upkg.DefaultConfig()
+	config.InstallPath = envSpec.InstallPath
+	config.Release = envSpec.Release
+	config.Arch = envSpec.Arch
+	config.Mirror = envSpec.Mirror
+	config.Repos = envSpec.Repos
+	config.GPGKeys = envSpec.GPGKeys
+	config.Relocate = envSpec.Relocate
+	config.SetInterpreter = envSpec.SetInterpreter
+	config.Progress = os.Stdout
+	return config
+}
+
+// printEnvSettings prints the backend settings an environment is pinned to
+func printEnvSettings(envSpec *env.EnvSpec) {
+	if envSpec.Release != "" {
+		fmt.Printf("  Release: %s\n", envSpec.Release)
+	}
+	if envSpec.Arch != "" {
+		fmt.Printf("  Arch: %s\n", envSpec.Arch)
+	}
+	if envSpec.Mirror != "" {
+		fmt.Printf("  Mirror: %s\n", envSpec.Mirror)
+	}
+	if len(envSpec.Repos) > 0 {
+		fmt.Printf("  Repos: %s\n", strings.Join(envSpec.Repos, ", "))
+	}
+	if len(envSpec.GPGKeys) > 0 {
+		fmt.Printf("  GPG keys: %s\n", strings.Join(envSpec.GPGKeys, ", "))
+	}
+	if envSpec.Relocate || envSpec.SetInterpreter {
+		fmt.Println("  Relocate: ELF RUNPATHs resolve inside the environment")
+	}
+	if envSpec.SetInterpreter {
+		fmt.Println("  Interpreter: environment's own dynamic loader")
+	}
+}
+
 func mapBackendName(name string) backend.BackendType {
 	switch strings.ToLower(name) {
 	case "apt":
@@ -925,10 +1104,16 @@ func mapBackendName(name string) backend.BackendType {
 		return backend.BackendBrew
 	case "nix":
 		return backend.BackendNix
+	case "guix":
+		return backend.BackendGuix
 	case "dnf":
 		return backend.BackendDnf
+	case "el":
+		return backend.BackendEL
 	case "pacman":
 		return backend.BackendPacman
+	case "msys2":
+		return backend.BackendMSYS2
 	case "apk":
 		return backend.BackendApk
 	case "zypper":
@@ -15,10 +15,11 @@ import (
 
 func main() {
 	var (
-		backendName = flag.String("backend", "auto", "Backend to use (auto, nix, brew, dpkg, apt, apk, dnf, choco, pacman, zypper)")
+		backendName = flag.String("backend", "auto", "Backend to use (auto, nix, guix, brew, dpkg, apt, apk, dnf, el, choco, pacman, msys2, zypper)")
 		pkgName     = flag.String("package", "", "Package name to download")
 		pkgVersion  = flag.String("version", "", "Package version (optional)")
 		platform    = flag.String("platform", "", "Target platform/architecture (optional)")
+		release     = flag.String("release", "", "Distribution release to target, overriding /etc/os-release (e.g. noble, 42, v3.19)")
 		info        = flag.Bool("info", false, "Show package info instead of downloading")
 		search      = flag.String("search", "", "Search for packages by keyword")
 		debug       = flag.Bool("debug", false, "Enable debug logging")
@@ -26,6 +27,9 @@ func main() {
 		noExtract   = flag.Bool("no-extract", false, "Download only, don't extract")
 		keepArchive = flag.Bool("keep-archive", false, "Keep archive file after extraction")
 		noVerify    = flag.Bool("no-verify", false, "Skip hash verification")
+		noGPGCheck  = flag.Bool("no-gpgcheck", false, "Skip package signature verification (dnf, el, zypper, apk, pacman, msys2, nix, guix)")
+		relocate    = flag.Bool("relocate", false, "Rewrite the RUNPATH of the ELF binaries under the install path to resolve inside it")
+		setInterp   = flag.Bool("set-interpreter", false, "Also point those executables at the install path's own dynamic loader (implies -relocate)")
 	)
 	flag.Parse()
 
@@ -39,12 +43,15 @@ func main() {
 		fmt.Println("Backends:")
 		fmt.Println("  auto   - Automatically detect best backend (default)")
 		fmt.Println("  nix    - Nix package manager (Cross-platform)")
+		fmt.Println("  guix   - GNU Guix substitutes (Linux)")
 		fmt.Println("  brew   - Homebrew package manager (macOS/Linux)")
 		fmt.Println("  dpkg   - Debian package manager (Debian-focused)")
 		fmt.Println("  apt    - Ubuntu package manager (Ubuntu-focused)")
 		fmt.Println("  apk    - Alpine package manager (Alpine Linux)")
-		fmt.Println("  dnf    - Fedora package manager (RedHat/Fedora)")
+		fmt.Println("  dnf    - Fedora package manager (Fedora)")
+		fmt.Println("  el     - Enterprise Linux repositories (Rocky/Alma/CentOS Stream/UBI)")
 		fmt.Println("  pacman - Arch Linux package manager (Arch/Manjaro)")
+		fmt.Println("  msys2  - MSYS2 MinGW repositories (Windows libraries, any host)")
 		fmt.Println("  zypper - OpenSUSE package manager (OpenSUSE/SLES)")
 		fmt.Println("  choco  - Chocolatey package manager (Windows)")
 		fmt.Println()
@@ -56,12 +63,17 @@ func main() {
 	// Create configuration
 	config := upkg.DefaultConfig()
 	config.Debug = *debug
+	config.Progress = os.Stdout
 	if *debug {
 		config.Logger = log.New(os.Stdout, "[upkg] ", log.LstdFlags)
 	}
 	if *installPath != "" {
 		config.InstallPath = *installPath
 	}
+	config.Release = *release
+	config.NoGPGCheck = *noGPGCheck
+	config.Relocate = *relocate
+	config.SetInterpreter = *setInterp
 
 	// Determine backend type
 	var backendType upkg.BackendType
@@ -70,6 +82,8 @@ func main() {
 		backendType = upkg.BackendAuto
 	case "nix":
 		backendType = upkg.BackendNix
+	case "guix":
+		backendType = upkg.BackendGuix
 	case "brew":
 		backendType = upkg.BackendBrew
 	case "dpkg":
@@ -80,15 +94,19 @@ func main() {
 		backendType = upkg.BackendApk
 	case "dnf":
 		backendType = upkg.BackendDnf
+	case "el":
+		backendType = upkg.BackendEL
 	case "choco":
 		backendType = upkg.BackendChoco
 	case "pacman":
 		backendType = upkg.BackendPacman
+	case "msys2":
+		backendType = upkg.BackendMSYS2
 	case "zypper":
 		backendType = upkg.BackendZypper
 	default:
 		fmt.Printf("Unknown backend: %s\n", *backendName)
-		fmt.Println("Available backends: auto, nix, brew, dpkg, apt, apk, dnf, choco, pacman, zypper")
+		fmt.Println("Available backends: auto, nix, guix, brew, dpkg, apt, apk, dnf, el, choco, pacman, msys2, zypper")
 		os.Exit(1)
 	}
 
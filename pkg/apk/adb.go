@@ -0,0 +1,469 @@
+// pkg/apk/adb.go
+package apk
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// apk-tools v3 stores indexes and packages in adb, a binary database format:
+//
+//	"ADBd" or "ADBc" <alg> <level>  optional compression of all that follows
+//	"ADB." <schema>                 file header; schema is "indx" or "pckg"
+//	blocks                          each a <type:2 | size:30> header (extended
+//	                                to 16 bytes for large blocks), payload and
+//	                                padding to 8 bytes
+//
+// The first block (ADB) holds the database, SIG blocks sign it and, in
+// packages, DATA blocks carry the contents of regular files.
+
+// adb file header and schemas
+const (
+	adbMagic         = "ADB."
+	adbSchemaIndex   = 0x78646e69 // "indx"
+	adbSchemaPackage = 0x676b6370 // "pckg"
+)
+
+// adb block types
+const (
+	adbBlockADB  = 0
+	adbBlockSig  = 1
+	adbBlockData = 2
+	adbBlockExt  = 3
+)
+
+// Compression algorithms of an "ADBc" header
+const (
+	adbCompNone    = 0
+	adbCompDeflate = 1
+	adbCompZstd    = 2
+)
+
+// adb value types, in the top 4 bits of a value
+const (
+	adbTypeMask   = 0xf0000000
+	adbValueMask  = 0x0fffffff
+	adbTypeInt    = 0x10000000
+	adbTypeInt32  = 0x20000000
+	adbTypeInt64  = 0x30000000
+	adbTypeBlob8  = 0x80000000
+	adbTypeBlob16 = 0x90000000
+	adbTypeBlob32 = 0xa0000000
+	adbTypeArray  = 0xd0000000
+	adbTypeObject = 0xe0000000
+)
+
+// Field indexes of the index, package, pkginfo, dependency, directory, file
+// and ACL objects (apk-tools src/apk_adb.h)
+const (
+	adbiNdxPackages = 0x02
+
+	adbiPkgPkginfo = 0x01
+	adbiPkgPaths   = 0x02
+
+	adbiPiName          = 0x01
+	adbiPiVersion       = 0x02
+	adbiPiHashes        = 0x03
+	adbiPiDescription   = 0x04
+	adbiPiArch          = 0x05
+	adbiPiLicense       = 0x06
+	adbiPiOrigin        = 0x07
+	adbiPiMaintainer    = 0x08
+	adbiPiURL           = 0x09
+	adbiPiRepoCommit    = 0x0a
+	adbiPiBuildTime     = 0x0b
+	adbiPiInstalledSize = 0x0c
+	adbiPiFileSize      = 0x0d
+	adbiPiDepends       = 0x0f
+	adbiPiProvides      = 0x10
+	adbiPiInstallIf     = 0x12
+
+	adbiDepName  = 0x01
+	adbiDepMatch = 0x03
+
+	adbiDiName  = 0x01
+	adbiDiACL   = 0x02
+	adbiDiFiles = 0x03
+
+	adbiFiName   = 0x01
+	adbiFiACL    = 0x02
+	adbiFiSize   = 0x03
+	adbiFiMtime  = 0x04
+	adbiFiHashes = 0x05
+	adbiFiTarget = 0x06
+
+	adbiACLMode = 0x01
+)
+
+// apkDepConflict marks a "!name" dependency
+const apkDepConflict = 16
+
+// isADB reports whether data starts like an adb file, compressed or not
+func isADB(magic []byte) bool {
+	return bytes.HasPrefix(magic, []byte("ADB"))
+}
+
+// openADB strips the compression header of an adb file and returns a
+// reader starting at its "ADB." file header
+func openADB(r io.Reader) (io.ReadCloser, error) {
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, fmt.Errorf("reading adb header: %w", err)
+	}
+	if !isADB(magic) {
+		return nil, fmt.Errorf("not an adb file")
+	}
+
+	alg := adbCompNone
+	switch magic[3] {
+	case '.':
+		return io.NopCloser(io.MultiReader(bytes.NewReader(magic), r)), nil
+	case 'd':
+		alg = adbCompDeflate
+	case 'c':
+		// Algorithm and level bytes
+		comp := make([]byte, 2)
+		if _, err := io.ReadFull(r, comp); err != nil {
+			return nil, fmt.Errorf("reading adb compression header: %w", err)
+		}
+		alg = int(comp[0])
+	default:
+		return nil, fmt.Errorf("unknown adb header %q", magic)
+	}
+
+	switch alg {
+	case adbCompNone:
+		return io.NopCloser(r), nil
+	case adbCompDeflate:
+		return flate.NewReader(r), nil
+	case adbCompZstd:
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("zstd init: %w", err)
+		}
+		return dec.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported adb compression %d", alg)
+	}
+}
+
+// adbFile is a parsed adb file, minus its data blocks
+type adbFile struct {
+	schema     uint32
+	db         adbDB    // Payload of the ADB block
+	signatures [][]byte // Payloads of the SIG blocks
+}
+
+// readADB reads the blocks of an uncompressed adb stream. Data blocks are
+// passed to onData (when set) with the directory and file they belong to;
+// the ADB block always precedes them.
+func readADB(r io.Reader, onData func(f *adbFile, pathIdx, fileIdx uint32, data io.Reader) error) (*adbFile, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("reading adb header: %w", err)
+	}
+	if string(header[:4]) != adbMagic {
+		return nil, fmt.Errorf("not an adb file")
+	}
+	f := &adbFile{schema: binary.LittleEndian.Uint32(header[4:])}
+
+	for {
+		var word [4]byte
+		if _, err := io.ReadFull(br, word[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("reading adb block: %w", err)
+		}
+
+		typeSize := binary.LittleEndian.Uint32(word[:])
+		blockType := typeSize >> 30
+		size := uint64(typeSize & 0x3fffffff)
+		hdrSize := uint64(4)
+		if blockType == adbBlockExt {
+			var ext [12]byte
+			if _, err := io.ReadFull(br, ext[:]); err != nil {
+				return nil, fmt.Errorf("reading adb block: %w", err)
+			}
+			blockType = typeSize & 0x3fffffff
+			size = binary.LittleEndian.Uint64(ext[4:])
+			hdrSize = 16
+		}
+		if size < hdrSize {
+			return nil, fmt.Errorf("invalid adb block size %d", size)
+		}
+
+		payload := &io.LimitedReader{R: br, N: int64(size - hdrSize)}
+		switch {
+		case blockType == adbBlockADB:
+			if f.db != nil {
+				return nil, fmt.Errorf("duplicate adb block")
+			}
+			if size > 256<<20 {
+				return nil, fmt.Errorf("adb block too large (%d bytes)", size)
+			}
+			db, err := io.ReadAll(payload)
+			if err != nil {
+				return nil, fmt.Errorf("reading adb block: %w", err)
+			}
+			f.db = db
+
+		case f.db == nil:
+			return nil, fmt.Errorf("adb file does not start with an adb block")
+
+		case blockType == adbBlockSig:
+			sig, err := io.ReadAll(io.LimitReader(payload, 64*1024))
+			if err != nil {
+				return nil, fmt.Errorf("reading signature block: %w", err)
+			}
+			f.signatures = append(f.signatures, sig)
+
+		case blockType == adbBlockData && onData != nil:
+			var idx [8]byte
+			if _, err := io.ReadFull(payload, idx[:]); err != nil {
+				return nil, fmt.Errorf("reading data block: %w", err)
+			}
+			pathIdx := binary.LittleEndian.Uint32(idx[:4])
+			fileIdx := binary.LittleEndian.Uint32(idx[4:])
+			if err := onData(f, pathIdx, fileIdx, payload); err != nil {
+				return nil, err
+			}
+		}
+
+		// Skip the unread rest of the payload and the alignment padding
+		skip := payload.N + int64((size+7)&^7-size)
+		if _, err := io.CopyN(io.Discard, br, skip); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("reading adb block: %w", err)
+		}
+	}
+
+	if f.db == nil {
+		return nil, fmt.Errorf("adb file has no adb block")
+	}
+	return f, nil
+}
+
+// adbDB is the payload of an ADB block. It starts with an 8 byte header
+// whose last 4 bytes are the root object; values reference data by offset
+// from the start of the payload.
+type adbDB []byte
+
+// adbObj is an object or array: slot 0 holds the number of slots
+type adbObj struct {
+	db    adbDB
+	slots []uint32
+}
+
+// root returns the root object
+func (db adbDB) root() adbObj {
+	if len(db) < 8 {
+		return adbObj{db: db}
+	}
+	return db.object(binary.LittleEndian.Uint32(db[4:8]))
+}
+
+// at returns n bytes at off, or nil when out of range
+func (db adbDB) at(off, n uint64) []byte {
+	if off+n < off || off+n > uint64(len(db)) {
+		return nil
+	}
+	return db[off : off+n]
+}
+
+// object resolves an object or array value
+func (db adbDB) object(v uint32) adbObj {
+	if t := v & adbTypeMask; t != adbTypeObject && t != adbTypeArray {
+		return adbObj{db: db}
+	}
+	off := uint64(v & adbValueMask)
+	count := db.at(off, 4)
+	if count == nil {
+		return adbObj{db: db}
+	}
+	n := uint64(binary.LittleEndian.Uint32(count))
+	raw := db.at(off, 4*n)
+	if raw == nil {
+		return adbObj{db: db}
+	}
+
+	slots := make([]uint32, n)
+	for i := range slots {
+		slots[i] = binary.LittleEndian.Uint32(raw[4*i:])
+	}
+	return adbObj{db: db, slots: slots}
+}
+
+// len returns the number of items of an array (or slots of an object)
+func (o adbObj) len() int {
+	if len(o.slots) == 0 {
+		return 0
+	}
+	return len(o.slots) - 1
+}
+
+// val returns slot i (1-based), or null
+func (o adbObj) val(i int) uint32 {
+	if i < 1 || i >= len(o.slots) {
+		return 0
+	}
+	return o.slots[i]
+}
+
+// obj returns the object or array in slot i
+func (o adbObj) obj(i int) adbObj {
+	return o.db.object(o.val(i))
+}
+
+// blob returns the blob in slot i
+func (o adbObj) blob(i int) []byte {
+	v := o.val(i)
+	off := uint64(v & adbValueMask)
+
+	var lenSize uint64
+	switch v & adbTypeMask {
+	case adbTypeBlob8:
+		lenSize = 1
+	case adbTypeBlob16:
+		lenSize = 2
+	case adbTypeBlob32:
+		lenSize = 4
+	default:
+		return nil
+	}
+
+	raw := o.db.at(off, lenSize)
+	if raw == nil {
+		return nil
+	}
+	var n uint64
+	switch lenSize {
+	case 1:
+		n = uint64(raw[0])
+	case 2:
+		n = uint64(binary.LittleEndian.Uint16(raw))
+	case 4:
+		n = uint64(binary.LittleEndian.Uint32(raw))
+	}
+	return o.db.at(off+lenSize, n)
+}
+
+// str returns the string in slot i
+func (o adbObj) str(i int) string {
+	return string(o.blob(i))
+}
+
+// int returns the integer in slot i
+func (o adbObj) int(i int) uint64 {
+	v := o.val(i)
+	off := uint64(v & adbValueMask)
+
+	switch v & adbTypeMask {
+	case adbTypeInt:
+		return off
+	case adbTypeInt32:
+		if raw := o.db.at(off, 4); raw != nil {
+			return uint64(binary.LittleEndian.Uint32(raw))
+		}
+	case adbTypeInt64:
+		if raw := o.db.at(off, 8); raw != nil {
+			return binary.LittleEndian.Uint64(raw)
+		}
+	}
+	return 0
+}
+
+// parseADBIndex parses a v3 index (Packages.adb)
+func parseADBIndex(r io.Reader) ([]*PackageInfo, error) {
+	stream, err := openADB(r)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	f, err := readADB(stream, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.schema != adbSchemaIndex {
+		return nil, fmt.Errorf("adb file is not an index (schema %08x)", f.schema)
+	}
+
+	list := f.db.root().obj(adbiNdxPackages)
+	packages := make([]*PackageInfo, 0, list.len())
+	for i := 1; i <= list.len(); i++ {
+		if pkg := pkginfoFromADB(list.obj(i)); pkg.Package != "" {
+			packages = append(packages, pkg)
+		}
+	}
+
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("no packages found in adb index")
+	}
+	return packages, nil
+}
+
+// pkginfoFromADB converts a pkginfo object to the fields an APKINDEX stanza carries
+func pkginfoFromADB(pi adbObj) *PackageInfo {
+	pkg := &PackageInfo{
+		Package:       pi.str(adbiPiName),
+		Version:       pi.str(adbiPiVersion),
+		Architecture:  pi.str(adbiPiArch),
+		PackageSize:   int64(pi.int(adbiPiFileSize)),
+		InstalledSize: int64(pi.int(adbiPiInstalledSize)),
+		Description:   pi.str(adbiPiDescription),
+		URL:           pi.str(adbiPiURL),
+		License:       pi.str(adbiPiLicense),
+		Origin:        pi.str(adbiPiOrigin),
+		Maintainer:    pi.str(adbiPiMaintainer),
+		BuildTime:     int64(pi.int(adbiPiBuildTime)),
+		Depends:       adbDependencies(pi.obj(adbiPiDepends)),
+		Provides:      adbDependencies(pi.obj(adbiPiProvides)),
+		InstallIf:     adbDependencies(pi.obj(adbiPiInstallIf)),
+	}
+
+	if commit := pi.blob(adbiPiRepoCommit); len(commit) > 0 {
+		pkg.Commit = hex.EncodeToString(commit)
+	}
+
+	// The package identity, printed like APKINDEX's C: field
+	switch hash := pi.blob(adbiPiHashes); len(hash) {
+	case 20:
+		pkg.Checksum = "Q1" + base64.StdEncoding.EncodeToString(hash)
+	case 32:
+		pkg.Checksum = "Q2" + base64.StdEncoding.EncodeToString(hash)
+	}
+
+	return pkg
+}
+
+// adbDependencies returns the names of a dependency array, without version
+// constraints (as parseAPKList does for APKINDEX); conflicts are dropped
+func adbDependencies(deps adbObj) []string {
+	var names []string
+	for i := 1; i <= deps.len(); i++ {
+		dep := deps.obj(i)
+		if dep.int(adbiDepMatch)&apkDepConflict != 0 {
+			continue
+		}
+		if name := dep.str(adbiDepName); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// adbFileAt returns the file object a data block belongs to
+func adbFileAt(db adbDB, pathIdx, fileIdx uint32) adbObj {
+	return db.root().obj(adbiPkgPaths).obj(int(pathIdx)).obj(adbiDiFiles).obj(int(fileIdx))
+}
@@ -16,6 +16,18 @@ const (
 
 	// DefaultInstallPath is where packages will be extracted
 	DefaultInstallPath = "/opt/upkg"
+
+	// DefaultRepositoriesFile lists the repositories of an Alpine system
+	DefaultRepositoriesFile = "/etc/apk/repositories"
+
+	// IndexFile is the repository index of apk-tools v2 repositories
+	IndexFile = "APKINDEX.tar.gz"
+
+	// IndexFileV3 is the adb repository index of apk-tools v3 repositories
+	IndexFileV3 = "Packages.adb"
+
+	// AlpineReleaseFile holds the running Alpine version ("3.19.1")
+	AlpineReleaseFile = "/etc/alpine-release"
 )
 
 // Common Alpine branches
@@ -33,4 +45,22 @@ const (
 	RepoMain      = "main"      // Main packages
 	RepoCommunity = "community" // Community packages
 	RepoTesting   = "testing"   // Testing packages (edge only)
-)
\ No newline at end of file
+)
+
+// DefaultRepositories are the repositories indexed when Config.Repositories is empty, in preference order
+var DefaultRepositories = []string{"main", "community"}
+
+// Signing keys
+const (
+	// DefaultKeysDir is where apk keeps trusted signing keys
+	DefaultKeysDir = "/etc/apk/keys"
+
+	// AlpineKeysURL publishes the official Alpine signing keys. upkg embeds
+	// them, so nothing is fetched from here unless Config.Keys lists this
+	// URL; official keys missing locally are then fetched over HTTPS (never
+	// from the mirror) and pinned in the cache.
+	AlpineKeysURL = "https://alpinelinux.org/keys"
+
+	// AlpineKeyPrefix starts the file name of every official Alpine key
+	AlpineKeyPrefix = "alpine-devel@lists.alpinelinux.org-"
+)
@@ -2,8 +2,10 @@
 package apk
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 )
@@ -18,43 +20,65 @@ func (pm *PackageManager) updatePackageIndex(ctx context.Context, arch Architect
 
 	pm.logger.Printf("Fetching package index from repositories...")
 
+	repositories, err := pm.repositories()
+	if err != nil {
+		return err
+	}
+
 	// Clear/Init cache
 	pm.cache.packages = make(map[string]*PackageInfo)
 	pm.cache.providers = make(map[string][]*PackageInfo)
-
-	// Repositories to index (order matters for preference)
-	repositories := []string{"main", "community"}
-	
-	// If config specifies a repo that isn't standard, add it (e.g. testing)
-	if pm.config.Repository != "main" && pm.config.Repository != "community" {
-		repositories = append(repositories, pm.config.Repository)
-	}
+	pm.cache.tagged = make(map[string]map[string]*PackageInfo)
 
 	totalPackages := 0
 	var lastErr error
 
-	for _, repo := range repositories {
-		// Construct URL for APKINDEX.tar.gz
+	for _, repository := range repositories {
+		repo := repository.Name
+		if repository.Tag != "" {
+			repo = repository.Name + "@" + repository.Tag
+		}
+
 		// e.g. https://dl-cdn.alpinelinux.org/alpine/v3.19/main/x86_64/APKINDEX.tar.gz
-		url := fmt.Sprintf("%s/%s/%s/%s/APKINDEX.tar.gz",
-			pm.config.RepositoryURL,
-			pm.config.Branch,
-			repo,
-			arch)
+		url := fmt.Sprintf("%s/%s/%s", repository.URL, arch, IndexFile)
 
 		pm.logger.Printf("  Fetching %s repository: %s", repo, url)
 
-		// Download APKINDEX
-		resp, err := pm.client.Get(ctx, url)
+		// Download APKINDEX, or the adb index of a v3-only repository
+		body, err := pm.openRepositoryFile(ctx, url)
+		if err != nil {
+			url = fmt.Sprintf("%s/%s/%s", repository.URL, arch, IndexFileV3)
+			if v3, v3Err := pm.openRepositoryFile(ctx, url); v3Err == nil {
+				pm.logger.Printf("  Using v3 index: %s", url)
+				body, err = v3, nil
+			}
+		}
+		if err != nil {
+			pm.logger.Printf("  ⚠️  Warning: failed to fetch %s repository: %v", repo, err)
+			lastErr = err
+			continue
+		}
+
+		data, err := io.ReadAll(body)
+		body.Close()
 		if err != nil {
 			pm.logger.Printf("  ⚠️  Warning: failed to fetch %s repository: %v", repo, err)
 			lastErr = err
 			continue
 		}
 
+		// The index signature covers every C: checksum in it, so a repository
+		// whose signature does not verify is skipped altogether
+		if !pm.config.AllowUntrusted {
+			if err := pm.verifyIndex(ctx, data); err != nil {
+				pm.logger.Printf("  ⚠️  Warning: skipping %s repository: %v", repo, err)
+				lastErr = fmt.Errorf("verifying %s APKINDEX: %w", repo, err)
+				continue
+			}
+		}
+
 		// Parse packages using existing parser
-		packages, err := ParseAPKINDEX(resp.Body)
-		resp.Body.Close()
+		packages, err := ParseAPKINDEX(bytes.NewReader(data))
 		if err != nil {
 			pm.logger.Printf("  ⚠️  Warning: failed to parse %s repository: %v", repo, err)
 			lastErr = err
@@ -64,14 +88,22 @@ func (pm *PackageManager) updatePackageIndex(ctx context.Context, arch Architect
 		// Index the packages
 		count := 0
 		for _, pkg := range packages {
-			pkg.Repository = repo // Store origin repo
+			pkg.Repository = repository.Name // Store origin repo
+			pkg.RepositoryURL = repository.URL
+			pkg.Tag = repository.Tag
 			
 			// 1. Map Name -> Package
-			// Note: This overwrites if same package exists in multiple repos. 
-			// Since we iterate main -> community, community might overwrite main if duplicates exist.
-			// Usually we want main to take precedence.
-			if _, exists := pm.cache.packages[pkg.Package]; !exists {
-				pm.cache.packages[pkg.Package] = pkg
+			// The first repository listing a name wins, so earlier repositories take
+			// precedence. Tagged repositories are indexed apart, per tag.
+			byName := pm.cache.packages
+			if pkg.Tag != "" {
+				if pm.cache.tagged[pkg.Tag] == nil {
+					pm.cache.tagged[pkg.Tag] = make(map[string]*PackageInfo)
+				}
+				byName = pm.cache.tagged[pkg.Tag]
+			}
+			if _, exists := byName[pkg.Package]; !exists {
+				byName[pkg.Package] = pkg
 			}
 
 			// 2. Map Provides -> Package (The Reverse Lookup)
@@ -105,8 +137,17 @@ func (pm *PackageManager) updatePackageIndex(ctx context.Context, arch Architect
 	return nil
 }
 
-// pickBestProvider selects the best package from a list of providers
-func (pm *PackageManager) pickBestProvider(providers []*PackageInfo) *PackageInfo {
+// pickBestProvider selects the best package from a list of providers.
+// Packages from tagged repositories are only eligible when tag pins them.
+func (pm *PackageManager) pickBestProvider(providers []*PackageInfo, tag string) *PackageInfo {
+	var eligible []*PackageInfo
+	for _, pkg := range providers {
+		if pkg.Tag == "" || pkg.Tag == tag {
+			eligible = append(eligible, pkg)
+		}
+	}
+	providers = eligible
+
 	if len(providers) == 0 {
 		return nil
 	}
@@ -116,30 +157,39 @@ func (pm *PackageManager) pickBestProvider(providers []*PackageInfo) *PackageInf
 		return providers[0]
 	}
 
-	// 2. Preference: Prefer 'main' repository over others
+	// 2. Preference: the repository the request is pinned to
+	if tag != "" {
+		for _, pkg := range providers {
+			if pkg.Tag == tag {
+				return pkg
+			}
+		}
+	}
+
+	// 3. Preference: Prefer 'main' repository over others
 	for _, pkg := range providers {
 		if pkg.Repository == "main" {
 			return pkg
 		}
 	}
 
-	// 3. Preference: Prefer 'community' over testing/others
+	// 4. Preference: Prefer 'community' over testing/others
 	for _, pkg := range providers {
 		if pkg.Repository == "community" {
 			return pkg
 		}
 	}
 
-	// 4. Fallback: Shortest name? (Heuristic: "bash" is better than "bash-doc")
+	// 5. Fallback: Shortest name? (Heuristic: "bash" is better than "bash-doc")
 	// Or just return the first one
 	return providers[0]
 }
 
 // resolveVirtualPackage attempts to find a package that provides the requested virtual name
-func (pm *PackageManager) resolveVirtualPackage(name string) (*PackageInfo, error) {
+func (pm *PackageManager) resolveVirtualPackage(name, tag string) (*PackageInfo, error) {
 	// Direct lookup in providers map
 	if providers, ok := pm.cache.providers[name]; ok {
-		best := pm.pickBestProvider(providers)
+		best := pm.pickBestProvider(providers, tag)
 		if best != nil {
 			return best, nil
 		}
@@ -150,7 +200,7 @@ func (pm *PackageManager) resolveVirtualPackage(name string) (*PackageInfo, erro
 	if idx := strings.IndexAny(name, "=<>~"); idx != -1 {
 		cleanName := name[:idx]
 		if providers, ok := pm.cache.providers[cleanName]; ok {
-			best := pm.pickBestProvider(providers)
+			best := pm.pickBestProvider(providers, tag)
 			if best != nil {
 				return best, nil
 			}
@@ -0,0 +1,45 @@
+// pkg/apk/keys.go
+package apk
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+//go:generate sh keys/update.sh
+
+// embeddedKeys holds the official Alpine signing keys shipped with upkg
+//
+//go:embed keys
+var embeddedKeys embed.FS
+
+// embeddedKey returns an embedded key by file name
+func embeddedKey(name string) ([]byte, bool) {
+	data, err := embeddedKeys.ReadFile(path.Join("keys", name))
+	return data, err == nil
+}
+
+// embeddedKeyNames lists the file names of the embedded keys
+func embeddedKeyNames() []string {
+	entries, _ := fs.ReadDir(embeddedKeys, "keys")
+	var names []string
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".pub") {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+// fetchAlpineKeys reports whether official keys missing locally may be
+// fetched from AlpineKeysURL, which Config.Keys opts into by listing it
+func (pm *PackageManager) fetchAlpineKeys() bool {
+	for _, source := range pm.config.Keys {
+		if strings.TrimSuffix(source, "/") == AlpineKeysURL {
+			return true
+		}
+	}
+	return false
+}
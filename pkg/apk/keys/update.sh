@@ -0,0 +1,20 @@
+#!/bin/sh
+# Fetches the official Alpine signing keys into this directory. Run through
+# `go generate ./pkg/apk` with network access, review the diff and commit it.
+set -eu
+
+url=https://alpinelinux.org/keys
+dir=$(dirname "$0")
+
+names=$(curl -fsSL "$url/" | grep -o 'alpine-devel@lists\.alpinelinux\.org-[0-9a-f]*\.rsa\.pub' | sort -u)
+if [ -z "$names" ]; then
+	echo "no keys listed at $url" >&2
+	exit 1
+fi
+
+for name in $names; do
+	curl -fsSL -o "$dir/$name.tmp" "$url/$name"
+	grep -q 'BEGIN PUBLIC KEY' "$dir/$name.tmp"
+	mv "$dir/$name.tmp" "$dir/$name"
+	echo "$name"
+done
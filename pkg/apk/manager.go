@@ -3,10 +3,12 @@ package apk
 
 import (
 	"archive/tar"
+	"bufio"
+	"bytes"
 	"compress/gzip"
 	"context"
-	"crypto/sha1"
-	"encoding/hex"
+	"crypto/rsa"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"log"
@@ -26,12 +28,23 @@ func NewPackageManager(cfg *Config) *PackageManager {
 	if cfg.RepositoryURL == "" {
 		cfg.RepositoryURL = DefaultRepositoryURL
 	}
+	if cfg.Branch == "" {
+		cfg.Branch = DetectBranch()
+	}
 	if cfg.Branch == "" {
 		cfg.Branch = DefaultBranch
 	}
 	if cfg.Repository == "" {
 		cfg.Repository = DefaultRepository
 	}
+	// A repositories file or explicit repository URLs replace the branch's
+	// default repositories rather than adding to them
+	if len(cfg.Repositories) == 0 && cfg.RepositoriesFile == "" && len(cfg.RepositoryURLs) == 0 {
+		cfg.Repositories = DefaultRepositories
+	}
+	if len(cfg.KeysDirs) == 0 {
+		cfg.KeysDirs = []string{DefaultKeysDir}
+	}
 	if cfg.InstallPath == "" {
 		cfg.InstallPath = DefaultInstallPath
 	}
@@ -60,13 +73,24 @@ func NewPackageManager(cfg *Config) *PackageManager {
 		cache: &PackageCache{
 			packages:      make(map[string]*PackageInfo),
 			providers:     make(map[string][]*PackageInfo),
+			tagged:        make(map[string]map[string]*PackageInfo),
 			cacheDuration: 30 * time.Minute,
 		},
+		keys: make(map[string]*rsa.PublicKey),
 	}
 
 	if cfg.Debug {
 		pm.logger.Printf("Initialized Alpine APK PackageManager")
 		pm.logger.Printf("  Branch: %s", cfg.Branch)
+		if cfg.RepositoriesFile != "" {
+			pm.logger.Printf("  Repositories file: %s", cfg.RepositoriesFile)
+		}
+		for _, line := range cfg.RepositoryURLs {
+			pm.logger.Printf("  Repository: %s", line)
+		}
+		if cfg.AllowUntrusted {
+			pm.logger.Printf("  ⚠️  Warning: signature checking disabled")
+		}
 	}
 
 	return pm
@@ -80,6 +104,11 @@ func (pm *PackageManager) Download(ctx context.Context, opts *DownloadOptions) e
 
 	pm.logger.Printf("Starting installation for package: %s", opts.Package)
 
+	// "name@tag" pins the package to the repositories tagged @tag, as in apk
+	if name, tag := SplitTag(opts.Package); tag != "" {
+		opts.Package, opts.Tag = name, tag
+	}
+
 	// Set defaults
 	if opts.Architecture == "" {
 		detected, err := DetectArchitecture()
@@ -105,7 +134,7 @@ func (pm *PackageManager) Download(ctx context.Context, opts *DownloadOptions) e
 // installRecursive handles the actual download, dependency resolution, and extraction
 func (pm *PackageManager) installRecursive(ctx context.Context, opts *DownloadOptions, visited map[string]bool) error {
 	// 1. Find package info (resolves virtual names like "so:libssl.so.3" to "libssl3")
-	pkgInfo, err := pm.findPackage(opts.Package, opts.Version, opts.Architecture)
+	pkgInfo, err := pm.findPackage(opts.Package, opts.Version, opts.Tag, opts.Architecture)
 	if err != nil {
 		// Alpine dependencies often use virtual names. If we can't find it even after
 		// our new resolution logic, it's a real error.
@@ -133,6 +162,7 @@ func (pm *PackageManager) installRecursive(ctx context.Context, opts *DownloadOp
 			depOpts := *opts // Shallow copy
 			depOpts.Package = depName
 			depOpts.Version = "" // Use latest/resolved version for deps
+			// depOpts.Tag is inherited: dependencies may come from the pinned repository too
 			
 			if err := pm.installRecursive(ctx, &depOpts, visited); err != nil {
 				pm.logger.Printf("  ⚠️  Warning: failed to install dependency %s: %v", depName, err)
@@ -146,11 +176,9 @@ func (pm *PackageManager) installRecursive(ctx context.Context, opts *DownloadOp
 		fmt.Sprintf("%s-%s.apk", pkgInfo.Package, pkgInfo.Version))
 
 	// Construct URL using the package's specific repository
-	// URL: {base}/{branch}/{repo}/{arch}/{pkg}-{ver}.apk
-	url := fmt.Sprintf("%s/%s/%s/%s/%s-%s.apk",
-		pm.config.RepositoryURL,
-		pm.config.Branch,
-		pkgInfo.Repository,
+	// URL: {repository}/{arch}/{pkg}-{ver}.apk
+	url := fmt.Sprintf("%s/%s/%s-%s.apk",
+		pkgInfo.RepositoryURL,
 		opts.Architecture,
 		pkgInfo.Package,
 		pkgInfo.Version)
@@ -159,11 +187,10 @@ func (pm *PackageManager) installRecursive(ctx context.Context, opts *DownloadOp
 		return fmt.Errorf("downloading package %s: %w", pkgInfo.Package, err)
 	}
 
-	// 4. Verify hash
-	if opts.VerifyHash && pkgInfo.Checksum != "" {
-		if err := pm.verifyFileHash(apkPath, pkgInfo.Checksum); err != nil {
-			return fmt.Errorf("hash verification failed for %s: %w", pkgInfo.Package, err)
-		}
+	// 4. Verify signature, control checksum and datahash
+	if err := pm.verifyPackage(ctx, pkgInfo, apkPath, opts.VerifyHash); err != nil {
+		os.Remove(apkPath)
+		return fmt.Errorf("verification failed for %s: %w", pkgInfo.Package, err)
 	}
 
 	// 5. Extract package
@@ -183,9 +210,20 @@ func (pm *PackageManager) installRecursive(ctx context.Context, opts *DownloadOp
 	return nil
 }
 
-// findPackage finds a package using the cache and provider map
-func (pm *PackageManager) findPackage(name, version string, arch Architecture) (*PackageInfo, error) {
+// findPackage finds a package using the cache and provider map. With a tag,
+// the repositories carrying that tag are searched before untagged ones.
+func (pm *PackageManager) findPackage(name, version, tag string, arch Architecture) (*PackageInfo, error) {
 	// 1. Try exact match in package map
+	if tag != "" {
+		if _, ok := pm.cache.tagged[tag]; !ok {
+			return nil, fmt.Errorf("no repository is tagged @%s", tag)
+		}
+		if pkg, ok := pm.cache.tagged[tag][name]; ok {
+			if version == "" || pkg.Version == version {
+				return pkg, nil
+			}
+		}
+	}
 	if pkg, ok := pm.cache.packages[name]; ok {
 		if version == "" || pkg.Version == version {
 			return pkg, nil
@@ -193,7 +231,7 @@ func (pm *PackageManager) findPackage(name, version string, arch Architecture) (
 	}
 
 	// 2. Try resolving as a virtual package (Provides)
-	if pkg, err := pm.resolveVirtualPackage(name); err == nil {
+	if pkg, err := pm.resolveVirtualPackage(name, tag); err == nil {
 		if version == "" || pkg.Version == version {
 			return pkg, nil
 		}
@@ -221,8 +259,16 @@ func (pm *PackageManager) downloadPackage(ctx context.Context, url, destPath str
 	}
 	defer f.Close()
 
-	// Download
-	_, err = pm.client.Download(ctx, url, f)
+	// Download, or copy from a local repository
+	if isLocal(url) {
+		var src io.ReadCloser
+		if src, err = pm.openRepositoryFile(ctx, url); err == nil {
+			_, err = io.Copy(f, src)
+			src.Close()
+		}
+	} else {
+		_, err = pm.client.Download(ctx, url, f)
+	}
 	if err != nil {
 		os.Remove(destPath) // Clean up partial
 		return fmt.Errorf("downloading: %w", err)
@@ -231,32 +277,7 @@ func (pm *PackageManager) downloadPackage(ctx context.Context, url, destPath str
 	return nil
 }
 
-// verifyFileHash verifies the SHA1 hash of a file
-func (pm *PackageManager) verifyFileHash(filePath, expectedHash string) error {
-	f, err := os.Open(filePath)
-	if err != nil {
-		return fmt.Errorf("opening file: %w", err)
-	}
-	defer f.Close()
-
-	hasher := sha1.New()
-	if _, err := io.Copy(hasher, f); err != nil {
-		return fmt.Errorf("computing hash: %w", err)
-	}
-
-	actualHash := hex.EncodeToString(hasher.Sum(nil))
-
-	// Alpine uses Q prefix for SHA1 hashes in the format Q1{hash}
-	expectedHashClean := strings.TrimPrefix(expectedHash, "Q1")
-
-	if !strings.EqualFold(actualHash, expectedHashClean) {
-		return fmt.Errorf("hash mismatch: expected %s, got %s", expectedHashClean, actualHash)
-	}
-
-	return nil
-}
-
-// extractAPKPackage extracts an .apk package
+// extractAPKPackage extracts an .apk package, v2 (tar.gz segments) or v3 (adb)
 func (pm *PackageManager) extractAPKPackage(apkPath, installPath string) error {
 	f, err := os.Open(apkPath)
 	if err != nil {
@@ -264,7 +285,12 @@ func (pm *PackageManager) extractAPKPackage(apkPath, installPath string) error {
 	}
 	defer f.Close()
 
-	gzReader, err := gzip.NewReader(f)
+	br := bufio.NewReader(f)
+	if magic, _ := br.Peek(4); isADB(magic) {
+		return pm.extractADBPackage(br, installPath)
+	}
+
+	gzReader, err := gzip.NewReader(br)
 	if err != nil {
 		return fmt.Errorf("creating gzip reader: %w", err)
 	}
@@ -313,6 +339,146 @@ func (pm *PackageManager) extractAPKPackage(apkPath, installPath string) error {
 	return nil
 }
 
+// extractADBPackage extracts a v3 package. The ADB block lists directories
+// and files; symlinks, hardlinks and empty files are created from it, and
+// the data blocks that follow carry the contents of the other files.
+func (pm *PackageManager) extractADBPackage(r io.Reader, installPath string) error {
+	stream, err := openADB(r)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	root, err := filepath.Abs(installPath)
+	if err != nil {
+		return err
+	}
+
+	// safePath joins a package path to the install directory, refusing escapes
+	safePath := func(name string) (string, error) {
+		target := filepath.Join(root, filepath.Clean("/"+name))
+		if target == root || strings.HasPrefix(target, root+string(filepath.Separator)) {
+			return target, nil
+		}
+		return "", fmt.Errorf("path %s escapes install directory", name)
+	}
+
+	var hardlinks [][2]string // link, target
+	prepared := false
+
+	// prepare creates the tree described by the ADB block
+	prepare := func(db adbDB) error {
+		prepared = true
+		paths := db.root().obj(adbiPkgPaths)
+		for i := 1; i <= paths.len(); i++ {
+			dir := paths.obj(i)
+			dirPath, err := safePath(dir.str(adbiDiName))
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(dirPath, 0755); err != nil {
+				return fmt.Errorf("creating dir: %w", err)
+			}
+
+			files := dir.obj(adbiDiFiles)
+			for j := 1; j <= files.len(); j++ {
+				fi := files.obj(j)
+				targetPath, err := safePath(filepath.Join(dir.str(adbiDiName), fi.str(adbiFiName)))
+				if err != nil {
+					return err
+				}
+
+				// Special files carry their type in the first two bytes of the target
+				if target := fi.blob(adbiFiTarget); len(target) >= 2 {
+					switch binary.LittleEndian.Uint16(target) & 0170000 {
+					case 0120000: // symlink
+						os.Remove(targetPath)
+						if err := os.Symlink(string(target[2:]), targetPath); err != nil {
+							return fmt.Errorf("creating symlink: %w", err)
+						}
+					case 0100000: // hardlink to another file of the package
+						hardlinks = append(hardlinks, [2]string{targetPath, string(target[2:])})
+					}
+					continue
+				}
+
+				if fi.int(adbiFiSize) == 0 {
+					if err := writeADBFile(targetPath, fi, bytes.NewReader(nil)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	f, err := readADB(stream, func(f *adbFile, pathIdx, fileIdx uint32, data io.Reader) error {
+		if !prepared {
+			if err := prepare(f.db); err != nil {
+				return err
+			}
+		}
+		dir := f.db.root().obj(adbiPkgPaths).obj(int(pathIdx))
+		fi := dir.obj(adbiDiFiles).obj(int(fileIdx))
+		targetPath, err := safePath(filepath.Join(dir.str(adbiDiName), fi.str(adbiFiName)))
+		if err != nil {
+			return err
+		}
+		return writeADBFile(targetPath, fi, data)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Packages of symlinks and empty files have no data blocks
+	if !prepared {
+		if err := prepare(f.db); err != nil {
+			return err
+		}
+	}
+
+	for _, link := range hardlinks {
+		target, err := safePath(link[1])
+		if err != nil {
+			return err
+		}
+		os.Remove(link[0])
+		if err := os.Link(target, link[0]); err != nil {
+			return fmt.Errorf("creating hardlink: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// writeADBFile writes a regular file with the mode and mtime of its adb entry
+func writeADBFile(targetPath string, fi adbObj, data io.Reader) error {
+	mode := os.FileMode(fi.obj(adbiFiACL).int(adbiACLMode) & 0777)
+	if mode == 0 {
+		mode = 0644
+	}
+
+	os.MkdirAll(filepath.Dir(targetPath), 0755)
+	os.Remove(targetPath)
+	outFile, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(outFile, data); err != nil {
+		outFile.Close()
+		return err
+	}
+	if err := outFile.Close(); err != nil {
+		return err
+	}
+
+	if mtime := fi.int(adbiFiMtime); mtime != 0 {
+		t := time.Unix(int64(mtime), 0)
+		os.Chtimes(targetPath, t, t)
+	}
+	return nil
+}
+
 // GetPackageInfo retrieves information about a package
 func (pm *PackageManager) GetPackageInfo(ctx context.Context, name string, arch Architecture) (*PackageInfo, error) {
 	if arch == "" {
@@ -327,7 +493,8 @@ func (pm *PackageManager) GetPackageInfo(ctx context.Context, name string, arch
 		return nil, err
 	}
 
-	return pm.findPackage(name, "", arch)
+	name, tag := SplitTag(name)
+	return pm.findPackage(name, "", tag, arch)
 }
 
 // SearchPackages searches for packages by name
@@ -347,12 +514,20 @@ func (pm *PackageManager) SearchPackages(ctx context.Context, query string, arch
 	var results []*PackageInfo
 	query = strings.ToLower(query)
 
-	for _, pkg := range pm.cache.packages {
+	match := func(pkg *PackageInfo) {
 		if strings.Contains(strings.ToLower(pkg.Package), query) ||
 			strings.Contains(strings.ToLower(pkg.Description), query) {
 			results = append(results, pkg)
 		}
 	}
+	for _, pkg := range pm.cache.packages {
+		match(pkg)
+	}
+	for _, byName := range pm.cache.tagged {
+		for _, pkg := range byName {
+			match(pkg)
+		}
+	}
 
 	return results, nil
 }
\ No newline at end of file
@@ -11,10 +11,16 @@ import (
 	"strings"
 )
 
-// ParseAPKINDEX parses an Alpine APKINDEX file
+// ParseAPKINDEX parses an Alpine package index: a v2 APKINDEX.tar.gz or a
+// v3 adb index, told apart by their magic bytes
 func ParseAPKINDEX(r io.Reader) ([]*PackageInfo, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(4); isADB(magic) {
+		return parseADBIndex(br)
+	}
+
 	// APKINDEX.tar.gz contains multiple files: signature file(s) and APKINDEX
-	gzReader, err := gzip.NewReader(r)
+	gzReader, err := gzip.NewReader(br)
 	if err != nil {
 		return nil, fmt.Errorf("creating gzip reader: %w", err)
 	}
@@ -4,6 +4,7 @@ package apk
 import (
 	"fmt"
 	"runtime"
+	"strings"
 )
 
 // Architecture represents an Alpine architecture
@@ -78,4 +79,25 @@ func (a Architecture) IsValid() bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+
+// BranchFor converts an Alpine release into a repository branch name.
+// Accepts os-release VERSION_ID values ("3.19.1"), bare versions ("3.20"),
+// branch names ("v3.19", "edge") and pre-release IDs ("3.21_alpha20240923",
+// which only exist on edge).
+func BranchFor(release string) string {
+	release = strings.TrimSpace(release)
+	if release == "" || release == BranchEdge {
+		return release
+	}
+	if strings.Contains(release, "_") {
+		return BranchEdge
+	}
+
+	version := strings.TrimPrefix(release, "v")
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return release
+	}
+	return "v" + parts[0] + "." + parts[1]
+}
@@ -0,0 +1,175 @@
+// pkg/apk/repositories.go
+package apk
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Repository is one apk repository: a base URL holding <arch>/APKINDEX.tar.gz
+// and the packages it lists. A local repository's URL is an absolute path.
+type Repository struct {
+	URL  string // Base URL (https://dl-cdn.alpinelinux.org/alpine/v3.19/main) or directory (/media/cdrom/apks)
+	Tag  string // Pin tag without the "@"; tagged repositories only serve name@tag requests
+	Name string // Last path element (main, community, testing, os)
+}
+
+// ParseRepositoryLine parses one line of /etc/apk/repositories:
+// "[@tag] url". Blank lines and comments yield nil.
+func ParseRepositoryLine(line string) (*Repository, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+
+	repo := &Repository{}
+	fields := strings.Fields(line)
+	if strings.HasPrefix(fields[0], "@") {
+		repo.Tag = strings.TrimPrefix(fields[0], "@")
+		fields = fields[1:]
+		if repo.Tag == "" || len(fields) == 0 {
+			return nil, fmt.Errorf("invalid repository line %q", line)
+		}
+	}
+	if len(fields) != 1 {
+		return nil, fmt.Errorf("invalid repository line %q", line)
+	}
+
+	// Local repositories are absolute paths; file:// URLs are accepted too
+	repo.URL = strings.TrimSuffix(fields[0], "/")
+	if dir, ok := strings.CutPrefix(repo.URL, "file://"); ok {
+		repo.URL = dir
+	}
+	repo.Name = path.Base(repo.URL)
+	return repo, nil
+}
+
+// isLocal reports whether a repository URL, or a file under one, is a path
+// on disk rather than a URL
+func isLocal(location string) bool {
+	return strings.HasPrefix(location, "/")
+}
+
+// openRepositoryFile opens a file of a repository: local repositories are
+// read from disk, the others are fetched over HTTP(S)
+func (pm *PackageManager) openRepositoryFile(ctx context.Context, location string) (io.ReadCloser, error) {
+	if isLocal(location) {
+		return os.Open(location)
+	}
+	resp, err := pm.client.Get(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ParseRepositories parses an apk repositories file
+func ParseRepositories(r io.Reader) ([]*Repository, error) {
+	var repos []*Repository
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		repo, err := ParseRepositoryLine(scanner.Text())
+		if err != nil {
+			return nil, err
+		}
+		if repo != nil {
+			repos = append(repos, repo)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return repos, nil
+}
+
+// LoadRepositories reads an apk repositories file such as /etc/apk/repositories
+func LoadRepositories(file string) ([]*Repository, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading repositories file: %w", err)
+	}
+	defer f.Close()
+
+	repos, err := ParseRepositories(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", file, err)
+	}
+	return repos, nil
+}
+
+// DetectBranch returns the branch of the running Alpine system from
+// /etc/alpine-release, or "" when the file is absent
+func DetectBranch() string {
+	data, err := os.ReadFile(AlpineReleaseFile)
+	if err != nil {
+		return ""
+	}
+	return BranchFor(string(data))
+}
+
+// SplitTag splits an apk package request "name@tag" into name and tag
+func SplitTag(request string) (string, string) {
+	name, tag, _ := strings.Cut(request, "@")
+	return name, tag
+}
+
+// repositories returns the repositories to index, in preference order: the
+// repositories file if one is configured, otherwise the named repositories of
+// the configured branch, followed by any extra repository lines
+func (pm *PackageManager) repositories() ([]*Repository, error) {
+	var repos []*Repository
+
+	if pm.config.RepositoriesFile != "" {
+		fromFile, err := LoadRepositories(pm.config.RepositoriesFile)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, fromFile...)
+	} else if len(pm.config.Repositories) > 0 {
+		names := append([]string(nil), pm.config.Repositories...)
+
+		// If config specifies a repo that isn't listed, add it (e.g. testing)
+		listed := false
+		for _, name := range names {
+			if name == pm.config.Repository {
+				listed = true
+				break
+			}
+		}
+		if !listed && pm.config.Repository != "" {
+			names = append(names, pm.config.Repository)
+		}
+
+		// e.g. https://dl-cdn.alpinelinux.org/alpine/v3.19/main
+		for _, name := range names {
+			repos = append(repos, &Repository{
+				URL:  fmt.Sprintf("%s/%s/%s", pm.config.RepositoryURL, pm.config.Branch, name),
+				Name: name,
+			})
+		}
+	}
+
+	for _, line := range pm.config.RepositoryURLs {
+		repo, err := ParseRepositoryLine(line)
+		if err != nil {
+			return nil, err
+		}
+		if repo != nil {
+			repos = append(repos, repo)
+		}
+	}
+
+	if len(repos) == 0 {
+		return nil, fmt.Errorf("no repositories configured")
+	}
+
+	return repos, nil
+}
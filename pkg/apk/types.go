@@ -2,20 +2,27 @@
 package apk
 
 import (
+	"crypto/rsa"
 	"log"
 	"time"
 )
 
 // Config configures the Alpine package manager
 type Config struct {
-	RepositoryURL string        // Default: https://dl-cdn.alpinelinux.org/alpine
-	Branch        string        // Alpine branch (v3.19, v3.18, edge, etc.)
-	Repository    string        // Repository name (main, community, testing)
-	InstallPath   string        // Where to install packages
-	CachePath     string        // Where to cache downloaded files
-	Timeout       time.Duration
-	Debug         bool        // Enable debug logging
-	Logger        *log.Logger // Custom logger (optional)
+	RepositoryURL    string      // Default: https://dl-cdn.alpinelinux.org/alpine
+	Branch           string      // Alpine branch (v3.19, v3.18, edge, etc.; default: from /etc/alpine-release)
+	Repository       string      // Repository name (main, community, testing)
+	Repositories     []string    // Repositories to index, in preference order (default: DefaultRepositories)
+	RepositoriesFile string      // apk repositories file (e.g. /etc/apk/repositories); replaces the branch repositories
+	RepositoryURLs   []string    // Extra repositories, as repositories file lines ("url" or "@tag url")
+	KeysDirs         []string    // Directories of trusted *.rsa.pub keys (default: /etc/apk/keys)
+	Keys             []string    // Extra trusted *.rsa.pub keys: paths, directories or https URLs (AlpineKeysURL opts into fetching official keys)
+	AllowUntrusted   bool        // Skip APKINDEX and package signature checks, like apk --allow-untrusted
+	InstallPath      string      // Where to install packages
+	CachePath        string      // Where to cache downloaded files
+	Timeout          time.Duration
+	Debug            bool        // Enable debug logging
+	Logger           *log.Logger // Custom logger (optional)
 }
 
 // PackageManager handles Alpine package operations
@@ -24,6 +31,8 @@ type PackageManager struct {
 	config *Config
 	logger *log.Logger
 	cache  *PackageCache
+	keys   map[string]*rsa.PublicKey // Trusted keys by file name, loaded on first use
+	keyIDs map[string]*rsa.PublicKey // Trusted keys by adb key id (hex), loaded on first use
 }
 
 // PackageInfo contains metadata about an Alpine package from APKINDEX
@@ -43,20 +52,23 @@ type PackageInfo struct {
 	Depends       []string // Dependencies (D:)
 	Provides      []string // Provides (p:)
 	InstallIf     []string // Install if (i:)
-	Checksum      string   // SHA256 checksum (C:)
+	Checksum      string   // Identity (C:): "Q1" + base64 control SHA1, "Q2" + SHA256 for v3
 	
 	// Internal fields
 	Repository    string   // "main", "community", etc.
+	RepositoryURL string   // Base URL of the repository the package was indexed from
+	Tag           string   // Pin tag of that repository ("" for untagged)
 }
 
 // DownloadOptions configures package download and extraction
 type DownloadOptions struct {
 	Package      string       // Required: package name (e.g., "curl")
 	Version      string       // Optional: specific version (uses latest if empty)
+	Tag          string       // Optional: repository tag the package is pinned to (set from "name@tag")
 	Architecture Architecture // Target architecture (auto-detected if empty)
 	Extract      bool         // Whether to extract the .apk (default: true)
 	KeepArchive  bool         // Whether to keep the .apk after extraction (default: false)
-	VerifyHash   bool         // Whether to verify SHA256 hash (default: true)
+	VerifyHash   bool         // Whether to verify the C: checksum and datahash (default: true)
 }
 
 // PackageCache caches package index information
@@ -64,6 +76,10 @@ type PackageCache struct {
 	// packages maps "pkgName" -> PackageInfo
 	// We only keep the latest version for simplicity in this implementation
 	packages map[string]*PackageInfo 
+
+	// tagged maps "tag" -> "pkgName" -> PackageInfo for tagged repositories,
+	// which never serve unpinned requests
+	tagged map[string]map[string]*PackageInfo
 	
 	// providers maps "virtualName" (e.g., "cmd:sh") -> list of packages that provide it
 	providers map[string][]*PackageInfo
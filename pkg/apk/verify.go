@@ -0,0 +1,630 @@
+// pkg/apk/verify.go
+package apk
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// An APK v2 file is a concatenation of gzip streams, each holding part of
+// one tar archive:
+//
+//	signature segment  .SIGN.RSA.<key> or .SIGN.RSA256.<key>, signing the
+//	                   raw bytes of the control segment
+//	control segment    .PKGINFO and install scripts; its SHA1 is the C: field
+//	                   of APKINDEX and .PKGINFO's datahash covers the data segment
+//	data segment       the files
+//
+// A signed APKINDEX.tar.gz is a signature segment followed by the index.
+
+// signature is a .SIGN.RSA* entry of a signature segment
+type signature struct {
+	keyName string      // Key file name (alpine-devel@lists.alpinelinux.org-6165ee59.rsa.pub)
+	hash    crypto.Hash // SHA1 for .SIGN.RSA, SHA256 for .SIGN.RSA256
+	value   []byte
+}
+
+// segmentReader hands gzip a buffered io.ByteReader, so gzip never reads
+// past the end of a member; it counts the bytes consumed and copies them
+// into the current segment's hashes
+type segmentReader struct {
+	r *bufio.Reader
+	w io.Writer // Hashes of the segment being read (nil for none)
+	n int64     // Bytes consumed so far
+}
+
+func (s *segmentReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.n += int64(n)
+	if s.w != nil && n > 0 {
+		s.w.Write(p[:n])
+	}
+	return n, err
+}
+
+func (s *segmentReader) ReadByte() (byte, error) {
+	b, err := s.r.ReadByte()
+	if err == nil {
+		s.n++
+		if s.w != nil {
+			s.w.Write([]byte{b})
+		}
+	}
+	return b, err
+}
+
+// readSegment decompresses one gzip member, passing its tar entries to fn,
+// and drains it so the reader is left at the start of the next member
+func readSegment(sr *segmentReader, fn func(hdr *tar.Header, r io.Reader) error) error {
+	gz, err := gzip.NewReader(sr)
+	if err != nil {
+		return fmt.Errorf("reading gzip segment: %w", err)
+	}
+	gz.Multistream(false)
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		// Signature and control segments end without the tar end-of-archive blocks
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading tar entry: %w", err)
+		}
+		if err := fn(hdr, tr); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.Copy(io.Discard, gz); err != nil {
+		return fmt.Errorf("reading gzip segment: %w", err)
+	}
+	return gz.Close()
+}
+
+// parseSignatureEntry recognizes a .SIGN.RSA.<key> or .SIGN.RSA256.<key> entry
+func parseSignatureEntry(hdr *tar.Header, r io.Reader) (*signature, error) {
+	name := strings.TrimPrefix(hdr.Name, "./")
+
+	var sig signature
+	switch {
+	case strings.HasPrefix(name, ".SIGN.RSA256."):
+		sig.keyName, sig.hash = strings.TrimPrefix(name, ".SIGN.RSA256."), crypto.SHA256
+	case strings.HasPrefix(name, ".SIGN.RSA."):
+		sig.keyName, sig.hash = strings.TrimPrefix(name, ".SIGN.RSA."), crypto.SHA1
+	default:
+		return nil, nil
+	}
+
+	value, err := io.ReadAll(io.LimitReader(r, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+	sig.value = value
+	return &sig, nil
+}
+
+// signedPart is a segment covered by signatures, with the digests needed to check them
+type signedPart struct {
+	signatures []*signature
+	sha1       []byte
+	sha256     []byte
+}
+
+// verifyIndex checks the signature of a raw APKINDEX.tar.gz: the first
+// segment signs the raw bytes of the rest of the file
+func (pm *PackageManager) verifyIndex(ctx context.Context, data []byte) error {
+	if isADB(data) {
+		stream, err := openADB(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		defer stream.Close()
+
+		f, err := readADB(stream, nil)
+		if err != nil {
+			return err
+		}
+		return pm.verifyADB(ctx, f)
+	}
+
+	sr := &segmentReader{r: bufio.NewReader(bytes.NewReader(data))}
+
+	var sigs []*signature
+	err := readSegment(sr, func(hdr *tar.Header, r io.Reader) error {
+		sig, err := parseSignatureEntry(hdr, r)
+		if sig != nil {
+			sigs = append(sigs, sig)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(sigs) == 0 {
+		return fmt.Errorf("APKINDEX is not signed")
+	}
+
+	rest := data[sr.n:]
+	sum1, sum256 := sha1.Sum(rest), sha256.Sum256(rest)
+
+	return pm.verifySignatures(ctx, &signedPart{signatures: sigs, sha1: sum1[:], sha256: sum256[:]})
+}
+
+// verifyPackage checks a downloaded .apk: its signature (unless untrusted
+// packages are allowed), the control segment against the APKINDEX C: field
+// and the data segment against the datahash in .PKGINFO
+func (pm *PackageManager) verifyPackage(ctx context.Context, pkgInfo *PackageInfo, apkPath string, digests bool) error {
+	f, err := os.Open(apkPath)
+	if err != nil {
+		return fmt.Errorf("opening .apk file: %w", err)
+	}
+	defer f.Close()
+
+	sr := &segmentReader{r: bufio.NewReaderSize(f, 64*1024)}
+	if magic, _ := sr.r.Peek(4); isADB(magic) {
+		return pm.verifyADBPackage(ctx, sr.r, digests)
+	}
+
+	// 1. Signature segment (absent on unsigned, locally built packages)
+	var sigs []*signature
+	control := &signedPart{}
+	controlSHA1, controlSHA256 := sha1.New(), sha256.New()
+	var pkginfo []byte
+
+	collectControl := func(hdr *tar.Header, r io.Reader) error {
+		if strings.TrimPrefix(hdr.Name, "./") == ".PKGINFO" {
+			data, err := io.ReadAll(io.LimitReader(r, 1024*1024))
+			pkginfo = data
+			return err
+		}
+		return nil
+	}
+
+	isSignature := true
+	err = readSegment(sr, func(hdr *tar.Header, r io.Reader) error {
+		sig, err := parseSignatureEntry(hdr, r)
+		if err != nil {
+			return err
+		}
+		if sig == nil {
+			isSignature = false
+			return nil
+		}
+		sigs = append(sigs, sig)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("reading signature segment: %w", err)
+	}
+
+	// 2. Control segment
+	if isSignature {
+		sr.w = io.MultiWriter(controlSHA1, controlSHA256)
+		err = readSegment(sr, collectControl)
+		sr.w = nil
+		if err != nil {
+			return fmt.Errorf("reading control segment: %w", err)
+		}
+	} else {
+		// The first segment was the control segment; hash it again from the start
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		sr = &segmentReader{r: bufio.NewReaderSize(f, 64*1024), w: io.MultiWriter(controlSHA1, controlSHA256)}
+		err = readSegment(sr, collectControl)
+		sr.w = nil
+		if err != nil {
+			return fmt.Errorf("reading control segment: %w", err)
+		}
+	}
+	control.signatures = sigs
+	control.sha1 = controlSHA1.Sum(nil)
+	control.sha256 = controlSHA256.Sum(nil)
+
+	// 3. Data segment: the rest of the file
+	dataSHA256 := sha256.New()
+	if _, err := io.Copy(dataSHA256, sr.r); err != nil {
+		return fmt.Errorf("reading data segment: %w", err)
+	}
+
+	if !pm.config.AllowUntrusted {
+		if err := pm.verifySignatures(ctx, control); err != nil {
+			return err
+		}
+	}
+
+	if !digests {
+		return nil
+	}
+
+	if pkgInfo.Checksum != "" {
+		if err := verifyChecksum(pkgInfo.Checksum, control.sha1); err != nil {
+			return fmt.Errorf("control segment: %w", err)
+		}
+	}
+
+	if datahash := pkginfoValue(pkginfo, "datahash"); datahash != "" {
+		if actual := hex.EncodeToString(dataSHA256.Sum(nil)); !strings.EqualFold(actual, datahash) {
+			return fmt.Errorf("data segment: datahash mismatch: expected %s, got %s", datahash, actual)
+		}
+	} else if pm.config.Debug {
+		pm.logger.Printf("  (no datahash in .PKGINFO)")
+	}
+
+	return nil
+}
+
+// verifyADBPackage checks a v3 package: its signature (unless untrusted
+// packages are allowed) and each file against the hash recorded for it.
+// The index identity of v3 packages is not an APKINDEX C: checksum; the
+// signature and the per-file hashes cover the whole package instead.
+func (pm *PackageManager) verifyADBPackage(ctx context.Context, r io.Reader, digests bool) error {
+	stream, err := openADB(r)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	f, err := readADB(stream, func(f *adbFile, pathIdx, fileIdx uint32, data io.Reader) error {
+		if !digests {
+			return nil
+		}
+		fi := adbFileAt(f.db, pathIdx, fileIdx)
+		expected := fi.blob(adbiFiHashes)
+
+		var h hash.Hash
+		switch len(expected) {
+		case sha256.Size:
+			h = sha256.New()
+		case sha1.Size:
+			h = sha1.New()
+		default:
+			return nil
+		}
+		if _, err := io.Copy(h, data); err != nil {
+			return fmt.Errorf("reading data block: %w", err)
+		}
+		if !bytes.Equal(h.Sum(nil), expected) {
+			return fmt.Errorf("data segment: hash mismatch for %s", fi.str(adbiFiName))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if f.schema != adbSchemaPackage {
+		return fmt.Errorf("adb file is not a package (schema %08x)", f.schema)
+	}
+
+	if !pm.config.AllowUntrusted {
+		return pm.verifyADB(ctx, f)
+	}
+	return nil
+}
+
+// Digest algorithms of an adb signature
+const (
+	apkDigestSHA256 = 3
+	apkDigestSHA512 = 4
+)
+
+// verifyADB checks the SIG blocks of an adb file. A v0 signature is
+// <version> <digest algorithm> <16 byte key id> <RSA signature>; it signs,
+// with SHA-512, the first 8 bytes of the ADB block, its own first 18 bytes
+// and the digest of the ADB block. At least one must come from a trusted key.
+func (pm *PackageManager) verifyADB(ctx context.Context, f *adbFile) error {
+	if len(f.signatures) == 0 {
+		return fmt.Errorf("not signed")
+	}
+	if len(f.db) < 8 {
+		return fmt.Errorf("adb block too short")
+	}
+
+	keys := pm.trustedKeysByID(ctx)
+	lastErr := fmt.Errorf("signing key not found in %s", strings.Join(pm.config.KeysDirs, ", "))
+
+	for _, sig := range f.signatures {
+		if len(sig) < 18 || sig[0] != 0 {
+			lastErr = fmt.Errorf("unsupported signature format")
+			continue
+		}
+		id := hex.EncodeToString(sig[2:18])
+		key, ok := keys[id]
+		if !ok {
+			continue
+		}
+
+		var digest []byte
+		switch sig[1] {
+		case apkDigestSHA256:
+			sum := sha256.Sum256(f.db)
+			digest = sum[:]
+		case apkDigestSHA512:
+			sum := sha512.Sum512(f.db)
+			digest = sum[:]
+		default:
+			lastErr = fmt.Errorf("unsupported signature digest %d", sig[1])
+			continue
+		}
+
+		h := sha512.New()
+		h.Write(f.db[:8])
+		h.Write(sig[:18])
+		h.Write(digest)
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA512, h.Sum(nil), sig[18:]); err != nil {
+			lastErr = fmt.Errorf("signature by key %s is invalid: %w", id, err)
+			continue
+		}
+
+		if pm.config.Debug {
+			pm.logger.Printf("  Signature OK: key %s", id)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("no valid signature from a trusted key: %w", lastErr)
+}
+
+// trustedKeysByID loads every trusted key once and indexes it by adb key
+// id: the first 16 bytes of the SHA-512 of its PKCS#1 encoding. adb
+// signatures do not name their key, so every key trustedKey would accept
+// is a candidate: the embedded keys, the keys directories, Config.Keys and
+// the pinned copies of keys fetched on request. Other files in the cache
+// are not trusted.
+func (pm *PackageManager) trustedKeysByID(ctx context.Context) map[string]*rsa.PublicKey {
+	if pm.keyIDs != nil {
+		return pm.keyIDs
+	}
+	pm.keyIDs = make(map[string]*rsa.PublicKey)
+
+	names := embeddedKeyNames()
+	dirs := append([]string(nil), pm.config.KeysDirs...)
+	for _, source := range pm.config.Keys {
+		switch {
+		case strings.TrimSuffix(source, "/") == AlpineKeysURL:
+			// Official keys fetched earlier on request, pinned in the cache
+			pinned, _ := filepath.Glob(filepath.Join(pm.config.CachePath, "keys", AlpineKeyPrefix+"*.rsa.pub"))
+			for _, file := range pinned {
+				names = append(names, filepath.Base(file))
+			}
+		case strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://"):
+			names = append(names, path.Base(source))
+		default:
+			if info, err := os.Stat(source); err == nil && info.IsDir() {
+				dirs = append(dirs, source)
+			} else {
+				names = append(names, filepath.Base(source))
+			}
+		}
+	}
+	for _, dir := range dirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.pub"))
+		for _, file := range matches {
+			names = append(names, filepath.Base(file))
+		}
+	}
+
+	for _, name := range names {
+		key, err := pm.trustedKey(ctx, name)
+		if err != nil {
+			pm.logger.Printf("  ⚠️  Warning: %v", err)
+			continue
+		}
+		sum := sha512.Sum512(x509.MarshalPKCS1PublicKey(key))
+		pm.keyIDs[hex.EncodeToString(sum[:16])] = key
+	}
+
+	return pm.keyIDs
+}
+
+// verifyChecksum compares an APKINDEX C: field with a control segment SHA1.
+// The field is "Q1" followed by the base64 digest.
+func verifyChecksum(field string, sum []byte) error {
+	encoded, ok := strings.CutPrefix(field, "Q1")
+	if !ok {
+		return fmt.Errorf("unsupported checksum format %q", field)
+	}
+	expected, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("decoding checksum: %w", err)
+	}
+	if !bytes.Equal(expected, sum) {
+		return fmt.Errorf("checksum mismatch: expected %s, got Q1%s", field, base64.StdEncoding.EncodeToString(sum))
+	}
+	return nil
+}
+
+// pkginfoValue returns the first "key = value" entry of a .PKGINFO
+func pkginfoValue(pkginfo []byte, key string) string {
+	for _, line := range strings.Split(string(pkginfo), "\n") {
+		k, v, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// verifySignatures requires at least one signature from a trusted key to be
+// valid. Signatures by unknown keys are skipped, as apk does, so indexes
+// re-signed during a key rotation still verify.
+func (pm *PackageManager) verifySignatures(ctx context.Context, part *signedPart) error {
+	if len(part.signatures) == 0 {
+		return fmt.Errorf("not signed")
+	}
+
+	var lastErr error
+	for _, sig := range part.signatures {
+		key, err := pm.trustedKey(ctx, sig.keyName)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		digest := part.sha1
+		if sig.hash == crypto.SHA256 {
+			digest = part.sha256
+		}
+		if err := rsa.VerifyPKCS1v15(key, sig.hash, digest, sig.value); err != nil {
+			lastErr = fmt.Errorf("signature by %s is invalid: %w", sig.keyName, err)
+			continue
+		}
+
+		if pm.config.Debug {
+			pm.logger.Printf("  Signature OK: %s", sig.keyName)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("no valid signature from a trusted key: %w", lastErr)
+}
+
+// trustedKey returns a trusted RSA key by file name. Keys are looked up in
+// Config.Keys, Config.KeysDirs and the official Alpine keys embedded in
+// upkg, in that order. Official keys found nowhere else are only fetched
+// from AlpineKeysURL, and pinned in the cache, when Config.Keys lists it.
+func (pm *PackageManager) trustedKey(ctx context.Context, name string) (*rsa.PublicKey, error) {
+	if key, ok := pm.keys[name]; ok {
+		return key, nil
+	}
+	if name != filepath.Base(name) || name == "" {
+		return nil, fmt.Errorf("invalid key name %q", name)
+	}
+
+	var data []byte
+	dirs := pm.config.KeysDirs
+	pinned := filepath.Join(pm.config.CachePath, "keys", name)
+
+	// Keys configured one by one: local files, directories or URLs
+	for _, source := range pm.config.Keys {
+		if strings.TrimSuffix(source, "/") == AlpineKeysURL {
+			continue
+		}
+		if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+			if path.Base(source) != name {
+				continue
+			}
+			d, err := pm.fetchKey(ctx, source, pinned)
+			if err != nil {
+				return nil, err
+			}
+			data = d
+			break
+		}
+		if info, err := os.Stat(source); err == nil && info.IsDir() {
+			dirs = append([]string{source}, dirs...)
+		} else if filepath.Base(source) == name {
+			d, err := os.ReadFile(source)
+			if err != nil {
+				return nil, fmt.Errorf("reading key %s: %w", source, err)
+			}
+			data = d
+			break
+		}
+	}
+
+	if data == nil {
+		for _, dir := range dirs {
+			if d, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+				data = d
+				break
+			}
+		}
+	}
+
+	if data == nil {
+		if d, ok := embeddedKey(name); ok {
+			data = d
+		}
+	}
+
+	if data == nil {
+		official := strings.HasPrefix(name, AlpineKeyPrefix) && strings.HasSuffix(name, ".rsa.pub")
+		if !official || !pm.fetchAlpineKeys() {
+			return nil, fmt.Errorf("untrusted key %s (not embedded or in %s)", name, strings.Join(dirs, ", "))
+		}
+		d, err := pm.fetchKey(ctx, AlpineKeysURL+"/"+name, pinned)
+		if err != nil {
+			return nil, err
+		}
+		data = d
+	}
+
+	key, err := parseRSAPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", name, err)
+	}
+
+	pm.keys[name] = key
+	return key, nil
+}
+
+// fetchKey returns the pinned copy of a remote key, downloading and pinning
+// it on first use
+func (pm *PackageManager) fetchKey(ctx context.Context, url, pinned string) ([]byte, error) {
+	if data, err := os.ReadFile(pinned); err == nil {
+		return data, nil
+	}
+
+	pm.logger.Printf("  Fetching signing key %s", url)
+	resp, err := pm.client.Get(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("fetching key %s: %w", url, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("fetching key %s: %w", url, err)
+	}
+
+	// Only pin material that actually parses as a key
+	if _, err := parseRSAPublicKey(data); err != nil {
+		return nil, fmt.Errorf("key %s: %w", url, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(pinned), 0755); err == nil {
+		os.WriteFile(pinned, data, 0644)
+	}
+
+	return data, nil
+}
+
+// parseRSAPublicKey parses a PEM "PUBLIC KEY" as shipped in /etc/apk/keys
+func parseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key: %w", err)
+	}
+
+	key, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("not an RSA key")
+	}
+	return key, nil
+}
@@ -40,4 +40,7 @@ const (
 	ComponentUniverse   = "universe"   // Community-maintained open-source software
 	ComponentRestricted = "restricted" // Proprietary drivers
 	ComponentMultiverse = "multiverse" // Software restricted by copyright or legal issues
-)
\ No newline at end of file
+)
+
+// DefaultComponents are the components indexed when Config.Components is empty, in search order
+var DefaultComponents = []string{ComponentMain, ComponentUniverse, ComponentRestricted, ComponentMultiverse}
\ No newline at end of file
@@ -42,6 +42,9 @@ func NewPackageManager(cfg *Config) *PackageManager {
 	if cfg.Component == "" {
 		cfg.Component = DefaultComponent
 	}
+	if len(cfg.Components) == 0 {
+		cfg.Components = DefaultComponents
+	}
 	if cfg.InstallPath == "" {
 		cfg.InstallPath = DefaultInstallPath
 	}
@@ -77,7 +80,7 @@ func NewPackageManager(cfg *Config) *PackageManager {
 		pm.logger.Printf("Initialized Ubuntu APT PackageManager")
 		pm.logger.Printf("  Repository: %s", cfg.RepositoryURL)
 		pm.logger.Printf("  Release: %s", cfg.Release)
-		pm.logger.Printf("  Component: %s", cfg.Component)
+		pm.logger.Printf("  Components: %s", strings.Join(cfg.Components, ", "))
 		pm.logger.Printf("  InstallPath: %s", cfg.InstallPath)
 		pm.logger.Printf("  CachePath: %s", cfg.CachePath)
 	}
@@ -219,11 +222,8 @@ func (pm *PackageManager) updatePackageIndex(ctx context.Context, arch Architect
 	// Clear cache before updating
 	pm.cache.packages = make(map[string]*PackageInfo)
 
-	// Common Ubuntu components to search
-	components := []string{"main", "universe", "restricted", "multiverse"}
-	
 	totalPackages := 0
-	for _, component := range components {
+	for _, component := range pm.config.Components {
 		// Construct URL for Packages.gz
 		url := fmt.Sprintf("%s/dists/%s/%s/binary-%s/Packages.gz",
 			repoURL,
@@ -265,10 +265,8 @@ func (pm *PackageManager) updatePackageIndex(ctx context.Context, arch Architect
 
 // findPackage finds a package in the cache across all components
 func (pm *PackageManager) findPackage(name, version string, arch Architecture) (*PackageInfo, error) {
-	// Search order: main, universe, restricted, multiverse
-	components := []string{"main", "universe", "restricted", "multiverse"}
-	
-	for _, component := range components {
+	// Search components in configured order (main, universe, restricted, multiverse by default)
+	for _, component := range pm.config.Components {
 		// Try exact architecture first
 		key := fmt.Sprintf("%s_%s_%s", name, arch, component)
 		if pkg, ok := pm.cache.packages[key]; ok {
@@ -13,6 +13,7 @@ type Config struct {
 	PortsURL      string        // For ARM and other architectures
 	Release       string        // Ubuntu release (noble, jammy, focal, etc.)
 	Component     string        // Repository component (main, universe, restricted, multiverse)
+	Components    []string      // Components to index, in search order (default: DefaultComponents)
 	InstallPath   string        // Where to install packages
 	CachePath     string        // Where to cache downloaded files
 	Timeout       time.Duration
@@ -4,6 +4,8 @@ package backend
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/arc-language/upkg/pkg/apk"
 )
@@ -20,15 +22,54 @@ func NewApkBackend(config *Config) (*ApkBackend, error) {
 		config = DefaultConfig()
 	}
 
+	// Repos mixes Alpine repository names (main, community) with extra
+	// repository URLs ("@tag url" pins one) and apk repositories files
+	var names, urls []string
+	var repositoriesFile string
+	for _, repo := range config.Repos {
+		switch {
+		case !strings.ContainsRune(repo, '/'):
+			names = append(names, repo)
+		case isRegularFile(repo):
+			repositoriesFile = repo
+		default:
+			urls = append(urls, repo)
+		}
+	}
+
+	// On an Alpine host with nothing else configured, use the host's own repositories
+	if len(config.Repos) == 0 && config.Mirror == "" && config.Release == "" && apk.DetectBranch() != "" {
+		if isRegularFile(apk.DefaultRepositoriesFile) {
+			repositoriesFile = apk.DefaultRepositoriesFile
+		}
+	}
+	if len(names) == 0 && len(urls) == 0 && repositoriesFile == "" {
+		names = apk.DefaultRepositories
+	}
+
+	// /etc/alpine-release is more precise than os-release on Alpine hosts
+	release := config.Release
+	if release == "" {
+		release = apk.DetectBranch()
+	}
+	if release == "" {
+		release = resolveRelease(config, BackendApk, apk.DefaultBranch)
+	}
+
 	apkConfig := &apk.Config{
-		RepositoryURL: "https://dl-cdn.alpinelinux.org/alpine",
-		Branch:        "v3.19",
-		Repository:    "main",
-		InstallPath:   config.InstallPath,
-		CachePath:     config.CachePath,
-		Timeout:       config.Timeout,
-		Debug:         config.Debug,
-		Logger:        config.Logger,
+		RepositoryURL:    stringOr(config.Mirror, "https://dl-cdn.alpinelinux.org/alpine"),
+		Branch:           apk.BranchFor(release),
+		Repository:       "main",
+		Repositories:     names,
+		RepositoriesFile: repositoriesFile,
+		RepositoryURLs:   urls,
+		Keys:             config.GPGKeys,
+		AllowUntrusted:   config.NoGPGCheck,
+		InstallPath:      config.InstallPath,
+		CachePath:        config.CachePath,
+		Timeout:          config.Timeout,
+		Debug:            config.Debug,
+		Logger:           config.Logger,
 	}
 
 	manager := apk.NewPackageManager(apkConfig)
@@ -49,8 +90,8 @@ func (b *ApkBackend) Download(ctx context.Context, pkg *Package, opts *DownloadO
 		VerifyHash:  derefBool(opts.VerifyHash, true),
 	}
 
-	if opts.Platform != "" {
-		apkOpts.Architecture = apk.Architecture(opts.Platform)
+	if platform := stringOr(opts.Platform, b.config.Arch); platform != "" {
+		apkOpts.Architecture = apk.Architecture(platform)
 	}
 
 	return b.manager.Download(ctx, apkOpts)
@@ -58,7 +99,7 @@ func (b *ApkBackend) Download(ctx context.Context, pkg *Package, opts *DownloadO
 
 // GetInfo retrieves package information from Alpine
 func (b *ApkBackend) GetInfo(ctx context.Context, name string) (*PackageInfo, error) {
-	arch, err := apk.DetectArchitecture()
+	arch, err := b.architecture()
 	if err != nil {
 		return nil, fmt.Errorf("detecting architecture: %w", err)
 	}
@@ -81,7 +122,7 @@ func (b *ApkBackend) GetInfo(ctx context.Context, name string) (*PackageInfo, er
 
 // Search searches for packages in Alpine repositories
 func (b *ApkBackend) Search(ctx context.Context, query string) ([]*PackageInfo, error) {
-	arch, err := apk.DetectArchitecture()
+	arch, err := b.architecture()
 	if err != nil {
 		return nil, fmt.Errorf("detecting architecture: %w", err)
 	}
@@ -107,6 +148,14 @@ func (b *ApkBackend) Search(ctx context.Context, query string) ([]*PackageInfo,
 	return results, nil
 }
 
+// architecture returns the configured target architecture, or the host's when unset
+func (b *ApkBackend) architecture() (apk.Architecture, error) {
+	if b.config.Arch != "" {
+		return apk.Architecture(b.config.Arch), nil
+	}
+	return apk.DetectArchitecture()
+}
+
 // Name returns the backend name
 func (b *ApkBackend) Name() string {
 	return "apk"
@@ -115,4 +164,10 @@ func (b *ApkBackend) Name() string {
 // Close cleans up resources
 func (b *ApkBackend) Close() error {
 	return nil
-}
\ No newline at end of file
+}
+
+// isRegularFile reports whether path names an existing regular file
+func isRegularFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
@@ -21,11 +21,12 @@ func NewAptBackend(config *Config) (*AptBackend, error) {
 	}
 
 	aptConfig := &apt.Config{
-		RepositoryURL: "http://archive.ubuntu.com/ubuntu",
+		RepositoryURL: stringOr(config.Mirror, "http://archive.ubuntu.com/ubuntu"),
 		SecurityURL:   "http://security.ubuntu.com/ubuntu",
-		PortsURL:      "http://ports.ubuntu.com/ubuntu-ports",
-		Release:       "noble", // Ubuntu 24.04 LTS
+		PortsURL:      stringOr(config.Mirror, "http://ports.ubuntu.com/ubuntu-ports"),
+		Release:       resolveRelease(config, BackendApt, apt.DefaultRelease),
 		Component:     "main",
+		Components:    reposOr(config, apt.DefaultComponents),
 		InstallPath:   config.InstallPath,
 		CachePath:     config.CachePath,
 		Timeout:       config.Timeout,
@@ -51,8 +52,8 @@ func (b *AptBackend) Download(ctx context.Context, pkg *Package, opts *DownloadO
 		VerifyHash:  derefBool(opts.VerifyHash, true),
 	}
 
-	if opts.Platform != "" {
-		aptOpts.Architecture = apt.Architecture(opts.Platform)
+	if platform := stringOr(opts.Platform, b.config.Arch); platform != "" {
+		aptOpts.Architecture = apt.Architecture(platform)
 	}
 
 	return b.manager.Download(ctx, aptOpts)
@@ -60,7 +61,7 @@ func (b *AptBackend) Download(ctx context.Context, pkg *Package, opts *DownloadO
 
 // GetInfo retrieves package information from Ubuntu
 func (b *AptBackend) GetInfo(ctx context.Context, name string) (*PackageInfo, error) {
-	arch, err := apt.DetectArchitecture()
+	arch, err := b.architecture()
 	if err != nil {
 		return nil, fmt.Errorf("detecting architecture: %w", err)
 	}
@@ -83,7 +84,7 @@ func (b *AptBackend) GetInfo(ctx context.Context, name string) (*PackageInfo, er
 
 // Search searches for packages in Ubuntu repositories
 func (b *AptBackend) Search(ctx context.Context, query string) ([]*PackageInfo, error) {
-	arch, err := apt.DetectArchitecture()
+	arch, err := b.architecture()
 	if err != nil {
 		return nil, fmt.Errorf("detecting architecture: %w", err)
 	}
@@ -108,6 +109,14 @@ func (b *AptBackend) Search(ctx context.Context, query string) ([]*PackageInfo,
 	return results, nil
 }
 
+// architecture returns the configured target architecture, or the host's when unset
+func (b *AptBackend) architecture() (apt.Architecture, error) {
+	if b.config.Arch != "" {
+		return apt.Architecture(b.config.Arch), nil
+	}
+	return apt.DetectArchitecture()
+}
+
 // Name returns the backend name
 func (b *AptBackend) Name() string {
 	return "apt"
@@ -4,6 +4,8 @@ package backend
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"sort"
 
 	"github.com/arc-language/upkg/pkg/brew"
 )
@@ -24,9 +26,11 @@ func NewBrewBackend(config *Config) (*BrewBackend, error) {
 		APIURL:      config.Brew.APIURL,
 		RegistryURL: config.Brew.RegistryURL,
 		InstallPath: config.InstallPath,
+		IndexPath:   filepath.Join(config.CachePath, "index", brew.IndexFile),
 		Timeout:     config.Timeout,
 		Debug:       config.Debug,
 		Logger:      config.Logger,
+		Progress:    config.Progress,
 	}
 
 	manager := brew.NewPackageManager(brewConfig)
@@ -54,18 +58,48 @@ func (b *BrewBackend) Download(ctx context.Context, pkg *Package, opts *Download
 	return b.manager.Download(ctx, brewOpts)
 }
 
-// GetInfo retrieves package information from Homebrew
+// Remove unlinks a formula from the prefix and deletes its kegs
+func (b *BrewBackend) Remove(ctx context.Context, pkg *Package) error {
+	return b.manager.Remove(pkg.Name)
+}
+
+// GetInfo retrieves package information from the Homebrew formula index
 func (b *BrewBackend) GetInfo(ctx context.Context, name string) (*PackageInfo, error) {
 	formula, err := b.manager.GetFormulaInfo(ctx, name)
 	if err != nil {
 		return nil, fmt.Errorf("getting formula info: %w", err)
 	}
 
+	return b.packageInfo(formula), nil
+}
+
+// Search searches the Homebrew formula index by name, alias and description
+func (b *BrewBackend) Search(ctx context.Context, query string) ([]*PackageInfo, error) {
+	matches, err := b.manager.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Limit results to avoid overwhelming output
+	if len(matches) > 100 {
+		matches = matches[:100]
+	}
+
+	results := make([]*PackageInfo, 0, len(matches))
+	for _, formula := range matches {
+		results = append(results, b.packageInfo(formula))
+	}
+	return results, nil
+}
+
+// packageInfo converts a formula to PackageInfo
+func (b *BrewBackend) packageInfo(formula *brew.FormulaInfo) *PackageInfo {
 	// Extract available platforms from bottle info
-	platforms := make([]string, 0)
+	platforms := make([]string, 0, len(formula.Bottle.Stable.Files))
 	for platform := range formula.Bottle.Stable.Files {
 		platforms = append(platforms, platform)
 	}
+	sort.Strings(platforms)
 
 	return &PackageInfo{
 		Name:        formula.Name,
@@ -75,13 +109,7 @@ func (b *BrewBackend) GetInfo(ctx context.Context, name string) (*PackageInfo, e
 		License:     formula.License,
 		Platforms:   platforms,
 		Backend:     "brew",
-	}, nil
-}
-
-// Search searches for packages in Homebrew
-func (b *BrewBackend) Search(ctx context.Context, query string) ([]*PackageInfo, error) {
-	// Homebrew search would require additional API endpoints
-	return nil, fmt.Errorf("search not implemented for Homebrew backend")
+	}
 }
 
 // Name returns the backend name
@@ -0,0 +1,102 @@
+// pkg/backend/distro.go
+package backend
+
+import (
+	"fmt"
+
+	"github.com/arc-language/upkg/pkg/apk"
+	"github.com/arc-language/upkg/pkg/el"
+	"github.com/arc-language/upkg/pkg/osrelease"
+)
+
+// Distro describes the backend and release that match a Linux distribution
+type Distro struct {
+	ID      string      // os-release ID of the distribution (ubuntu, linuxmint, rocky, ...)
+	Backend BackendType // Backend that serves this distribution family
+	Release string      // Release in the backend's own terms (noble, 42, v3.19, 15.6, rocky-9, ...)
+}
+
+// DetectDistro reads /etc/os-release and maps the running distribution to a backend and release
+func DetectDistro() (*Distro, error) {
+	info, err := osrelease.Load()
+	if err != nil {
+		return nil, err
+	}
+	return DistroFor(info)
+}
+
+// DistroFor maps parsed os-release information to a backend and release.
+// Derivatives are matched through ID_LIKE, so Linux Mint resolves to apt with
+// its Ubuntu base codename and Manjaro resolves to pacman.
+func DistroFor(info *osrelease.Info) (*Distro, error) {
+	d := &Distro{ID: info.ID}
+
+	switch {
+	case info.Is("alpine"):
+		d.Backend = BackendApk
+		d.Release = apk.BranchFor(info.VersionID)
+
+	// Checked before Fedora: RHEL rebuilds list "fedora" in ID_LIKE but are not ABI compatible with it
+	case info.Is("rhel", "centos"):
+		d.Backend = BackendEL
+		d.Release = el.ReleaseFor(info.ID, info.VersionID)
+
+	case info.Is("fedora"):
+		d.Backend = BackendDnf
+		d.Release = info.VersionID
+		if d.Release == "" || info.Get("REDHAT_SUPPORT_PRODUCT_VERSION") == "rawhide" {
+			d.Release = "rawhide"
+		}
+
+	case info.Is("opensuse", "suse", "sles"):
+		d.Backend = BackendZypper
+		switch info.ID {
+		case "opensuse-tumbleweed", "opensuse-microos", "opensuse-slowroll":
+			d.Release = "tumbleweed"
+		default:
+			// Leap and SLE share version numbers and ABI
+			d.Release = info.VersionID
+		}
+
+	case info.Is("arch"):
+		// Rolling release, there is nothing to pin
+		d.Backend = BackendPacman
+
+	// Checked before Debian: Ubuntu and its derivatives list "debian" in ID_LIKE
+	case info.Is("ubuntu"):
+		d.Backend = BackendApt
+		d.Release = info.Get("UBUNTU_CODENAME")
+		if d.Release == "" && info.ID == "ubuntu" {
+			d.Release = info.VersionCodename
+		}
+
+	case info.Is("debian"):
+		d.Backend = BackendDpkg
+		d.Release = info.Get("DEBIAN_CODENAME")
+		if d.Release == "" && (info.ID == "debian" || info.ID == "raspbian") {
+			d.Release = info.VersionCodename
+			if d.Release == "" {
+				// testing/unstable images often ship without a codename
+				d.Release = "sid"
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unrecognized Linux distribution %q", info.ID)
+	}
+
+	return d, nil
+}
+
+// resolveRelease picks the release a backend targets: the explicit
+// Config.Release override first, then the host's own release when the host
+// belongs to that backend's family, then the backend's built-in default.
+func resolveRelease(config *Config, backendType BackendType, fallback string) string {
+	if config.Release != "" {
+		return config.Release
+	}
+	if d, err := DetectDistro(); err == nil && d.Backend == backendType && d.Release != "" {
+		return d.Release
+	}
+	return fallback
+}
@@ -4,6 +4,7 @@ package backend
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/arc-language/upkg/pkg/dnf"
 )
@@ -20,10 +21,24 @@ func NewDnfBackend(config *Config) (*DnfBackend, error) {
 		config = DefaultConfig()
 	}
 
+	// Repos mixes Fedora repository names (releases, updates) with paths
+	// to yum .repo files or directories such as /etc/yum.repos.d
+	var repositories, repoFiles []string
+	for _, repo := range config.Repos {
+		if strings.ContainsRune(repo, '/') || strings.HasSuffix(repo, ".repo") {
+			repoFiles = append(repoFiles, repo)
+		} else {
+			repositories = append(repositories, repo)
+		}
+	}
+
 	dnfConfig := &dnf.Config{
-		RepositoryURL: "https://dl.fedoraproject.org/pub/fedora/linux",
-		Release:       "42", // Updated to Fedora 42
-		Repository:    "releases",
+		RepositoryURL: stringOr(config.Mirror, "https://dl.fedoraproject.org/pub/fedora/linux"),
+		Release:       resolveRelease(config, BackendDnf, dnf.DefaultRelease),
+		Repositories:  repositories,
+		RepoFiles:     repoFiles,
+		GPGKeys:       config.GPGKeys,
+		NoGPGCheck:    config.NoGPGCheck,
 		InstallPath:   config.InstallPath,
 		CachePath:     config.CachePath,
 		Timeout:       config.Timeout,
@@ -49,8 +64,8 @@ func (b *DnfBackend) Download(ctx context.Context, pkg *Package, opts *DownloadO
 		VerifyHash:  derefBool(opts.VerifyHash, true),
 	}
 
-	if opts.Platform != "" {
-		dnfOpts.Architecture = dnf.Architecture(opts.Platform)
+	if platform := stringOr(opts.Platform, b.config.Arch); platform != "" {
+		dnfOpts.Architecture = dnf.Architecture(platform)
 	}
 
 	return b.manager.Download(ctx, dnfOpts)
@@ -58,7 +73,7 @@ func (b *DnfBackend) Download(ctx context.Context, pkg *Package, opts *DownloadO
 
 // GetInfo retrieves package information from Fedora
 func (b *DnfBackend) GetInfo(ctx context.Context, name string) (*PackageInfo, error) {
-	arch, err := dnf.DetectArchitecture()
+	arch, err := b.architecture()
 	if err != nil {
 		return nil, fmt.Errorf("detecting architecture: %w", err)
 	}
@@ -81,7 +96,7 @@ func (b *DnfBackend) GetInfo(ctx context.Context, name string) (*PackageInfo, er
 
 // Search searches for packages in Fedora repositories
 func (b *DnfBackend) Search(ctx context.Context, query string) ([]*PackageInfo, error) {
-	arch, err := dnf.DetectArchitecture()
+	arch, err := b.architecture()
 	if err != nil {
 		return nil, fmt.Errorf("detecting architecture: %w", err)
 	}
@@ -107,6 +122,42 @@ func (b *DnfBackend) Search(ctx context.Context, query string) ([]*PackageInfo,
 	return results, nil
 }
 
+// FindFileOwners returns the packages that ship a path
+func (b *DnfBackend) FindFileOwners(ctx context.Context, path string) ([]*PackageInfo, error) {
+	arch, err := b.architecture()
+	if err != nil {
+		return nil, fmt.Errorf("detecting architecture: %w", err)
+	}
+
+	packages, err := b.manager.FindFileOwners(ctx, path, arch)
+	if err != nil {
+		return nil, fmt.Errorf("finding file owners: %w", err)
+	}
+
+	results := make([]*PackageInfo, 0, len(packages))
+	for _, pkg := range packages {
+		results = append(results, &PackageInfo{
+			Name:        pkg.Name,
+			Version:     pkg.FullVersion(),
+			Description: pkg.Summary,
+			Homepage:    pkg.URL,
+			License:     pkg.License,
+			Platforms:   []string{pkg.Architecture},
+			Backend:     "dnf",
+		})
+	}
+
+	return results, nil
+}
+
+// architecture returns the configured target architecture, or the host's when unset
+func (b *DnfBackend) architecture() (dnf.Architecture, error) {
+	if b.config.Arch != "" {
+		return dnf.Architecture(b.config.Arch), nil
+	}
+	return dnf.DetectArchitecture()
+}
+
 // Name returns the backend name
 func (b *DnfBackend) Name() string {
 	return "dnf"
@@ -21,10 +21,11 @@ func NewDpkgBackend(config *Config) (*DpkgBackend, error) {
 	}
 
 	dpkgConfig := &dpkg.Config{
-		RepositoryURL: "http://deb.debian.org/debian",
+		RepositoryURL: stringOr(config.Mirror, "http://deb.debian.org/debian"),
 		SecurityURL:   "http://security.debian.org/debian-security",
-		Release:       "bookworm",
+		Release:       resolveRelease(config, BackendDpkg, dpkg.DefaultRelease),
 		Component:     "main",
+		Components:    reposOr(config, dpkg.DefaultComponents),
 		InstallPath:   config.InstallPath,
 		CachePath:     config.CachePath,
 		Timeout:       config.Timeout,
@@ -50,8 +51,8 @@ func (b *DpkgBackend) Download(ctx context.Context, pkg *Package, opts *Download
 		VerifyHash:  derefBool(opts.VerifyHash, true),
 	}
 
-	if opts.Platform != "" {
-		dpkgOpts.Architecture = dpkg.Architecture(opts.Platform)
+	if platform := stringOr(opts.Platform, b.config.Arch); platform != "" {
+		dpkgOpts.Architecture = dpkg.Architecture(platform)
 	}
 
 	return b.manager.Download(ctx, dpkgOpts)
@@ -59,7 +60,7 @@ func (b *DpkgBackend) Download(ctx context.Context, pkg *Package, opts *Download
 
 // GetInfo retrieves package information from Debian
 func (b *DpkgBackend) GetInfo(ctx context.Context, name string) (*PackageInfo, error) {
-	arch, err := dpkg.DetectArchitecture()
+	arch, err := b.architecture()
 	if err != nil {
 		return nil, fmt.Errorf("detecting architecture: %w", err)
 	}
@@ -82,7 +83,7 @@ func (b *DpkgBackend) GetInfo(ctx context.Context, name string) (*PackageInfo, e
 
 // Search searches for packages in Debian repositories
 func (b *DpkgBackend) Search(ctx context.Context, query string) ([]*PackageInfo, error) {
-	arch, err := dpkg.DetectArchitecture()
+	arch, err := b.architecture()
 	if err != nil {
 		return nil, fmt.Errorf("detecting architecture: %w", err)
 	}
@@ -107,6 +108,14 @@ func (b *DpkgBackend) Search(ctx context.Context, query string) ([]*PackageInfo,
 	return results, nil
 }
 
+// architecture returns the configured target architecture, or the host's when unset
+func (b *DpkgBackend) architecture() (dpkg.Architecture, error) {
+	if b.config.Arch != "" {
+		return dpkg.Architecture(b.config.Arch), nil
+	}
+	return dpkg.DetectArchitecture()
+}
+
 // Name returns the backend name
 func (b *DpkgBackend) Name() string {
 	return "dpkg"
@@ -0,0 +1,170 @@
+// pkg/backend/el.go
+package backend
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arc-language/upkg/pkg/el"
+)
+
+// ELBackend implements the Backend interface for Enterprise Linux
+// (Rocky, AlmaLinux, CentOS Stream and UBI) packages
+type ELBackend struct {
+	manager *el.PackageManager
+	config  *Config
+}
+
+// NewELBackend creates a new Enterprise Linux backend
+func NewELBackend(config *Config) (*ELBackend, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	// Release is "<distro>-<major>" (rocky-9, ubi-9) or a bare major version,
+	// in which case the host's distribution is kept when it is itself EL
+	distro, major := el.ParseRelease(resolveRelease(config, BackendEL, ""))
+	if distro == "" {
+		if d, err := DetectDistro(); err == nil && d.Backend == BackendEL {
+			distro, _ = el.ParseRelease(d.Release)
+		}
+	}
+
+	elConfig := &el.Config{
+		Distro:      distro,
+		Release:     major,
+		MirrorURL:   config.Mirror,
+		Repos:       config.Repos,
+		GPGKeys:     config.GPGKeys,
+		NoGPGCheck:  config.NoGPGCheck,
+		InstallPath: config.InstallPath,
+		CachePath:   config.CachePath,
+		Timeout:     config.Timeout,
+		Debug:       config.Debug,
+		Logger:      config.Logger,
+	}
+
+	manager, err := el.NewPackageManager(elConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ELBackend{
+		manager: manager,
+		config:  config,
+	}, nil
+}
+
+// Download downloads a package from Enterprise Linux repositories
+func (b *ELBackend) Download(ctx context.Context, pkg *Package, opts *DownloadOptions) error {
+	elOpts := &el.DownloadOptions{
+		Package:     pkg.Name,
+		Version:     pkg.Version,
+		Extract:     derefBool(opts.Extract, true),
+		KeepArchive: derefBool(opts.KeepArchive, false),
+		VerifyHash:  derefBool(opts.VerifyHash, true),
+	}
+
+	if platform := stringOr(opts.Platform, b.config.Arch); platform != "" {
+		elOpts.Architecture = el.Architecture(platform)
+	}
+
+	return b.manager.Download(ctx, elOpts)
+}
+
+// GetInfo retrieves package information from Enterprise Linux repositories
+func (b *ELBackend) GetInfo(ctx context.Context, name string) (*PackageInfo, error) {
+	arch, err := b.architecture()
+	if err != nil {
+		return nil, fmt.Errorf("detecting architecture: %w", err)
+	}
+
+	pkgInfo, err := b.manager.GetPackageInfo(ctx, name, arch)
+	if err != nil {
+		return nil, fmt.Errorf("getting package info: %w", err)
+	}
+
+	return &PackageInfo{
+		Name:        pkgInfo.Name,
+		Version:     pkgInfo.FullVersion(),
+		Description: pkgInfo.Description,
+		Homepage:    pkgInfo.URL,
+		License:     pkgInfo.License,
+		Platforms:   []string{pkgInfo.Architecture},
+		Backend:     "el",
+	}, nil
+}
+
+// Search searches for packages in Enterprise Linux repositories
+func (b *ELBackend) Search(ctx context.Context, query string) ([]*PackageInfo, error) {
+	arch, err := b.architecture()
+	if err != nil {
+		return nil, fmt.Errorf("detecting architecture: %w", err)
+	}
+
+	packages, err := b.manager.SearchPackages(ctx, query, arch)
+	if err != nil {
+		return nil, fmt.Errorf("searching packages: %w", err)
+	}
+
+	results := make([]*PackageInfo, 0, len(packages))
+	for _, pkg := range packages {
+		results = append(results, &PackageInfo{
+			Name:        pkg.Name,
+			Version:     pkg.FullVersion(),
+			Description: pkg.Description,
+			Homepage:    pkg.URL,
+			License:     pkg.License,
+			Platforms:   []string{pkg.Architecture},
+			Backend:     "el",
+		})
+	}
+
+	return results, nil
+}
+
+// FindFileOwners returns the packages that ship a path
+func (b *ELBackend) FindFileOwners(ctx context.Context, path string) ([]*PackageInfo, error) {
+	arch, err := b.architecture()
+	if err != nil {
+		return nil, fmt.Errorf("detecting architecture: %w", err)
+	}
+
+	packages, err := b.manager.FindFileOwners(ctx, path, arch)
+	if err != nil {
+		return nil, fmt.Errorf("finding file owners: %w", err)
+	}
+
+	results := make([]*PackageInfo, 0, len(packages))
+	for _, pkg := range packages {
+		results = append(results, &PackageInfo{
+			Name:        pkg.Name,
+			Version:     pkg.FullVersion(),
+			Description: pkg.Summary,
+			Homepage:    pkg.URL,
+			License:     pkg.License,
+			Platforms:   []string{pkg.Architecture},
+			Backend:     "el",
+		})
+	}
+
+	return results, nil
+}
+
+// architecture returns the configured target architecture, or the host's when unset
+func (b *ELBackend) architecture() (el.Architecture, error) {
+	if b.config.Arch != "" {
+		return el.Architecture(b.config.Arch), nil
+	}
+	return el.DetectArchitecture()
+}
+
+// Name returns the backend name
+func (b *ELBackend) Name() string {
+	return "el"
+}
+
+// Close cleans up resources
+func (b *ELBackend) Close() error {
+	return nil
+}
@@ -0,0 +1,178 @@
+// pkg/backend/guix.go
+package backend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/arc-language/upkg/pkg/guix"
+	"github.com/arc-language/upkg/pkg/nix"
+)
+
+// GuixBackend implements the Backend interface for GNU Guix substitutes.
+// Packages are resolved through the cached guix_<system>.json index and
+// installed, with their runtime closure, from the Guix build farms.
+type GuixBackend struct {
+	manager *guix.PackageManager
+	config  *Config
+}
+
+// NewGuixBackend creates a new Guix backend
+func NewGuixBackend(config *Config) (*GuixBackend, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	// Mirror and Repos name substitute servers, tried in order; GPGKeys
+	// adds authorized keys (.pub files or public-key sexps)
+	var servers []string
+	if config.Mirror != "" {
+		servers = append(servers, config.Mirror)
+	}
+	servers = append(servers, config.Repos...)
+
+	var keys []string
+	if len(config.GPGKeys) > 0 {
+		keys = append(append(keys, guix.DefaultAuthorizedKeys...), config.GPGKeys...)
+	}
+
+	guixConfig := &guix.Config{
+		SubstituteURLs: servers,
+		AuthorizedKeys: keys,
+		AllowUnsigned:  config.NoGPGCheck,
+		System:         guixSystem(config.Arch),
+		InstallPath:    config.InstallPath,
+		CachePath:      config.CachePath,
+		Timeout:        config.Timeout,
+		Debug:          config.Debug,
+		Logger:         config.Logger,
+	}
+
+	return &GuixBackend{
+		manager: guix.NewPackageManager(guixConfig),
+		config:  config,
+	}, nil
+}
+
+// Download installs a package's outputs (or pkg.Output) and their closure
+func (b *GuixBackend) Download(ctx context.Context, pkg *Package, opts *DownloadOptions) error {
+	guixOpts := &guix.DownloadOptions{
+		Extract:     derefBool(opts.Extract, true),
+		KeepArchive: derefBool(opts.KeepArchive, false),
+		VerifyHash:  derefBool(opts.VerifyHash, true),
+		Platform:    nix.Platform(guixSystem(opts.Platform)),
+	}
+	if pkg.Output != "" {
+		guixOpts.Outputs = []string{pkg.Output}
+	}
+
+	if err := b.manager.Download(ctx, pkg.Name, guixOpts); err != nil {
+		return err
+	}
+	if !guixOpts.Extract {
+		return nil
+	}
+	return rebuildProfile(b.config, "guix")
+}
+
+// Remove deletes an installed package (or only pkg.Output) and rebuilds the profile
+func (b *GuixBackend) Remove(ctx context.Context, pkg *Package) error {
+	var outputs []string
+	if pkg.Output != "" {
+		outputs = []string{pkg.Output}
+	}
+
+	if err := b.manager.Remove(pkg.Name, outputs); err != nil {
+		return err
+	}
+	return rebuildProfile(b.config, "guix")
+}
+
+// GetInfo retrieves package information from the Guix index
+func (b *GuixBackend) GetInfo(ctx context.Context, name string) (*PackageInfo, error) {
+	pkg, err := b.manager.LookupPackage(name)
+	if err != nil {
+		return nil, fmt.Errorf("looking up package: %w", err)
+	}
+	return b.packageInfo(pkg), nil
+}
+
+// Search searches the Guix index, best matches first
+func (b *GuixBackend) Search(ctx context.Context, query string) ([]*PackageInfo, error) {
+	matches, err := b.manager.Search(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Limit results to avoid overwhelming output
+	if len(matches) > 100 {
+		matches = matches[:100]
+	}
+
+	results := make([]*PackageInfo, 0, len(matches))
+	for i := range matches {
+		results = append(results, b.packageInfo(&matches[i]))
+	}
+	return results, nil
+}
+
+// packageInfo converts an index entry to PackageInfo
+func (b *GuixBackend) packageInfo(pkg *guix.Package) *PackageInfo {
+	version := pkg.Version
+	if version == "" {
+		version = pkg.NameVersion
+	}
+
+	platforms := []string(pkg.Platforms)
+	if len(platforms) == 0 {
+		platforms = []string{b.manager.System()}
+	}
+
+	return &PackageInfo{
+		Name:        pkg.Attribute,
+		Version:     version,
+		Description: pkg.Description,
+		Homepage:    pkg.Homepage,
+		License:     strings.Join(pkg.License, ", "),
+		Outputs:     parseStorePath(pkg.StorePath),
+		Platforms:   platforms,
+		Backend:     "guix",
+	}
+}
+
+// Name returns the backend name
+func (b *GuixBackend) Name() string {
+	return "guix"
+}
+
+// Close cleans up resources
+func (b *GuixBackend) Close() error {
+	return nil
+}
+
+// guixSystem turns a system or architecture into a Guix system:
+// "aarch64-linux" is used as-is, a bare "arm64" or "aarch64" becomes
+// "aarch64-linux". Empty stays empty so the host system applies.
+func guixSystem(arch string) string {
+	switch {
+	case arch == "":
+		return ""
+	case strings.Contains(arch, "-"):
+		return arch
+	}
+
+	switch arch {
+	case "amd64":
+		arch = "x86_64"
+	case "arm64":
+		arch = "aarch64"
+	case "386":
+		arch = "i686"
+	case "arm", "armv7", "armv7l":
+		arch = "armhf"
+	case "ppc64le":
+		arch = "powerpc64le"
+	}
+	return arch + "-linux"
+}
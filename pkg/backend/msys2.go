@@ -0,0 +1,112 @@
+// pkg/backend/msys2.go
+package backend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/arc-language/upkg/pkg/msys2"
+)
+
+// MSYS2Backend implements the Backend interface for MSYS2's MinGW
+// environments (mingw64, ucrt64, clang64, clangarm64). It targets Windows
+// regardless of the host, so Linux cross builds can fetch MinGW libraries.
+type MSYS2Backend struct {
+	manager *msys2.PackageManager
+	config  *Config
+}
+
+// NewMSYS2Backend creates a new MSYS2 backend
+func NewMSYS2Backend(config *Config) (*MSYS2Backend, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	// Release selects the environment ("ucrt64" or "/ucrt64"); the host's
+	// distribution never applies
+	msysConfig := &msys2.Config{
+		Environment: stringOr(config.Release, msys2.DefaultEnvironment),
+		MirrorURL:   config.Mirror,
+		GPGKeys:     config.GPGKeys,
+		NoGPGCheck:  config.NoGPGCheck,
+		InstallPath: config.InstallPath,
+		CachePath:   config.CachePath,
+		Timeout:     config.Timeout,
+		Debug:       config.Debug,
+		Logger:      config.Logger,
+	}
+
+	manager, err := msys2.NewPackageManager(msysConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MSYS2Backend{
+		manager: manager,
+		config:  config,
+	}, nil
+}
+
+// Download downloads a package from the environment's repository
+func (b *MSYS2Backend) Download(ctx context.Context, pkg *Package, opts *DownloadOptions) error {
+	msysOpts := &msys2.DownloadOptions{
+		Package:     pkg.Name,
+		Version:     pkg.Version,
+		Extract:     derefBool(opts.Extract, true),
+		KeepArchive: derefBool(opts.KeepArchive, false),
+		VerifyHash:  derefBool(opts.VerifyHash, true),
+	}
+
+	return b.manager.Download(ctx, msysOpts)
+}
+
+// GetInfo retrieves package information from the environment's repository
+func (b *MSYS2Backend) GetInfo(ctx context.Context, name string) (*PackageInfo, error) {
+	pkgInfo, err := b.manager.GetPackageInfo(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("getting package info: %w", err)
+	}
+
+	return &PackageInfo{
+		Name:        pkgInfo.Name,
+		Version:     pkgInfo.Version,
+		Description: pkgInfo.Description,
+		Homepage:    pkgInfo.URL,
+		License:     strings.Join(pkgInfo.License, ", "),
+		Platforms:   []string{b.manager.Environment().Name},
+		Backend:     "msys2",
+	}, nil
+}
+
+// Search searches for packages in the environment's repository
+func (b *MSYS2Backend) Search(ctx context.Context, query string) ([]*PackageInfo, error) {
+	packages, err := b.manager.SearchPackages(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("searching packages: %w", err)
+	}
+
+	results := make([]*PackageInfo, 0, len(packages))
+	for _, pkg := range packages {
+		results = append(results, &PackageInfo{
+			Name:        pkg.Name,
+			Version:     pkg.Version,
+			Description: pkg.Description,
+			Homepage:    pkg.URL,
+			Platforms:   []string{b.manager.Environment().Name},
+			Backend:     "msys2",
+		})
+	}
+
+	return results, nil
+}
+
+// Name returns the backend name
+func (b *MSYS2Backend) Name() string {
+	return "msys2"
+}
+
+// Close cleans up resources
+func (b *MSYS2Backend) Close() error {
+	return nil
+}
@@ -4,8 +4,13 @@ package backend
 import (
 	"context"
 	"fmt"
+	"log"
+	"os"
+	"path"
+	"runtime"
 	"strings"
 
+	"github.com/arc-language/upkg/pkg/env"
 	"github.com/arc-language/upkg/pkg/nix"
 )
 
@@ -21,13 +26,32 @@ func NewNixBackend(config *Config) (*NixBackend, error) {
 		config = DefaultConfig()
 	}
 
+	cacheURL := config.Nix.CacheURL
+	if config.Mirror != "" {
+		cacheURL = config.Mirror
+	}
+
+	var substituters []nix.Substituter
+	for _, sub := range config.Nix.Substituters {
+		substituters = append(substituters, nix.Substituter{
+			URL:        sub.URL,
+			Priority:   sub.Priority,
+			PublicKeys: sub.PublicKeys,
+		})
+	}
+
 	nixConfig := &nix.Config{
-		CacheURL:    config.Nix.CacheURL,
-		InstallPath: config.InstallPath,
-		CachePath:   config.CachePath, // Pass the cache path for index loading
-		Timeout:     config.Timeout,
-		Debug:       config.Debug,
-		Logger:      config.Logger,
+		CacheURL:          cacheURL,
+		Substituters:      substituters,
+		Platform:          nixPlatform(config.Arch),
+		TrustedPublicKeys: config.Nix.TrustedPublicKeys,
+		AllowUnsigned:     config.NoGPGCheck,
+		InstallPath:       config.InstallPath,
+		CachePath:         config.CachePath, // Pass the cache path for index loading
+		Timeout:           config.Timeout,
+		Debug:             config.Debug,
+		Logger:            config.Logger,
+		Progress:          config.Progress,
 	}
 
 	if nixConfig.Logger == nil && config.Debug {
@@ -54,6 +78,7 @@ func (b *NixBackend) Download(ctx context.Context, pkg *Package, opts *DownloadO
 		Extract:     derefBool(opts.Extract, true),
 		KeepArchive: derefBool(opts.KeepArchive, false),
 		VerifyHash:  derefBool(opts.VerifyHash, true),
+		Platform:    nixPlatform(opts.Platform),
 	}
 
 	// If specific outputs are requested via pkg.Output, use it
@@ -61,7 +86,48 @@ func (b *NixBackend) Download(ctx context.Context, pkg *Package, opts *DownloadO
 		nixOpts.Outputs = []string{pkg.Output}
 	}
 
-	return b.manager.Download(ctx, attribute, nixOpts)
+	if err := b.manager.Download(ctx, attribute, nixOpts); err != nil {
+		return err
+	}
+	if !nixOpts.Extract {
+		return nil
+	}
+	return rebuildProfile(b.config, "nix")
+}
+
+// Remove deletes an installed package (or only pkg.Output) and rebuilds the profile
+func (b *NixBackend) Remove(ctx context.Context, pkg *Package) error {
+	var outputs []string
+	if pkg.Output != "" {
+		outputs = []string{pkg.Output}
+	}
+
+	if err := b.manager.Remove(pkg.Name, outputs); err != nil {
+		return err
+	}
+	return rebuildProfile(b.config, "nix")
+}
+
+// rebuildProfile relinks InstallPath/profile from every installed output,
+// so the environment's bin, lib, include and share see the change. Nix and
+// Guix share the store-path layout it is built from.
+func rebuildProfile(config *Config, backendName string) error {
+	profile, err := env.New(config.InstallPath, backendName).RebuildProfile()
+	if err != nil {
+		return fmt.Errorf("rebuilding profile: %w", err)
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = log.New(os.Stderr, "", 0)
+	}
+	for _, c := range profile.Collisions {
+		logger.Printf("Warning: profile collision on %s: keeping %s, ignoring %s", c.Path, c.Kept, c.Ignored)
+	}
+	if config.Debug && config.Logger != nil {
+		config.Logger.Printf("Profile %s rebuilt (%d links, %d collisions)", profile.Path, profile.Links, len(profile.Collisions))
+	}
+	return nil
 }
 
 // GetInfo retrieves package information from Nix
@@ -72,54 +138,53 @@ func (b *NixBackend) GetInfo(ctx context.Context, name string) (*PackageInfo, er
 		return nil, fmt.Errorf("looking up package: %w", err)
 	}
 
-	// Parse the store path to get all available outputs
-	outputs := parseStorePath(pkg.StorePath)
-
-	return &PackageInfo{
-		Name:        pkg.Attribute,
-		Version:     pkg.NameVersion,
-		Description: "", // Not available in static registry
-		Outputs:     outputs,
-		Platforms:   []string{"x86_64-linux"}, // Only supported platform currently
-		Backend:     "nix",
-	}, nil
+	return b.packageInfo(pkg), nil
 }
 
-// Search searches for packages in the Nix registry
-// This is a simple substring match against attribute names
+// Search searches for packages in the Nix registry, best matches first
+// (exact attribute or name, then prefix, substring and description matches)
 func (b *NixBackend) Search(ctx context.Context, query string) ([]*PackageInfo, error) {
-	query = strings.ToLower(query)
-	var results []*PackageInfo
-
-	// Get the package registry from the manager instance
-	registry := b.manager.GetPackageRegistry()
-	
-	// Simple linear search through the registry
-	for _, pkg := range registry {
-		if strings.Contains(strings.ToLower(pkg.Attribute), query) ||
-			strings.Contains(strings.ToLower(pkg.NameVersion), query) {
-			
-			outputs := parseStorePath(pkg.StorePath)
-			
-			results = append(results, &PackageInfo{
-				Name:        pkg.Attribute,
-				Version:     pkg.NameVersion,
-				Description: "",
-				Outputs:     outputs,
-				Platforms:   []string{"x86_64-linux"},
-				Backend:     "nix",
-			})
-
-			// Limit results to avoid overwhelming output
-			if len(results) >= 100 {
-				break
-			}
-		}
+	matches, err := b.manager.Search(query, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Limit results to avoid overwhelming output
+	if len(matches) > 100 {
+		matches = matches[:100]
 	}
 
+	results := make([]*PackageInfo, 0, len(matches))
+	for i := range matches {
+		results = append(results, b.packageInfo(&matches[i]))
+	}
 	return results, nil
 }
 
+// packageInfo converts an index entry to PackageInfo
+func (b *NixBackend) packageInfo(pkg *nix.Package) *PackageInfo {
+	version := pkg.Version
+	if version == "" {
+		version = pkg.NameVersion
+	}
+
+	platforms := []string(pkg.Platforms)
+	if len(platforms) == 0 {
+		platforms = []string{b.manager.Platform().String()}
+	}
+
+	return &PackageInfo{
+		Name:        pkg.Attribute,
+		Version:     version,
+		Description: pkg.Description,
+		Homepage:    pkg.Homepage,
+		License:     strings.Join(pkg.License, ", "),
+		Outputs:     parseStorePath(pkg.StorePath),
+		Platforms:   platforms,
+		Backend:     "nix",
+	}
+}
+
 // Name returns the backend name
 func (b *NixBackend) Name() string {
 	return "nix"
@@ -130,6 +195,28 @@ func (b *NixBackend) Close() error {
 	return nil
 }
 
+// nixPlatform turns a platform or architecture into a Nix system:
+// "aarch64-linux" is used as-is, a bare "arm64" or "aarch64" is paired with
+// the host OS. Empty stays empty so the manager's default applies.
+func nixPlatform(arch string) nix.Platform {
+	switch {
+	case arch == "":
+		return ""
+	case strings.Contains(arch, "-"):
+		return nix.Platform(arch)
+	}
+
+	switch arch {
+	case "amd64":
+		arch = "x86_64"
+	case "arm64":
+		arch = "aarch64"
+	case "386":
+		arch = "i686"
+	}
+	return nix.Platform(arch + "-" + runtime.GOOS)
+}
+
 // parseStorePath parses a StorePath field into a map of outputs
 // Format: "bin=/nix/store/hash-name;dev=/nix/store/hash-name;/nix/store/hash-name"
 func parseStorePath(storePath string) map[string]string {
@@ -148,15 +235,15 @@ func parseStorePath(storePath string) map[string]string {
 			outputName := kv[0]
 			fullPath := kv[1]
 
-			// Extract hash from "/nix/store/hash-name-version"
-			pathWithoutPrefix := strings.TrimPrefix(fullPath, "/nix/store/")
+			// Extract hash from "/nix/store/hash-name-version" (or /gnu/store)
+			pathWithoutPrefix := path.Base(fullPath)
 			hashParts := strings.SplitN(pathWithoutPrefix, "-", 2)
 			if len(hashParts) > 0 {
 				outputs[outputName] = hashParts[0]
 			}
 		} else {
 			// Default output: "/nix/store/hash-name"
-			pathWithoutPrefix := strings.TrimPrefix(part, "/nix/store/")
+			pathWithoutPrefix := path.Base(part)
 			hashParts := strings.SplitN(pathWithoutPrefix, "-", 2)
 			if len(hashParts) > 0 {
 				outputs["out"] = hashParts[0]
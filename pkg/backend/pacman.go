@@ -22,14 +22,16 @@ func NewPacmanBackend(config *Config) (*PacmanBackend, error) {
 	}
 
 	pacmanConfig := &pacman.Config{
-		// In a real scenario, this might come from a config file
-		MirrorURL:   "https://geo.mirror.pkgbuild.com", 
-		Repos:       []string{"core", "extra"},
-		InstallPath: config.InstallPath,
-		CachePath:   config.CachePath,
-		Timeout:     config.Timeout,
-		Debug:       config.Debug,
-		Logger:      config.Logger,
+		MirrorURL:    config.Mirror, // Empty picks Arch Linux or Arch Linux ARM by architecture
+		Architecture: config.Arch,
+		Repos:        config.Repos, // Empty means core, extra (plus alarm on ARM); add multilib for lib32-*
+		GPGKeys:      config.GPGKeys,
+		NoGPGCheck:   config.NoGPGCheck,
+		InstallPath:  config.InstallPath,
+		CachePath:    config.CachePath,
+		Timeout:      config.Timeout,
+		Debug:        config.Debug,
+		Logger:       config.Logger,
 	}
 
 	manager := pacman.NewPackageManager(pacmanConfig)
@@ -50,8 +52,8 @@ func (b *PacmanBackend) Download(ctx context.Context, pkg *Package, opts *Downlo
 		VerifyHash:  derefBool(opts.VerifyHash, true),
 	}
 
-	if opts.Platform != "" {
-		pacOpts.Architecture = opts.Platform
+	if platform := stringOr(opts.Platform, b.config.Arch); platform != "" {
+		pacOpts.Architecture = platform
 	}
 
 	return b.manager.Download(ctx, pacOpts)
@@ -3,6 +3,7 @@ package backend
 
 import (
 	"context"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -16,6 +17,8 @@ type BackendType string
 const (
 	// BackendNix uses the Nix package manager
 	BackendNix BackendType = "nix"
+	// BackendGuix uses GNU Guix substitutes
+	BackendGuix BackendType = "guix"
 	// BackendBrew uses the Homebrew package manager
 	BackendBrew BackendType = "brew"
 	// BackendDpkg uses the Debian package manager
@@ -26,10 +29,14 @@ const (
 	BackendApk BackendType = "apk"
 	// BackendDnf uses the Fedora package manager
 	BackendDnf BackendType = "dnf"
+	// BackendEL uses Enterprise Linux repositories (Rocky, AlmaLinux, CentOS Stream, UBI)
+	BackendEL BackendType = "el"
 	// BackendChoco uses the choco package manager
 	BackendChoco BackendType = "choco"
 	// BackendPacman uses the Arch Linux package manager
 	BackendPacman BackendType = "pacman"
+	// BackendMSYS2 uses MSYS2's MinGW repositories (Windows libraries, from any host)
+	BackendMSYS2 BackendType = "msys2"
 	// BackendZypper uses the OpenSUSE package manager
 	BackendZypper BackendType = "zypper"
 	// BackendWinget uses the Windows Package Manager
@@ -56,6 +63,21 @@ type Backend interface {
 	Close() error
 }
 
+// FileOwnerFinder is implemented by backends that can tell which package
+// ships a given file path (dnf, el and zypper, via repository filelists)
+type FileOwnerFinder interface {
+	// FindFileOwners returns the packages that ship path; a bare file name
+	// matches it in any directory
+	FindFileOwners(ctx context.Context, path string) ([]*PackageInfo, error)
+}
+
+// Remover is implemented by backends that install each package into its
+// own directory and can therefore remove one again (nix, guix, brew)
+type Remover interface {
+	// Remove deletes an installed package, or only pkg.Output when set
+	Remove(ctx context.Context, pkg *Package) error
+}
+
 // Package represents a package to download
 type Package struct {
 	Name    string // Package name (e.g., "wget", "gcc")
@@ -102,6 +124,44 @@ type Config struct {
 	// Logger for custom logging
 	Logger *log.Logger
 
+	// Progress receives user-facing progress messages, such as the size of
+	// a nix closure before it is fetched. Nil discards them.
+	Progress io.Writer
+
+	// Release overrides the distribution release detected from /etc/os-release,
+	// in the selected backend's own terms (e.g. "jammy" for apt, "41" for dnf,
+	// "v3.20" for apk, "15.6" for zypper, "rocky-9" for el). Set it to cross-target
+	// another release. For msys2 it names the environment (ucrt64, mingw64, clang64).
+	Release string
+
+	// Arch selects the target architecture in the backend's own terms
+	// (e.g. "arm64" for apt, "aarch64" for dnf). Empty means the host's.
+	Arch string
+
+	// Mirror replaces the backend's default repository base URL
+	Mirror string
+
+	// Repos replaces the backend's default repositories or components
+	// (e.g. main,universe for apt; core,extra for pacman)
+	Repos []string
+
+	// GPGKeys adds trusted package signing keys (paths or URLs) for backends
+	// that verify signatures (dnf, el, zypper, pacman; *.rsa.pub keys for apk)
+	GPGKeys []string
+
+	// NoGPGCheck skips package signature checks (and APKINDEX signatures for
+	// apk, narinfo signatures for nix); header and payload digests are still verified
+	NoGPGCheck bool
+
+	// Relocate rewrites the RUNPATH of every ELF file in InstallPath to
+	// $ORIGIN-relative paths inside it after extraction (default: false).
+	// Meant for InstallPaths that hold one environment's packages only.
+	Relocate bool
+
+	// SetInterpreter also points relocated executables at the environment's
+	// own dynamic loader instead of the host's; it implies Relocate
+	SetInterpreter bool
+
 	// Nix-specific configuration
 	Nix *NixConfig
 
@@ -111,7 +171,16 @@ type Config struct {
 
 // NixConfig holds Nix-specific configuration
 type NixConfig struct {
-	CacheURL string // Default: https://cache.nixos.org
+	CacheURL          string           // Default: https://cache.nixos.org
+	Substituters      []NixSubstituter // Binary caches tried by priority (default: CacheURL alone)
+	TrustedPublicKeys []string         // "name:base64" narinfo signing keys (default: cache.nixos.org-1)
+}
+
+// NixSubstituter is one Nix binary cache
+type NixSubstituter struct {
+	URL        string   // e.g. https://cache.example.com
+	Priority   int      // Lower is tried first; 0 reads it from the cache's nix-cache-info
+	PublicKeys []string // "name:base64" keys trusted for this cache only
 }
 
 // BrewConfig holds Homebrew-specific configuration
@@ -150,4 +219,20 @@ func derefBool(ptr *bool, defaultVal bool) bool {
 		return defaultVal
 	}
 	return *ptr
+}
+
+// stringOr returns value, or fallback when value is empty
+func stringOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// reposOr returns the configured repositories, or fallback when none are set
+func reposOr(config *Config, fallback []string) []string {
+	if len(config.Repos) == 0 {
+		return fallback
+	}
+	return config.Repos
 }
\ No newline at end of file
@@ -20,9 +20,12 @@ func NewZypperBackend(config *Config) (*ZypperBackend, error) {
 	}
 
 	zypConfig := &zypper.Config{
-		MirrorURL:    "http://download.opensuse.org",
-		Distribution: "tumbleweed", // Default to rolling
-		Repos:        []string{"repo/oss"},
+		MirrorURL:    stringOr(config.Mirror, "http://download.opensuse.org"),
+		Distribution: zypper.DistributionFor(resolveRelease(config, BackendZypper, zypper.DefaultDistribution)),
+		Architecture: config.Arch,
+		Repos:        config.Repos, // empty selects the distribution's defaults (oss + updates)
+		GPGKeys:      config.GPGKeys,
+		NoGPGCheck:   config.NoGPGCheck,
 		InstallPath:  config.InstallPath,
 		CachePath:    config.CachePath,
 		Timeout:      config.Timeout,
@@ -47,8 +50,8 @@ func (b *ZypperBackend) Download(ctx context.Context, pkg *Package, opts *Downlo
 		VerifyHash:  derefBool(opts.VerifyHash, true),
 	}
 
-	if opts.Platform != "" {
-		zOpts.Architecture = opts.Platform
+	if platform := stringOr(opts.Platform, b.config.Arch); platform != "" {
+		zOpts.Architecture = platform
 	}
 
 	return b.manager.Download(ctx, zOpts)
@@ -107,6 +110,29 @@ func (b *ZypperBackend) Search(ctx context.Context, query string) ([]*PackageInf
 	return results, nil
 }
 
+// FindFileOwners returns the packages that ship a path
+func (b *ZypperBackend) FindFileOwners(ctx context.Context, path string) ([]*PackageInfo, error) {
+	packages, err := b.manager.FindFileOwners(ctx, path, b.config.Arch)
+	if err != nil {
+		return nil, fmt.Errorf("finding file owners: %w", err)
+	}
+
+	results := make([]*PackageInfo, 0, len(packages))
+	for _, pkg := range packages {
+		results = append(results, &PackageInfo{
+			Name:        pkg.Name,
+			Version:     pkg.Version,
+			Description: pkg.Summary,
+			Homepage:    pkg.URL,
+			License:     pkg.License,
+			Platforms:   []string{pkg.Architecture},
+			Backend:     "zypper",
+		})
+	}
+
+	return results, nil
+}
+
 func (b *ZypperBackend) Name() string {
 	return "zypper"
 }
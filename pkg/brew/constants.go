@@ -14,6 +14,28 @@ const (
 	// DefaultInstallPathARM is the default Homebrew install path for ARM Macs
 	DefaultInstallPathARM = "/opt/homebrew"
 
+	// DefaultInstallPathLinux is the default Homebrew install path on Linux
+	DefaultInstallPathLinux = "/home/linuxbrew/.linuxbrew"
+
 	// DefaultCellar is the Cellar subdirectory name
 	DefaultCellar = "Cellar"
-)
\ No newline at end of file
+
+	// IndexFile is the name of the cached bulk formula index
+	IndexFile = "brew_formula.json"
+)
+
+// Placeholders written into bottles in place of install-time paths
+const (
+	PlaceholderPrefix     = "@@HOMEBREW_PREFIX@@"
+	PlaceholderCellar     = "@@HOMEBREW_CELLAR@@"
+	PlaceholderRepository = "@@HOMEBREW_REPOSITORY@@"
+	PlaceholderLibrary    = "@@HOMEBREW_LIBRARY@@"
+	PlaceholderPerl       = "@@HOMEBREW_PERL@@"
+	PlaceholderJava       = "@@HOMEBREW_JAVA@@"
+)
+
+// Bottle cellar values for bottles that can be poured into any prefix
+const (
+	CellarAny               = ":any"
+	CellarAnySkipRelocation = ":any_skip_relocation"
+)
@@ -0,0 +1,195 @@
+// index.go
+package brew
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arc-language/upkg/pkg/search"
+)
+
+// jwsEnvelope is the JSON Web Signature wrapper of formula.jws.json. Its
+// payload is the formula.json array, stored as a string.
+type jwsEnvelope struct {
+	Payload string `json:"payload"`
+}
+
+// FetchIndex downloads the bulk formula index from apiURL and stores it at
+// dest as a plain JSON array of formulae. formula.jws.json, the file brew
+// itself uses, is tried first, then formula.json. The JWS signature is not
+// checked; like the bottles, the index is trusted through HTTPS.
+func FetchIndex(ctx context.Context, apiURL, dest string) error {
+	client := NewClientWithTimeout(5 * time.Minute)
+
+	data, err := fetchJWSIndex(ctx, client, apiURL+"/formula.jws.json")
+	if err != nil {
+		data, err = fetch(ctx, client, apiURL+"/formula.json")
+		if err != nil {
+			return fmt.Errorf("fetching formula index: %w", err)
+		}
+	}
+
+	var formulae []json.RawMessage
+	if err := json.Unmarshal(data, &formulae); err != nil {
+		return fmt.Errorf("parsing formula index: %w", err)
+	}
+	if len(formulae) == 0 {
+		return fmt.Errorf("formula index is empty")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	tmp := dest + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
+
+// fetchJWSIndex downloads formula.jws.json and returns its payload
+func fetchJWSIndex(ctx context.Context, client *Client, url string) ([]byte, error) {
+	data, err := fetch(ctx, client, url)
+	if err != nil {
+		return nil, err
+	}
+
+	var jws jwsEnvelope
+	if err := json.Unmarshal(data, &jws); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", url, err)
+	}
+	payload := strings.TrimSpace(jws.Payload)
+	if strings.HasPrefix(payload, "[") {
+		return []byte(payload), nil
+	}
+	// A payload that is not JSON is base64url-encoded
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(payload, "="))
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s payload: %w", url, err)
+	}
+	return decoded, nil
+}
+
+func fetch(ctx context.Context, client *Client, url string) ([]byte, error) {
+	resp, err := client.Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// loadIndex reads the cached formula index once. It returns nil without an
+// error when there is no index yet, so callers fall back to the API.
+func (pm *PackageManager) loadIndex() (map[string]*FormulaInfo, error) {
+	pm.indexOnce.Do(func() {
+		if pm.config.IndexPath == "" {
+			return
+		}
+		data, err := os.ReadFile(pm.config.IndexPath)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				pm.indexErr = fmt.Errorf("reading formula index: %w", err)
+			}
+			return
+		}
+		if err := json.Unmarshal(data, &pm.formulae); err != nil {
+			pm.indexErr = fmt.Errorf("parsing formula index %s: %w", pm.config.IndexPath, err)
+			return
+		}
+
+		pm.index = make(map[string]*FormulaInfo, len(pm.formulae))
+		for i := range pm.formulae {
+			f := &pm.formulae[i]
+			names := []string{f.Name, f.FullName}
+			names = append(names, f.Aliases...)
+			names = append(names, f.OldNames...)
+			for _, name := range names {
+				// Real names win over aliases and old names of other formulae
+				if _, taken := pm.index[name]; name != "" && (!taken || name == f.Name) {
+					pm.index[name] = f
+				}
+			}
+		}
+		pm.logger.Printf("Loaded %d formulae from %s", len(pm.formulae), pm.config.IndexPath)
+	})
+	return pm.index, pm.indexErr
+}
+
+// Search returns the formulae of the local index matching query on their
+// name, aliases or description, ranked by search.Score. The index is
+// fetched on first use.
+func (pm *PackageManager) Search(ctx context.Context, query string) ([]*FormulaInfo, error) {
+	index, err := pm.formulaIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if index == nil {
+		return nil, fmt.Errorf("searching formulae needs an index path")
+	}
+
+	words := search.Words(query)
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	type match struct {
+		formula *FormulaInfo
+		score   int
+	}
+	var matches []match
+	for i := range pm.formulae {
+		f := &pm.formulae[i]
+		if score, ok := search.Score(words, append([]string{f.Name}, f.Aliases...), f.Description); ok {
+			matches = append(matches, match{f, score})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.score != b.score {
+			return a.score < b.score
+		}
+		if len(a.formula.Name) != len(b.formula.Name) {
+			return len(a.formula.Name) < len(b.formula.Name)
+		}
+		return a.formula.Name < b.formula.Name
+	})
+
+	results := make([]*FormulaInfo, len(matches))
+	for i, m := range matches {
+		results[i] = m.formula
+	}
+	return results, nil
+}
+
+// formulaIndex returns the local formula index, downloading it to
+// IndexPath first if there is none yet. It returns nil without an error
+// when no IndexPath is configured.
+func (pm *PackageManager) formulaIndex(ctx context.Context) (map[string]*FormulaInfo, error) {
+	index, err := pm.loadIndex()
+	if err != nil || index != nil || pm.config.IndexPath == "" {
+		return index, err
+	}
+	if pm.fetchErr != nil {
+		return nil, pm.fetchErr
+	}
+
+	fmt.Fprintf(pm.config.Progress, "Fetching Homebrew formula index from %s...\n", pm.config.APIURL)
+	if err := FetchIndex(ctx, pm.config.APIURL, pm.config.IndexPath); err != nil {
+		pm.fetchErr = err
+		return nil, err
+	}
+
+	pm.indexOnce = sync.Once{}
+	return pm.loadIndex()
+}
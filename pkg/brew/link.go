@@ -0,0 +1,285 @@
+// link.go
+package brew
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LinkDirs are the keg directories linked into the prefix
+var LinkDirs = []string{"bin", "lib", "include", "share"}
+
+// LinkConflict is a prefix path a keg wants to link that is already taken
+type LinkConflict struct {
+	Path  string // Path in the prefix
+	Owner string // Keg the existing link points into, or "" for a file brew does not manage
+}
+
+// LinkError reports the conflicts that kept a keg from being linked into
+// the prefix
+type LinkError struct {
+	Keg       string
+	Conflicts []LinkConflict
+}
+
+func (e *LinkError) Error() string {
+	lines := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		if c.Owner != "" {
+			lines = append(lines, fmt.Sprintf("%s (linked from %s)", c.Path, c.Owner))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s (already exists)", c.Path))
+		}
+	}
+	return fmt.Sprintf("cannot link %s, %d conflicting paths:\n  %s", e.Keg, len(lines), strings.Join(lines, "\n  "))
+}
+
+// cellarPath returns the prefix's Cellar directory
+func (pm *PackageManager) cellarPath() string {
+	return filepath.Join(pm.config.InstallPath, DefaultCellar)
+}
+
+// OptPath returns opt/<formula>, the version-independent path of a formula
+func (pm *PackageManager) OptPath(formula string) string {
+	return filepath.Join(pm.config.InstallPath, "opt", formula)
+}
+
+// linkedRecord returns var/homebrew/linked/<formula>, which brew keeps
+// pointing at the keg currently linked into the prefix
+func (pm *PackageManager) linkedRecord(formula string) string {
+	return filepath.Join(pm.config.InstallPath, "var", "homebrew", "linked", formula)
+}
+
+// Link makes a keg the formula's current version, as `brew link` does:
+// opt/<formula> points at the keg, and unless the formula is keg-only the
+// files under its bin, lib, include and share are symlinked into the
+// prefix. Another version of the formula that is linked is unlinked first.
+// If any path is taken by another keg or an unmanaged file a *LinkError
+// listing every conflict is returned and nothing is linked into the prefix;
+// like brew, the keg keeps its opt/ link.
+func (pm *PackageManager) Link(formula, keg string, kegOnly bool) error {
+	if err := relativeSymlink(keg, pm.OptPath(formula)); err != nil {
+		return fmt.Errorf("linking opt/%s: %w", formula, err)
+	}
+	if kegOnly {
+		pm.logger.Printf("  %s is keg-only, only linked into opt/", formula)
+		return nil
+	}
+
+	// Check the whole keg before touching the prefix
+	var conflicts []LinkConflict
+	if err := pm.linkKeg(formula, keg, func(c LinkConflict) {
+		conflicts = append(conflicts, c)
+	}, true); err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return &LinkError{Keg: keg, Conflicts: conflicts}
+	}
+
+	if err := pm.Unlink(formula); err != nil {
+		return err
+	}
+	if err := pm.linkKeg(formula, keg, nil, false); err != nil {
+		return err
+	}
+	if err := relativeSymlink(keg, pm.linkedRecord(formula)); err != nil {
+		return fmt.Errorf("recording linked keg: %w", err)
+	}
+	return nil
+}
+
+// linkKeg walks the keg's LinkDirs and links each file into the prefix,
+// creating real directories as needed. With dryRun it only reports
+// conflicts. Links into another version of the same formula are not
+// conflicts: Link unlinks them before linking.
+func (pm *PackageManager) linkKeg(formula, keg string, conflict func(LinkConflict), dryRun bool) error {
+	for _, dir := range LinkDirs {
+		root := filepath.Join(keg, dir)
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			continue
+		}
+
+		err := filepath.WalkDir(root, func(src string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(keg, src)
+			dst := filepath.Join(pm.config.InstallPath, rel)
+
+			existing, err := os.Lstat(dst)
+			if errors.Is(err, fs.ErrNotExist) {
+				if dryRun {
+					if d.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if d.IsDir() {
+					return os.MkdirAll(dst, 0755)
+				}
+				return relativeSymlink(src, dst)
+			}
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case d.IsDir() && existing.IsDir():
+				return nil
+			case existing.Mode()&fs.ModeSymlink != 0:
+				owner := pm.linkOwner(dst)
+				if !exists(dst) {
+					// Dangling link, e.g. left by a removed keg
+					if !dryRun {
+						os.Remove(dst)
+						return relativeSymlink(src, dst)
+					}
+					return nil
+				}
+				if owner == keg || pm.kegFormula(owner) == formula {
+					if !dryRun && owner != keg {
+						return relativeSymlink(src, dst)
+					}
+					if d.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if conflict != nil {
+					conflict(LinkConflict{Path: dst, Owner: owner})
+				}
+			default:
+				if conflict != nil {
+					conflict(LinkConflict{Path: dst})
+				}
+			}
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("linking %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// Unlink removes the prefix symlinks pointing into any keg of the formula
+// and prunes directories left empty, as `brew unlink` does. opt/<formula>
+// is kept.
+func (pm *PackageManager) Unlink(formula string) error {
+	for _, dir := range LinkDirs {
+		root := filepath.Join(pm.config.InstallPath, dir)
+		if _, err := os.Lstat(root); err != nil {
+			continue
+		}
+
+		var dirs []string
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				dirs = append(dirs, path)
+				return nil
+			}
+			if d.Type()&fs.ModeSymlink != 0 && pm.kegFormula(pm.linkOwner(path)) == formula {
+				return os.Remove(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("unlinking %s: %w", formula, err)
+		}
+
+		// Deepest first, so emptied parents go too; the top-level directory stays
+		sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+		for _, d := range dirs {
+			if d != root {
+				os.Remove(d) // Fails unless empty
+			}
+		}
+	}
+
+	os.Remove(pm.linkedRecord(formula))
+	return nil
+}
+
+// Remove uninstalls a formula: it is unlinked and its opt/ link and every
+// keg under Cellar/<formula> are deleted
+func (pm *PackageManager) Remove(formula string) error {
+	if _, err := os.Stat(filepath.Join(pm.cellarPath(), formula)); err != nil {
+		return fmt.Errorf("%s is not installed", formula)
+	}
+	if err := pm.Unlink(formula); err != nil {
+		return err
+	}
+	os.Remove(pm.OptPath(formula))
+	if err := os.RemoveAll(filepath.Join(pm.cellarPath(), formula)); err != nil {
+		return fmt.Errorf("removing %s: %w", formula, err)
+	}
+	return nil
+}
+
+// linkOwner returns the keg (Cellar/<formula>/<version>) a symlink in the
+// prefix points into, or "" if it points elsewhere
+func (pm *PackageManager) linkOwner(link string) string {
+	target, err := os.Readlink(link)
+	if err != nil {
+		return ""
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(link), target)
+	}
+
+	rel, err := filepath.Rel(pm.cellarPath(), target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return ""
+	}
+	parts := strings.SplitN(filepath.ToSlash(rel), "/", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	return filepath.Join(pm.cellarPath(), parts[0], parts[1])
+}
+
+// kegFormula returns the formula name of a keg path, or ""
+func (pm *PackageManager) kegFormula(keg string) string {
+	if keg == "" {
+		return ""
+	}
+	return filepath.Base(filepath.Dir(keg))
+}
+
+// relativeSymlink points link at target with a relative path, replacing
+// an existing link, so the prefix can be moved as a whole
+func relativeSymlink(target, link string) error {
+	if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(filepath.Dir(link), target)
+	if err != nil {
+		rel = target
+	}
+	if info, err := os.Lstat(link); err == nil {
+		if info.Mode()&fs.ModeSymlink == 0 {
+			return fmt.Errorf("%s exists and is not a symlink", link)
+		}
+		if err := os.Remove(link); err != nil {
+			return err
+		}
+	}
+	return os.Symlink(rel, link)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -8,6 +8,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -53,6 +54,10 @@ func NewPackageManager(cfg *Config) *PackageManager {
 		}
 	}
 
+	if cfg.Progress == nil {
+		cfg.Progress = io.Discard
+	}
+
 	pm := &PackageManager{
 		client: NewClientWithTimeout(cfg.Timeout),
 		config: cfg,
@@ -156,11 +161,15 @@ func (pm *PackageManager) downloadRecursive(ctx context.Context, opts *DownloadO
 
 	// 3. Get OCI manifest and find bottle
 	pm.logger.Printf("Step 3: Fetching OCI manifest for %s...", opts.Formula)
-	bottleDigest, bottleURL, sha256Hash, err := pm.getBottleInfo(ctx, opts.Formula, version, opts.Platform)
+	tag, bottleFile := selectBottle(formula, opts.Platform)
+	bottleDigest, bottleURL, sha256Hash, err := pm.getBottleInfo(ctx, opts.Formula, version, opts.Platform, tag)
 	if err != nil {
 		return fmt.Errorf("getting bottle info: %w", err)
 	}
 	pm.logger.Printf("  ✓ Bottle found for platform %s", opts.Platform)
+	if tag != "" && tag != string(opts.Platform) {
+		pm.logger.Printf("    Using the %s bottle", tag)
+	}
 	pm.logger.Printf("    Digest: %s", bottleDigest)
 	pm.logger.Printf("    SHA256: %s", sha256Hash)
 
@@ -189,11 +198,36 @@ func (pm *PackageManager) downloadRecursive(ctx context.Context, opts *DownloadO
 	if opts.Extract {
 		pm.logger.Printf("Step 6: Extracting bottle...")
 		cellarPath := filepath.Join(pm.config.InstallPath, DefaultCellar)
-		if err := pm.extractBottle(bottlePath, cellarPath); err != nil {
+		kegs, err := pm.extractBottle(bottlePath, cellarPath)
+		if err != nil {
 			return fmt.Errorf("extracting bottle: %w", err)
 		}
 		pm.logger.Printf("  ✓ Extraction complete")
 
+		// Replace the bottle's placeholders with this prefix
+		for _, keg := range kegs {
+			if err := pm.relocateKeg(keg, bottleFile.Cellar); err != nil {
+				return fmt.Errorf("relocating keg: %w", err)
+			}
+		}
+		pm.logger.Printf("  ✓ Relocated to %s", pm.config.InstallPath)
+
+		// Link the keg into opt/ and the prefix. As with brew, a conflict
+		// leaves the keg installed but unlinked, reachable through opt/
+		for _, keg := range kegs {
+			err := pm.Link(pm.kegFormula(keg), keg, formula.KegOnly)
+			var linkErr *LinkError
+			if errors.As(err, &linkErr) {
+				fmt.Fprintf(pm.config.Progress, "Warning: %v\n  %s is installed but not linked into %s; it is available under %s\n",
+					linkErr, pm.kegFormula(keg), pm.config.InstallPath, pm.OptPath(pm.kegFormula(keg)))
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("linking keg: %w", err)
+			}
+		}
+		pm.logger.Printf("  ✓ Linked into %s", pm.config.InstallPath)
+
 		// 7. Cleanup archive if requested
 		if !opts.KeepArchive {
 			pm.logger.Printf("Step 7: Removing archive file...")
@@ -213,8 +247,21 @@ func (pm *PackageManager) downloadRecursive(ctx context.Context, opts *DownloadO
 	return nil
 }
 
-// GetFormulaInfo retrieves formula information from the API
+// GetFormulaInfo retrieves formula information from the local index,
+// fetching it on first use. The per-formula API is used when the index is
+// unavailable or does not have the formula (yet).
 func (pm *PackageManager) GetFormulaInfo(ctx context.Context, formula string) (*FormulaInfo, error) {
+	index, err := pm.formulaIndex(ctx)
+	if err != nil {
+		pm.logger.Printf("⚠️  Warning: %v; using the formula API", err)
+	}
+	if info, ok := index[formula]; ok {
+		return info, nil
+	}
+	if index != nil {
+		pm.logger.Printf("Formula %s not found in index %s", formula, pm.config.IndexPath)
+	}
+
 	url := fmt.Sprintf("%s/formula/%s.json", pm.config.APIURL, formula)
 	pm.logger.Printf("Fetching formula info from: %s", url)
 
@@ -228,8 +275,39 @@ func (pm *PackageManager) GetFormulaInfo(ctx context.Context, formula string) (*
 	return &info, nil
 }
 
-// getBottleInfo retrieves bottle information from OCI registry
-func (pm *PackageManager) getBottleInfo(ctx context.Context, formula, version string, platform Platform) (string, string, string, error) {
+// selectBottle picks the formula's bottle for platform the way brew does:
+// the platform's own tag, else an "all" bottle, else the newest bottle for
+// an older macOS release on the same architecture. It returns an empty tag
+// when the formula lists no usable bottle.
+func selectBottle(formula *FormulaInfo, platform Platform) (string, BottleFileInfo) {
+	files := formula.Bottle.Stable.Files
+	if file, ok := files[string(platform)]; ok {
+		return string(platform), file
+	}
+	if file, ok := files["all"]; ok {
+		return "all", file
+	}
+
+	older := false
+	for _, candidate := range AllPlatforms {
+		if candidate == platform {
+			older = true
+			continue
+		}
+		if !older || candidate.ToOCI() != platform.ToOCI() || strings.HasSuffix(string(candidate), "_linux") {
+			continue
+		}
+		if file, ok := files[string(candidate)]; ok {
+			return string(candidate), file
+		}
+	}
+	return "", BottleFileInfo{}
+}
+
+// getBottleInfo retrieves bottle information from OCI registry. With a
+// bottle tag the manifest for that tag is used, otherwise the first one
+// for the platform's architecture.
+func (pm *PackageManager) getBottleInfo(ctx context.Context, formula, version string, platform Platform, tag string) (string, string, string, error) {
 	// Get OCI manifest
 	manifestURL := fmt.Sprintf("%s/%s/manifests/%s", pm.config.RegistryURL, formula, version)
 	pm.logger.Printf("Fetching OCI manifest from: %s", manifestURL)
@@ -253,7 +331,10 @@ func (pm *PackageManager) getBottleInfo(ctx context.Context, formula, version st
 	// Find matching platform
 	ociArch := platform.ToOCI()
 	for _, m := range manifest.Manifests {
-		if m.Platform.Architecture == ociArch {
+		if tag != "" && !strings.HasSuffix(m.Annotations["org.opencontainers.image.ref.name"], "."+tag) {
+			continue
+		}
+		if tag != "" || m.Platform.Architecture == ociArch {
 			// Extract bottle digest from annotations
 			bottleDigest, ok := m.Annotations["sh.brew.bottle.digest"]
 			if !ok {
@@ -383,21 +464,22 @@ func (pm *PackageManager) verifyFileHash(filePath, expectedHash string) error {
 	return nil
 }
 
-// extractBottle extracts a bottle tarball
-func (pm *PackageManager) extractBottle(bottlePath, cellarPath string) error {
+// extractBottle extracts a bottle tarball and returns the kegs
+// (Cellar/<formula>/<version>) it contained
+func (pm *PackageManager) extractBottle(bottlePath, cellarPath string) ([]string, error) {
 	pm.logger.Printf("Extracting bottle: %s -> %s", bottlePath, cellarPath)
 
 	// Open the tarball
 	f, err := os.Open(bottlePath)
 	if err != nil {
-		return fmt.Errorf("opening bottle: %w", err)
+		return nil, fmt.Errorf("opening bottle: %w", err)
 	}
 	defer f.Close()
 
 	// Create gzip reader
 	gzr, err := gzip.NewReader(f)
 	if err != nil {
-		return fmt.Errorf("creating gzip reader: %w", err)
+		return nil, fmt.Errorf("creating gzip reader: %w", err)
 	}
 	defer gzr.Close()
 
@@ -405,6 +487,8 @@ func (pm *PackageManager) extractBottle(bottlePath, cellarPath string) error {
 	tr := tar.NewReader(gzr)
 
 	// Track statistics
+	var kegs []string
+	seen := make(map[string]bool)
 	fileCount := 0
 	dirCount := 0
 	symlinkCount := 0
@@ -416,18 +500,26 @@ func (pm *PackageManager) extractBottle(bottlePath, cellarPath string) error {
 			break
 		}
 		if err != nil {
-			return fmt.Errorf("reading tar entry: %w", err)
+			return nil, fmt.Errorf("reading tar entry: %w", err)
 		}
 
 		// Construct target path
 		targetPath := filepath.Join(cellarPath, header.Name)
 
+		if parts := strings.SplitN(filepath.ToSlash(filepath.Clean(header.Name)), "/", 3); len(parts) >= 2 {
+			keg := filepath.Join(cellarPath, parts[0], parts[1])
+			if !seen[keg] {
+				seen[keg] = true
+				kegs = append(kegs, keg)
+			}
+		}
+
 		// Handle different file types
 		switch header.Typeflag {
 		case tar.TypeDir:
 			// Create directory
 			if err := os.MkdirAll(targetPath, 0755); err != nil {
-				return fmt.Errorf("creating directory %s: %w", targetPath, err)
+				return nil, fmt.Errorf("creating directory %s: %w", targetPath, err)
 			}
 			dirCount++
 			pm.logger.Printf("  📁 %s/", header.Name)
@@ -435,12 +527,12 @@ func (pm *PackageManager) extractBottle(bottlePath, cellarPath string) error {
 		case tar.TypeSymlink:
 			// Create symlink
 			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-				return fmt.Errorf("creating parent directory for symlink: %w", err)
+				return nil, fmt.Errorf("creating parent directory for symlink: %w", err)
 			}
 			if err := os.Symlink(header.Linkname, targetPath); err != nil {
 				// Ignore if symlink already exists
 				if !os.IsExist(err) {
-					return fmt.Errorf("creating symlink %s -> %s: %w", targetPath, header.Linkname, err)
+					return nil, fmt.Errorf("creating symlink %s -> %s: %w", targetPath, header.Linkname, err)
 				}
 			}
 			symlinkCount++
@@ -449,23 +541,23 @@ func (pm *PackageManager) extractBottle(bottlePath, cellarPath string) error {
 		case tar.TypeReg:
 			// Regular file
 			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-				return fmt.Errorf("creating parent directory: %w", err)
+				return nil, fmt.Errorf("creating parent directory: %w", err)
 			}
 
 			// Create and write file
 			outFile, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
 			if err != nil {
-				return fmt.Errorf("creating file %s: %w", targetPath, err)
+				return nil, fmt.Errorf("creating file %s: %w", targetPath, err)
 			}
 
 			written, err := io.Copy(outFile, tr)
 			outFile.Close()
 			if err != nil {
-				return fmt.Errorf("writing file %s: %w", targetPath, err)
+				return nil, fmt.Errorf("writing file %s: %w", targetPath, err)
 			}
 
 			if written != header.Size {
-				return fmt.Errorf("file size mismatch for %s: expected %d, got %d", targetPath, header.Size, written)
+				return nil, fmt.Errorf("file size mismatch for %s: expected %d, got %d", targetPath, header.Size, written)
 			}
 
 			fileCount++
@@ -485,5 +577,5 @@ func (pm *PackageManager) extractBottle(bottlePath, cellarPath string) error {
 	pm.logger.Printf("  - %d directories", dirCount)
 	pm.logger.Printf("  - %d symlinks", symlinkCount)
 
-	return nil
+	return kegs, nil
 }
\ No newline at end of file
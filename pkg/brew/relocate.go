@@ -0,0 +1,250 @@
+// relocate.go
+package brew
+
+import (
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/arc-language/upkg/pkg/patchelf"
+)
+
+// replacement is one path substituted during relocation
+type replacement struct {
+	old string
+	new string
+}
+
+// relocations returns what a bottle's placeholders (and, for bottles built
+// for a fixed cellar, its build prefix) are replaced with for InstallPath
+func (pm *PackageManager) relocations(bottleCellar string) []replacement {
+	prefix := pm.config.InstallPath
+	repository := prefix
+	if prefix == DefaultInstallPathIntel || prefix == DefaultInstallPathLinux {
+		repository = filepath.Join(prefix, "Homebrew")
+	}
+
+	repls := []replacement{
+		{PlaceholderPrefix, prefix},
+		{PlaceholderCellar, filepath.Join(prefix, DefaultCellar)},
+		{PlaceholderRepository, repository},
+		{PlaceholderLibrary, filepath.Join(repository, "Library")},
+		{PlaceholderPerl, "/usr/bin/perl"},
+		{PlaceholderJava, filepath.Join(prefix, "opt", "openjdk", "libexec")},
+	}
+
+	// A cellar path means the binaries embed the prefix they were built in
+	if filepath.IsAbs(bottleCellar) {
+		if buildPrefix := filepath.Dir(bottleCellar); buildPrefix != prefix {
+			repls = append(repls, replacement{buildPrefix, prefix})
+		}
+	}
+	return repls
+}
+
+// relocateKeg rewrites the install-time paths in an extracted keg, as brew
+// does when pouring a bottle: placeholders in text files (scripts, .pc
+// files, ...) are replaced outright; the RUNPATH and interpreter of ELF
+// files are rewritten with patchelf; other strings inside binaries are
+// patched in place, which fails when the new path is longer than the space
+// the old one occupies.
+func (pm *PackageManager) relocateKeg(kegPath, bottleCellar string) error {
+	if bottleCellar == CellarAnySkipRelocation {
+		return nil
+	}
+	repls := pm.relocations(bottleCellar)
+
+	return filepath.WalkDir(kegPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		changed, err := relocateFile(path, repls)
+		if err != nil {
+			return fmt.Errorf("relocating %s: %w", path, err)
+		}
+		if changed {
+			pm.logger.Printf("  Relocated %s", path)
+		}
+		return nil
+	})
+}
+
+// relocateFile applies repls to one file and reports whether it changed
+func relocateFile(path string, repls []replacement) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	// Text files only get placeholders replaced; when bottling, brew already
+	// turned the build prefix in them into placeholders
+	if !isBinary(data) {
+		var placeholders []replacement
+		for _, r := range repls {
+			if strings.HasPrefix(r.old, "@@") {
+				placeholders = append(placeholders, r)
+			}
+		}
+		text := replaceAll(string(data), placeholders)
+		if text == string(data) {
+			return false, nil
+		}
+		return true, writeFile(path, []byte(text))
+	}
+
+	if !containsAny(data, repls) {
+		return false, nil
+	}
+
+	if bytes.HasPrefix(data, []byte(elf.ELFMAG)) {
+		if err := relocateELF(path, repls); err != nil {
+			return false, err
+		}
+		if data, err = os.ReadFile(path); err != nil {
+			return false, err
+		}
+	}
+
+	if err := relocateBinary(data, repls); err != nil {
+		return false, err
+	}
+	if err := writeFile(path, data); err != nil {
+		return false, err
+	}
+	if runtime.GOOS == "darwin" && isMachO(data) {
+		// Patching invalidates the code signature; arm64 macOS refuses to run
+		// unsigned code, so sign ad hoc like brew does
+		exec.Command("codesign", "--sign", "-", "--force",
+			"--preserve-metadata=entitlements,requirements,flags,runtime", path).Run()
+	}
+	return true, nil
+}
+
+// relocateELF rewrites the RUNPATH and interpreter of an ELF file. Unlike
+// other strings these can grow, since patchelf moves them when needed.
+func relocateELF(path string, repls []replacement) error {
+	f, err := patchelf.Open(path)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	if runPath := f.RunPath(); runPath != nil {
+		relocated := make([]string, len(runPath))
+		for i, entry := range runPath {
+			relocated[i] = replaceAll(entry, repls)
+			changed = changed || relocated[i] != entry
+		}
+		if changed {
+			f.SetRunPath(relocated)
+		}
+	}
+	if interp := f.Interpreter(); interp != "" {
+		if relocated := replaceAll(interp, repls); relocated != interp {
+			f.SetInterpreter(relocated)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return f.Save()
+}
+
+// relocateBinary patches every NUL-terminated string containing one of the
+// old paths in place, padding the end with NULs. Strings keep their offsets,
+// so the replacement must not be longer than the original.
+func relocateBinary(data []byte, repls []replacement) error {
+	for pos := 0; pos < len(data); {
+		start := indexAny(data[pos:], repls)
+		if start < 0 {
+			break
+		}
+		start += pos
+
+		end := bytes.IndexByte(data[start:], 0)
+		if end < 0 {
+			end = len(data)
+		} else {
+			end += start
+		}
+
+		old := string(data[start:end])
+		relocated := replaceAll(old, repls)
+		if len(relocated) > len(old) {
+			return fmt.Errorf("cannot relocate %q to %q in place: %d bytes longer than the original",
+				old, relocated, len(relocated)-len(old))
+		}
+		copy(data[start:], relocated)
+		clear(data[start+len(relocated) : end])
+		pos = end
+	}
+	return nil
+}
+
+// replaceAll applies every replacement to s in a single pass, so a new path
+// containing an old one is not replaced twice
+func replaceAll(s string, repls []replacement) string {
+	pairs := make([]string, 0, 2*len(repls))
+	for _, r := range repls {
+		pairs = append(pairs, r.old, r.new)
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
+}
+
+// indexAny returns the first offset of any old path in data, or -1
+func indexAny(data []byte, repls []replacement) int {
+	first := -1
+	for _, r := range repls {
+		if i := bytes.Index(data, []byte(r.old)); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	return first
+}
+
+func containsAny(data []byte, repls []replacement) bool {
+	return indexAny(data, repls) >= 0
+}
+
+// isBinary reports whether data looks binary: a NUL in the first 8000
+// bytes, the same heuristic git uses
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
+
+// isMachO reports whether data is a Mach-O file or universal binary
+func isMachO(data []byte) bool {
+	if len(data) < 4 {
+		return false
+	}
+	switch string(data[:4]) {
+	case "\xcf\xfa\xed\xfe", "\xce\xfa\xed\xfe", "\xfe\xed\xfa\xcf", "\xfe\xed\xfa\xce", "\xca\xfe\xba\xbe":
+		return true
+	}
+	return false
+}
+
+// writeFile replaces the contents of path, which bottles often ship
+// read-only, keeping its permissions
+func writeFile(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	mode := info.Mode().Perm()
+	if mode&0200 == 0 {
+		if err := os.Chmod(path, mode|0200); err != nil {
+			return err
+		}
+		defer os.Chmod(path, mode)
+	}
+	return os.WriteFile(path, data, mode)
+}
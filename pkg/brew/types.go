@@ -2,18 +2,22 @@
 package brew
 
 import (
+	"io"
 	"log"
+	"sync"
 	"time"
 )
 
 // Config configures the package manager
 type Config struct {
-	APIURL      string        // Default: https://formulae.brew.sh/api
-	RegistryURL string        // Default: https://ghcr.io/v2/homebrew/core
-	InstallPath string        // Default: /usr/local (Intel) or /opt/homebrew (ARM)
+	APIURL      string // Default: https://formulae.brew.sh/api
+	RegistryURL string // Default: https://ghcr.io/v2/homebrew/core
+	InstallPath string // Default: /usr/local (Intel) or /opt/homebrew (ARM)
+	IndexPath   string // Cached bulk formula index (see FetchIndex), fetched on first use; the API is used without one
 	Timeout     time.Duration
 	Debug       bool        // Enable debug logging
 	Logger      *log.Logger // Custom logger (optional)
+	Progress    io.Writer   // User-facing progress such as index downloads and link conflicts (optional)
 }
 
 // PackageManager handles Homebrew package operations
@@ -21,6 +25,12 @@ type PackageManager struct {
 	client *Client
 	config *Config
 	logger *log.Logger
+
+	indexOnce sync.Once
+	index     map[string]*FormulaInfo // Name, full name, alias or old name -> formula
+	formulae  []FormulaInfo           // Every formula of the index
+	indexErr  error
+	fetchErr  error // Set when downloading the index failed; it is not retried
 }
 
 // FormulaInfo contains metadata about a Homebrew formula from the JSON API
@@ -34,6 +44,9 @@ type FormulaInfo struct {
 	Bottle       BottleInfo             `json:"bottle"`
 	URLs         map[string]interface{} `json:"urls"`
 	Dependencies []string               `json:"dependencies"` // Runtime dependencies
+	KegOnly      bool                   `json:"keg_only"`     // Only linked into opt/, not the prefix
+	Aliases      []string               `json:"aliases"`      // Other names the formula answers to
+	OldNames     []string               `json:"oldnames"`     // Names it was renamed from
 }
 
 // FormulaVersions contains version information
@@ -101,4 +114,4 @@ type DownloadOptions struct {
 	Extract     bool     // Whether to extract the tarball (default: true)
 	KeepArchive bool     // Whether to keep the .tar.gz after extraction (default: false)
 	VerifyHash  bool     // Whether to verify SHA256 hash (default: true)
-}
\ No newline at end of file
+}
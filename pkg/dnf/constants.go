@@ -25,6 +25,18 @@ const (
 
 // Fedora repositories
 const (
-	RepoReleases   = "releases" // Stable releases
-	RepoUpdates    = "updates"  // Updates to stable
-)
\ No newline at end of file
+	RepoReleases       = "releases"        // Stable releases
+	RepoUpdates        = "updates"         // Updates to stable
+	RepoUpdatesTesting = "updates-testing" // Candidate updates
+)
+
+// DefaultRepositories are indexed when no repository of any kind is configured
+var DefaultRepositories = []string{RepoReleases, RepoUpdates}
+
+// FedoraGPGKeys are trusted for the built-in Fedora repositories: the key
+// fedora-repos installs locally, then the bundle of current release keys
+// published on fedoraproject.org (fetched once and pinned in the cache)
+var FedoraGPGKeys = []string{
+	"file:///etc/pki/rpm-gpg/RPM-GPG-KEY-fedora-$releasever-$basearch",
+	"https://fedoraproject.org/fedora.gpg",
+}
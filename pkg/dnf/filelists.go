@@ -0,0 +1,201 @@
+// pkg/dnf/filelists.go
+package dnf
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// FindFileOwners returns the packages that ship path. A bare file name
+// (e.g. "libssl.so.3") matches that name in any directory.
+func (pm *PackageManager) FindFileOwners(ctx context.Context, filePath string, arch Architecture) ([]*PackageInfo, error) {
+	if arch == "" {
+		var err error
+		arch, err = DetectArchitecture()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := pm.updatePackageIndex(ctx, arch); err != nil {
+		return nil, err
+	}
+
+	owners, err := pm.lookupFile(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(owners) == 0 {
+		return nil, fmt.Errorf("no package ships %s", filePath)
+	}
+	return owners, nil
+}
+
+// lookupFile resolves a path against the file index. primary.xml only
+// lists a subset of the files (binaries and /etc); the first time a path is
+// missing from it, or a bare file name is looked up, every repository's full
+// filelists are downloaded and added to the index, once per sync.
+func (pm *PackageManager) lookupFile(ctx context.Context, filePath string) ([]*PackageInfo, error) {
+	bare := !strings.Contains(filePath, "/")
+	if !bare {
+		if owners, ok := pm.cache.files[filePath]; ok {
+			return owners, nil
+		}
+	}
+
+	if !pm.cache.filesIndexed {
+		pm.indexFilelists(ctx)
+	}
+
+	if bare {
+		return pm.cache.baseNames[filePath], nil
+	}
+	return pm.cache.files[filePath], nil
+}
+
+// indexFilelists adds the files of every repository's filelists to the file
+// index. Filelists identify packages by pkgid, the checksum primary.xml
+// lists, so only the builds that won the index are recorded.
+func (pm *PackageManager) indexFilelists(ctx context.Context) {
+	pm.cache.filesIndexed = true
+
+	byID := make(map[string]*PackageInfo, len(pm.cache.packages))
+	for _, pkg := range pm.cache.packages {
+		byID[pkg.Checksum] = pkg
+	}
+
+	for _, source := range pm.cache.filelists {
+		if err := pm.fetchFilelists(ctx, source); err != nil {
+			pm.logger.Printf("  ⚠️  Warning: skipping filelists for %s: %v", source.repoID, err)
+			continue
+		}
+
+		pm.logger.Printf("  Indexing %s filelists", source.repoID)
+
+		err := pm.scanFilelists(source.path, func(pkgID, name string, files []string) {
+			pkg, ok := byID[pkgID]
+			if !ok {
+				return
+			}
+			for _, file := range files {
+				pm.addFile(file, pkg)
+			}
+		})
+		if err != nil {
+			pm.logger.Printf("  ⚠️  Warning: reading filelists for %s: %v", source.repoID, err)
+		}
+	}
+
+	pm.logger.Printf("  ✓ Indexed %d files", len(pm.cache.files))
+}
+
+// addFile records pkg as an owner of file, by path and by base name
+func (pm *PackageManager) addFile(file string, pkg *PackageInfo) {
+	pm.cache.files[file] = appendOwner(pm.cache.files[file], pkg)
+	base := path.Base(file)
+	pm.cache.baseNames[base] = appendOwner(pm.cache.baseNames[base], pkg)
+}
+
+// appendOwner adds pkg to owners unless primary.xml already listed it
+func appendOwner(owners []*PackageInfo, pkg *PackageInfo) []*PackageInfo {
+	for _, owner := range owners {
+		if owner == pkg {
+			return owners
+		}
+	}
+	return append(owners, pkg)
+}
+
+// fetchFilelists downloads a repository's filelists into the cache directory
+func (pm *PackageManager) fetchFilelists(ctx context.Context, source *filelistSource) error {
+	if source.path != "" {
+		return nil
+	}
+
+	dest, err := pm.fetchRepodata(ctx, source.repoID, source.url, source.checksum, source.checksumType)
+	if err != nil {
+		return err
+	}
+	source.path = dest
+	return nil
+}
+
+// fetchRepodata downloads a repodata file (primary, filelists) into the
+// cache directory and checks it against the checksum repomd.xml lists for
+// it, so a verified repomd.xml covers the metadata too. A cached copy that
+// still matches is reused; one that no longer does is downloaded again.
+func (pm *PackageManager) fetchRepodata(ctx context.Context, repoID, url, checksum, checksumType string) (string, error) {
+	if checksumType != "sha256" && checksumType != "sha512" {
+		return "", fmt.Errorf("unsupported checksum type %q for %s", checksumType, path.Base(url))
+	}
+
+	dest := filepath.Join(pm.config.CachePath, "repodata", repoID+"-"+path.Base(url))
+	if _, err := os.Stat(dest); err == nil {
+		if pm.verifyFileHash(dest, checksum, checksumType) == nil {
+			return dest, nil
+		}
+		os.Remove(dest)
+	}
+
+	pm.logger.Printf("  Downloading %s %s", repoID, url)
+	if err := pm.downloadPackage(ctx, url, dest); err != nil {
+		return "", err
+	}
+	if err := pm.verifyFileHash(dest, checksum, checksumType); err != nil {
+		os.Remove(dest)
+		return "", fmt.Errorf("verifying %s: %w", path.Base(url), err)
+	}
+	return dest, nil
+}
+
+// scanFilelists streams a cached filelists file through ParseFilelists
+func (pm *PackageManager) scanFilelists(filePath string, fn func(pkgID, name string, files []string)) error {
+	return readRepodata(filePath, func(r io.Reader) error {
+		return ParseFilelists(r, fn)
+	})
+}
+
+// readRepodata opens a cached repodata file, decompressing it based on its
+// extension, and passes it to fn
+func readRepodata(filePath string, fn func(r io.Reader) error) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	switch {
+	case strings.HasSuffix(filePath, ".gz"):
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("creating gzip reader: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	case strings.HasSuffix(filePath, ".xz"):
+		x, err := xz.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("creating xz reader: %w", err)
+		}
+		r = x
+	case strings.HasSuffix(filePath, ".zst"):
+		zs, err := zstd.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("creating zstd reader: %w", err)
+		}
+		defer zs.Close()
+		r = zs
+	}
+
+	return fn(r)
+}
@@ -2,19 +2,21 @@
 package dnf
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
-	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/sassoftware/go-rpmutils"
+	"github.com/arc-language/upkg/pkg/rpm"
 )
 
 // Dependency type classification
@@ -74,8 +76,14 @@ func NewPackageManager(cfg *Config) *PackageManager {
 	if cfg.Release == "" {
 		cfg.Release = DefaultRelease
 	}
-	if cfg.Repository == "" {
-		cfg.Repository = DefaultRepository
+	if cfg.NoFedora {
+		cfg.Repositories = nil
+	} else if len(cfg.Repositories) == 0 {
+		if cfg.Repository != "" {
+			cfg.Repositories = []string{cfg.Repository}
+		} else {
+			cfg.Repositories = DefaultRepositories
+		}
 	}
 	if cfg.InstallPath == "" {
 		cfg.InstallPath = DefaultInstallPath
@@ -105,13 +113,22 @@ func NewPackageManager(cfg *Config) *PackageManager {
 		cache: &PackageCache{
 			packages:      make(map[string]*PackageInfo),
 			providers:     make(map[string][]*PackageInfo),
+			repos:         make(map[string]*Repo),
 			cacheDuration: 30 * time.Minute,
 		},
+		keyrings: make(map[string]*rpm.Keyring),
 	}
 
 	if cfg.Debug {
-		pm.logger.Printf("Initialized Fedora DNF PackageManager")
+		pm.logger.Printf("Initialized DNF PackageManager")
 		pm.logger.Printf("  Release: %s", cfg.Release)
+		pm.logger.Printf("  Repositories: %s", strings.Join(cfg.Repositories, ", "))
+		if len(cfg.RepoFiles) > 0 {
+			pm.logger.Printf("  Repo files: %s", strings.Join(cfg.RepoFiles, ", "))
+		}
+		if cfg.NoGPGCheck {
+			pm.logger.Printf("  ⚠️  Warning: signature checking disabled")
+		}
 	}
 
 	return pm
@@ -149,7 +166,7 @@ func (pm *PackageManager) Download(ctx context.Context, opts *DownloadOptions) e
 // installRecursive resolves dependencies and installs the package
 func (pm *PackageManager) installRecursive(ctx context.Context, pkgRequest string, arch Architecture, visited map[string]bool, opts *DownloadOptions) error {
 	// 1. Resolve Package (might be a package name or a soname/capability)
-	pkgInfo, err := pm.resolvePackage(pkgRequest, arch)
+	pkgInfo, err := pm.resolvePackage(ctx, pkgRequest, arch)
 	if err != nil {
 		return fmt.Errorf("resolving %s: %w", pkgRequest, err)
 	}
@@ -190,20 +207,8 @@ func (pm *PackageManager) installRecursive(ctx context.Context, pkgRequest strin
 	rpmPath := filepath.Join(pm.config.CachePath, "downloads",
 		fmt.Sprintf("%s-%s.%s.rpm", pkgInfo.Name, pkgInfo.FullVersion(), pkgInfo.Architecture))
 
-	// Construct download URL
-	baseURL := pm.config.RepositoryURL
-	var downloadURL string
-
-	if pm.config.Repository == "updates" {
-		downloadURL = fmt.Sprintf("%s/updates/%s/Everything/%s/%s",
-			baseURL, pm.config.Release, arch, pkgInfo.Location)
-	} else if pm.config.Release == "rawhide" {
-		downloadURL = fmt.Sprintf("%s/development/rawhide/Everything/%s/os/%s",
-			baseURL, arch, pkgInfo.Location)
-	} else {
-		downloadURL = fmt.Sprintf("%s/releases/%s/Everything/%s/os/%s",
-			baseURL, pm.config.Release, arch, pkgInfo.Location)
-	}
+	// Construct download URL from the repository the package was indexed from
+	downloadURL := fmt.Sprintf("%s/%s", pkgInfo.BaseURL, pkgInfo.Location)
 
 	// Download if not cached
 	if _, err := os.Stat(rpmPath); os.IsNotExist(err) {
@@ -215,12 +220,17 @@ func (pm *PackageManager) installRecursive(ctx context.Context, pkgRequest strin
 		pm.logger.Printf("  Using cached: %s", rpmPath)
 	}
 
-	// 4. Verify hash
+	// 4. Verify hash, then the RPM's own digests and signature
 	if opts.VerifyHash && pkgInfo.Checksum != "" {
 		if err := pm.verifyFileHash(rpmPath, pkgInfo.Checksum, pkgInfo.ChecksumType); err != nil {
+			os.Remove(rpmPath)
 			return fmt.Errorf("checksum verification failed: %w", err)
 		}
 	}
+	if err := pm.verifyPackage(ctx, pkgInfo, rpmPath, arch, opts.VerifyHash); err != nil {
+		os.Remove(rpmPath)
+		return err
+	}
 
 	// 5. Extract package
 	if opts.Extract {
@@ -239,8 +249,8 @@ func (pm *PackageManager) installRecursive(ctx context.Context, pkgRequest strin
 }
 
 // resolvePackage finds a package by name or by what it provides
-// Handles package names, sonames, and other capabilities
-func (pm *PackageManager) resolvePackage(name string, arch Architecture) (*PackageInfo, error) {
+// Handles package names, sonames, other capabilities and file paths
+func (pm *PackageManager) resolvePackage(ctx context.Context, name string, arch Architecture) (*PackageInfo, error) {
 	clean := cleanDependencyName(name)
 
 	// Try direct package name lookup first (most common case)
@@ -250,145 +260,207 @@ func (pm *PackageManager) resolvePackage(name string, arch Architecture) (*Packa
 
 	// Try providers map (handles sonames, virtual packages, etc.)
 	if providers, ok := pm.cache.providers[clean]; ok && len(providers) > 0 {
-		// Prefer exact architecture match
-		for _, p := range providers {
-			if p.Architecture == string(arch) {
-				return p, nil
-			}
+		return pickForArch(providers, arch), nil
+	}
+
+	// File requirements (/usr/bin/sh) resolve to the package that ships the path
+	if classifyDependency(clean) == depTypeFile {
+		owners, err := pm.lookupFile(ctx, clean)
+		if err != nil {
+			return nil, err
 		}
-		// Then noarch
-		for _, p := range providers {
-			if p.Architecture == "noarch" {
-				return p, nil
-			}
+		if len(owners) > 0 {
+			return pickForArch(owners, arch), nil
 		}
-		// Fallback to first available
-		return providers[0], nil
 	}
 
 	return nil, fmt.Errorf("package or capability '%s' not found", clean)
 }
 
-// updatePackageIndex updates the local package index cache
+// pickForArch prefers an exact architecture match, then noarch, then the first candidate
+func pickForArch(candidates []*PackageInfo, arch Architecture) *PackageInfo {
+	for _, p := range candidates {
+		if p.Architecture == string(arch) {
+			return p
+		}
+	}
+	for _, p := range candidates {
+		if p.Architecture == "noarch" {
+			return p
+		}
+	}
+	return candidates[0]
+}
+
+// updatePackageIndex updates the local package index cache.
+// Every enabled repository is indexed; when several carry the same package
+// the highest epoch:version-release wins, so updates supersede releases.
 func (pm *PackageManager) updatePackageIndex(ctx context.Context, arch Architecture) error {
 	if time.Since(pm.cache.lastUpdate) < pm.cache.cacheDuration && len(pm.cache.packages) > 0 {
 		return nil
 	}
 
-	pm.logger.Printf("Fetching package index from repository...")
+	repos, err := pm.repositories()
+	if err != nil {
+		return fmt.Errorf("loading repositories: %w", err)
+	}
+	if len(repos) == 0 {
+		return fmt.Errorf("no enabled repositories")
+	}
+
+	pm.logger.Printf("Fetching package index from %d repositories...", len(repos))
 	pm.cache.packages = make(map[string]*PackageInfo)
 	pm.cache.providers = make(map[string][]*PackageInfo)
+	pm.cache.files = make(map[string][]*PackageInfo)
+	pm.cache.baseNames = make(map[string][]*PackageInfo)
+	pm.cache.filelists = nil
+	pm.cache.filesIndexed = false
+	pm.cache.repos = make(map[string]*Repo)
 
-	// Construct URL
-	baseURL := pm.config.RepositoryURL
-	var repomdURL string
+	var lastErr error
+	indexed := 0
 
-	if pm.config.Repository == "updates" {
-		repomdURL = fmt.Sprintf("%s/updates/%s/Everything/%s/repodata/repomd.xml",
-			baseURL, pm.config.Release, arch)
-	} else if pm.config.Release == "rawhide" {
-		repomdURL = fmt.Sprintf("%s/development/rawhide/Everything/%s/os/repodata/repomd.xml",
-			baseURL, arch)
-	} else {
-		repomdURL = fmt.Sprintf("%s/releases/%s/Everything/%s/os/repodata/repomd.xml",
-			baseURL, pm.config.Release, arch)
+	for _, repo := range repos {
+		packages, err := pm.fetchPrimary(ctx, repo, arch)
+		if err != nil {
+			pm.logger.Printf("  ⚠️  Warning: skipping repository %s: %v", repo.ID, err)
+			lastErr = err
+			continue
+		}
+		indexed++
+		pm.cache.repos[repo.ID] = repo
+
+		for _, pkg := range packages {
+			// Only index packages for the target architecture or noarch
+			if pkg.Architecture != string(arch) && pkg.Architecture != "noarch" {
+				continue
+			}
+
+			if existing, ok := pm.cache.packages[pkg.Name]; ok && pkg.evr().Compare(existing.evr()) <= 0 {
+				continue
+			}
+			pm.cache.packages[pkg.Name] = pkg
+		}
 	}
 
-	pm.logger.Printf("  Fetching repomd.xml: %s", repomdURL)
+	if indexed == 0 {
+		return fmt.Errorf("failed to index any repository: %w", lastErr)
+	}
 
-	resp, err := pm.client.Get(ctx, repomdURL)
+	// Build the provider index from the winning packages only, so a
+	// capability never resolves to a superseded build
+	for _, pkg := range pm.cache.packages {
+		// Package name always provides itself
+		pm.cache.providers[pkg.Name] = append(pm.cache.providers[pkg.Name], pkg)
+
+		// Index ALL provides (including sonames, virtual capabilities, etc.)
+		// This is how DNF resolves soname dependencies like libssl.so.3()(64bit) -> openssl-libs
+		for _, provide := range pkg.Provides {
+			pm.cache.providers[provide] = append(pm.cache.providers[provide], pkg)
+		}
+
+		// primary.xml lists the files most requirements point at (/usr/bin, /etc)
+		for _, file := range pkg.Files {
+			pm.addFile(file, pkg)
+		}
+	}
+
+	pm.logger.Printf("  ✓ Indexed %d packages, %d unique provides", len(pm.cache.packages), len(pm.cache.providers))
+	pm.cache.lastUpdate = time.Now()
+
+	return nil
+}
+
+// fetchPrimary downloads and parses the primary metadata of one repository
+func (pm *PackageManager) fetchPrimary(ctx context.Context, repo *Repo, arch Architecture) ([]*PackageInfo, error) {
+	baseURL, metalink, err := pm.resolveBaseURL(ctx, repo, arch)
 	if err != nil {
-		return fmt.Errorf("fetching repomd.xml: %w", err)
+		return nil, err
 	}
 
-	repoMD, err := ParseRepoMD(resp.Body)
+	repomdURL := fmt.Sprintf("%s/repodata/repomd.xml", baseURL)
+	pm.logger.Printf("  Fetching %s repomd.xml: %s", repo.ID, repomdURL)
+
+	resp, err := pm.client.Get(ctx, repomdURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching repomd.xml: %w", err)
+	}
+	data, err := io.ReadAll(resp.Body)
 	resp.Body.Close()
 	if err != nil {
-		return fmt.Errorf("parsing repomd.xml: %w", err)
+		return nil, fmt.Errorf("fetching repomd.xml: %w", err)
+	}
+
+	// A mirror picked from the metalink must serve the repomd.xml it vouches for
+	if metalink != nil {
+		if err := metalink.Verify(data); err != nil {
+			return nil, fmt.Errorf("verifying %s: %w", repomdURL, err)
+		}
 	}
 
-	var primaryLocation string
-	for _, data := range repoMD.Data {
-		if data.Type == "primary" {
-			primaryLocation = data.Location
-			break
+	repoMD, err := ParseRepoMD(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing repomd.xml: %w", err)
+	}
+
+	var primary, filelists *RepoData
+	for i, data := range repoMD.Data {
+		switch data.Type {
+		case "primary":
+			primary = &repoMD.Data[i]
+		case "filelists":
+			filelists = &repoMD.Data[i]
+		case "filelists-ext", "filelists_ext":
+			if filelists == nil {
+				filelists = &repoMD.Data[i]
+			}
 		}
 	}
 
-	if primaryLocation == "" {
-		return fmt.Errorf("primary.xml location not found in repomd.xml")
+	// Remember where the full file lists live; they are only fetched when
+	// a path is not covered by primary.xml
+	if filelists != nil {
+		pm.cache.filelists = append(pm.cache.filelists, &filelistSource{
+			repoID:       repo.ID,
+			url:          fmt.Sprintf("%s/%s", baseURL, filelists.Location),
+			checksum:     filelists.Checksum,
+			checksumType: filelists.ChecksumType,
+		})
 	}
 
-	var primaryURL string
-	if pm.config.Repository == "updates" {
-		primaryURL = fmt.Sprintf("%s/updates/%s/Everything/%s/%s",
-			baseURL, pm.config.Release, arch, primaryLocation)
-	} else if pm.config.Release == "rawhide" {
-		primaryURL = fmt.Sprintf("%s/development/rawhide/Everything/%s/os/%s",
-			baseURL, arch, primaryLocation)
-	} else {
-		primaryURL = fmt.Sprintf("%s/releases/%s/Everything/%s/os/%s",
-			baseURL, pm.config.Release, arch, primaryLocation)
+	if primary == nil || primary.Location == "" {
+		return nil, fmt.Errorf("primary.xml location not found in repomd.xml")
 	}
 
-	pm.logger.Printf("  Downloading primary metadata...")
+	primaryURL := fmt.Sprintf("%s/%s", baseURL, primary.Location)
 
-	var primaryReader io.ReadCloser
-	if strings.HasSuffix(primaryLocation, ".gz") {
-		primaryReader, err = pm.client.GetGzipped(ctx, primaryURL)
-	} else if strings.HasSuffix(primaryLocation, ".xz") {
-		primaryReader, err = pm.client.GetXZ(ctx, primaryURL)
-	} else if strings.HasSuffix(primaryLocation, ".zst") {
-		primaryReader, err = pm.client.GetZstd(ctx, primaryURL)
-	} else {
-		resp, err = pm.client.Get(ctx, primaryURL)
-		if err == nil {
-			primaryReader = resp.Body
-		}
-	}
+	pm.logger.Printf("  Downloading %s primary metadata...", repo.ID)
 
+	primaryPath, err := pm.fetchRepodata(ctx, repo.ID, primaryURL, primary.Checksum, primary.ChecksumType)
 	if err != nil {
-		return fmt.Errorf("fetching primary.xml: %w", err)
+		return nil, fmt.Errorf("fetching primary.xml: %w", err)
 	}
-	defer primaryReader.Close()
 
-	packages, err := ParsePrimary(primaryReader)
+	var packages []*PackageInfo
+	err = readRepodata(primaryPath, func(r io.Reader) error {
+		packages, err = ParsePrimary(r)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("parsing primary.xml: %w", err)
+		return nil, fmt.Errorf("parsing primary.xml: %w", err)
 	}
 
-	// Build package and provider indexes
-	packageCount := 0
-	
 	for _, pkg := range packages {
-		// Only index packages for the target architecture or noarch
-		if pkg.Architecture != string(arch) && pkg.Architecture != "noarch" {
-			continue
-		}
-		packageCount++
-
-		// Index by package name
-		pm.cache.packages[pkg.Name] = pkg
-		
-		// Package name always provides itself
-		pm.cache.providers[pkg.Name] = append(pm.cache.providers[pkg.Name], pkg)
-		
-		// Index ALL provides (including sonames, virtual capabilities, etc.)
-		// This is how DNF resolves soname dependencies like libssl.so.3()(64bit) -> openssl-libs
-		for _, provide := range pkg.Provides {
-			pm.cache.providers[provide] = append(pm.cache.providers[provide], pkg)
-		}
+		pkg.RepoID = repo.ID
+		pkg.BaseURL = baseURL
 	}
 
-	pm.logger.Printf("  ✓ Indexed %d packages, %d unique provides", packageCount, len(pm.cache.providers))
-	pm.cache.lastUpdate = time.Now()
-
-	return nil
+	return packages, nil
 }
 
 // findPackage is exposed for the generic Manager interface
-func (pm *PackageManager) findPackage(name, version string, arch Architecture) (*PackageInfo, error) {
-	return pm.resolvePackage(name, arch)
+func (pm *PackageManager) findPackage(ctx context.Context, name, version string, arch Architecture) (*PackageInfo, error) {
+	return pm.resolvePackage(ctx, name, arch)
 }
 
 // downloadPackage downloads an .rpm package
@@ -420,11 +492,16 @@ func (pm *PackageManager) verifyFileHash(filePath, expectedHash, hashType string
 	}
 	defer f.Close()
 
-	if hashType != "sha256" {
+	var hasher hash.Hash
+	switch hashType {
+	case "sha256":
+		hasher = sha256.New()
+	case "sha512":
+		hasher = sha512.New()
+	default:
 		return nil
 	}
 
-	hasher := sha256.New()
 	if _, err := io.Copy(hasher, f); err != nil {
 		return fmt.Errorf("computing hash: %w", err)
 	}
@@ -438,67 +515,18 @@ func (pm *PackageManager) verifyFileHash(filePath, expectedHash, hashType string
 	return nil
 }
 
-// extractRPMPackage with Retry on Permission Denied
+// extractRPMPackage extracts an .rpm into the install path and logs what it produced
 func (pm *PackageManager) extractRPMPackage(rpmPath, installPath string) error {
-	if err := os.MkdirAll(installPath, 0755); err != nil {
-		return fmt.Errorf("creating install directory: %w", err)
-	}
-
-	// Function to perform extraction
-	extract := func() error {
-		f, err := os.Open(rpmPath)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-
-		rpm, err := rpmutils.ReadRpm(f)
-		if err != nil {
-			return err
-		}
-
-		if err := rpm.ExpandPayload(installPath); err != nil {
-			return err
-		}
-		return nil
+	files, err := rpm.Extract(rpmPath, installPath)
+	if err != nil {
+		return fmt.Errorf("expanding rpm payload: %w", err)
 	}
 
-	// Try extraction
-	err := extract()
-	
-	// If permission error, fix permissions and retry
-	if err != nil && (strings.Contains(err.Error(), "permission denied") || strings.Contains(err.Error(), "access denied")) {
-		pm.logger.Printf("  ⚠️  Permission denied during extraction. Fixing permissions and retrying...")
-		
-		// Force write permissions on everything in install path
-		errFix := filepath.WalkDir(installPath, func(path string, d fs.DirEntry, err error) error {
-			if err != nil { return nil } // Ignore walk errors
-			
-			info, err := d.Info()
-			if err != nil { return nil }
-			
-			mode := info.Mode()
-			newMode := mode | 0200 // Add user write permission
-			if mode.IsDir() {
-				newMode |= 0700 // Add user read/write/execute for dirs
-			}
-			
-			if mode != newMode {
-				_ = os.Chmod(path, newMode)
-			}
-			return nil
-		})
-		
-		if errFix != nil {
-			pm.logger.Printf("  ⚠️  Failed to fix permissions: %v", errFix)
+	pm.logger.Printf("  Extracted %d files", len(files))
+	if pm.config.Debug {
+		for _, file := range files {
+			pm.logger.Printf("    %s", file)
 		}
-
-		// Retry extraction
-		err = extract()
-	}
-
-	if err != nil {
-		return fmt.Errorf("expanding rpm payload: %w", err)
 	}
 
 	return nil
@@ -518,7 +546,7 @@ func (pm *PackageManager) GetPackageInfo(ctx context.Context, name string, arch
 		return nil, err
 	}
 
-	return pm.findPackage(name, "", arch)
+	return pm.findPackage(ctx, name, "", arch)
 }
 
 // SearchPackages searches for packages by name
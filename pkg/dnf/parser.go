@@ -5,7 +5,10 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
+
+	"github.com/arc-language/upkg/pkg/rpm"
 )
 
 // ParseRepoMD parses a repomd.xml file
@@ -46,7 +49,8 @@ func ParseRepoMD(r io.Reader) (*RepoMD, error) {
 		repoMD.Data = append(repoMD.Data, RepoData{
 			Type:         d.Type,
 			Location:     d.Location.Href,
-			Checksum:     d.Checksum.Value,
+			Checksum:     strings.TrimSpace(d.Checksum.Value),
+			ChecksumType: d.Checksum.Type,
 			OpenChecksum: d.OpenChecksum.Value,
 			Timestamp:    d.Timestamp,
 			Size:         d.Size,
@@ -57,6 +61,74 @@ func ParseRepoMD(r io.Reader) (*RepoMD, error) {
 	return repoMD, nil
 }
 
+// ParseMetalink parses a metalink document and returns the http(s) URLs of
+// repomd.xml it lists, most preferred first, along with the digests of the
+// current repomd.xml and of the alternates mirrors may still serve
+func ParseMetalink(r io.Reader) (*Metalink, error) {
+	type xmlHash struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	}
+	type XMLMetalink struct {
+		XMLName xml.Name `xml:"metalink"`
+		Files   []struct {
+			Name       string    `xml:"name,attr"`
+			Hashes     []xmlHash `xml:"verification>hash"`
+			Alternates []struct {
+				Hashes []xmlHash `xml:"verification>hash"`
+			} `xml:"alternates>alternate"`
+			URLs []struct {
+				Protocol   string `xml:"protocol,attr"`
+				Preference int    `xml:"preference,attr"`
+				Value      string `xml:",chardata"`
+			} `xml:"resources>url"`
+		} `xml:"files>file"`
+	}
+
+	var metalink XMLMetalink
+	decoder := xml.NewDecoder(r)
+	if err := decoder.Decode(&metalink); err != nil {
+		return nil, fmt.Errorf("decoding metalink: %w", err)
+	}
+
+	type mirror struct {
+		url        string
+		preference int
+	}
+	var mirrors []mirror
+	result := &Metalink{Hashes: make(map[string][]string)}
+	addHashes := func(hashes []xmlHash) {
+		for _, h := range hashes {
+			kind := strings.ToLower(h.Type)
+			result.Hashes[kind] = append(result.Hashes[kind], strings.ToLower(strings.TrimSpace(h.Value)))
+		}
+	}
+	for _, file := range metalink.Files {
+		if file.Name != "repomd.xml" {
+			continue
+		}
+		addHashes(file.Hashes)
+		for _, alt := range file.Alternates {
+			addHashes(alt.Hashes)
+		}
+		for _, u := range file.URLs {
+			if u.Protocol != "http" && u.Protocol != "https" {
+				continue
+			}
+			mirrors = append(mirrors, mirror{url: strings.TrimSpace(u.Value), preference: u.Preference})
+		}
+	}
+
+	sort.SliceStable(mirrors, func(i, j int) bool {
+		return mirrors[i].preference > mirrors[j].preference
+	})
+
+	for _, m := range mirrors {
+		result.URLs = append(result.URLs, m.url)
+	}
+	return result, nil
+}
+
 // ParsePrimary parses a primary.xml file (package metadata)
 func ParsePrimary(r io.Reader) ([]*PackageInfo, error) {
 	type XMLPackage struct {
@@ -105,6 +177,10 @@ func ParsePrimary(r io.Reader) ([]*PackageInfo, error) {
 					Name string `xml:"name,attr"`
 				} `xml:"http://linux.duke.edu/metadata/rpm entry"`
 			} `xml:"http://linux.duke.edu/metadata/rpm obsoletes"`
+			Files []struct {
+				Type string `xml:"type,attr"`
+				Path string `xml:",chardata"`
+			} `xml:"file"`
 		} `xml:"format"`
 	}
 
@@ -169,12 +245,62 @@ func ParsePrimary(r io.Reader) ([]*PackageInfo, error) {
 			}
 		}
 
+		// Parse the primary file subset (directories can't satisfy requirements)
+		for _, file := range p.Format.Files {
+			if file.Type != "dir" && file.Path != "" {
+				pkg.Files = append(pkg.Files, file.Path)
+			}
+		}
+
 		packages = append(packages, pkg)
 	}
 
 	return packages, nil
 }
 
+// ParseFilelists streams a filelists.xml (or filelists-ext.xml) document,
+// calling fn with each package's pkgid, name and non-directory files
+func ParseFilelists(r io.Reader, fn func(pkgID, name string, files []string)) error {
+	type XMLFilelistPackage struct {
+		PkgID string `xml:"pkgid,attr"`
+		Name  string `xml:"name,attr"`
+		Arch  string `xml:"arch,attr"`
+		Files []struct {
+			Type string `xml:"type,attr"`
+			Path string `xml:",chardata"`
+		} `xml:"file"`
+	}
+
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("decoding filelists: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "package" {
+			continue
+		}
+
+		var p XMLFilelistPackage
+		if err := decoder.DecodeElement(&p, &start); err != nil {
+			return fmt.Errorf("decoding filelists package: %w", err)
+		}
+
+		files := make([]string, 0, len(p.Files))
+		for _, file := range p.Files {
+			if file.Type != "dir" {
+				files = append(files, file.Path)
+			}
+		}
+		fn(p.PkgID, p.Name, files)
+	}
+}
+
 // FullVersion returns the full version string (epoch:version-release)
 func (p *PackageInfo) FullVersion() string {
 	if p.Epoch != "" && p.Epoch != "0" {
@@ -186,4 +312,9 @@ func (p *PackageInfo) FullVersion() string {
 // NVRA returns the Name-Version-Release.Architecture format
 func (p *PackageInfo) NVRA() string {
 	return fmt.Sprintf("%s-%s.%s", p.Name, p.FullVersion(), p.Architecture)
-}
\ No newline at end of file
+}
+
+// evr returns the package's epoch:version-release for rpm ordering
+func (p *PackageInfo) evr() rpm.EVR {
+	return rpm.EVR{Epoch: p.Epoch, Version: p.Version, Release: p.Release}
+}
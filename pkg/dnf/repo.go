@@ -0,0 +1,292 @@
+// pkg/dnf/repo.go
+package dnf
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ParseRepoFile parses a yum/dnf .repo file (INI sections, one repository each)
+func ParseRepoFile(r io.Reader) ([]*Repo, error) {
+	var repos []*Repo
+	var current *Repo
+	var lastKey string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			// gpgcheck defaults on, as in the dnf.conf every distribution ships
+			current = &Repo{
+				ID:       strings.TrimSpace(line[1 : len(line)-1]),
+				Enabled:  true,
+				GPGCheck: true,
+			}
+			repos = append(repos, current)
+			lastKey = ""
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		// Indented lines continue the previous key (baseurl and gpgkey lists)
+		if raw[0] == ' ' || raw[0] == '\t' {
+			if lastKey == "gpgkey" {
+				current.GPGKey = append(current.GPGKey, strings.Fields(line)...)
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		lastKey = key
+
+		switch key {
+		case "name":
+			current.Name = value
+		case "baseurl":
+			if urls := strings.FieldsFunc(value, isListSeparator); len(urls) > 0 {
+				current.BaseURL = urls[0]
+			}
+		case "metalink":
+			current.Metalink = value
+		case "mirrorlist":
+			current.MirrorList = value
+		case "enabled":
+			current.Enabled = parseRepoBool(value)
+		case "gpgcheck":
+			current.GPGCheck = parseRepoBool(value)
+		case "gpgkey":
+			current.GPGKey = append(current.GPGKey, strings.FieldsFunc(value, isListSeparator)...)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return repos, nil
+}
+
+// LoadRepoFiles reads .repo files; directories contribute every *.repo file they contain
+func LoadRepoFiles(paths []string) ([]*Repo, error) {
+	var repos []*Repo
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading repo file: %w", err)
+		}
+
+		files := []string{path}
+		if info.IsDir() {
+			files, err = filepath.Glob(filepath.Join(path, "*.repo"))
+			if err != nil {
+				return nil, err
+			}
+			sort.Strings(files)
+		}
+
+		for _, file := range files {
+			f, err := os.Open(file)
+			if err != nil {
+				return nil, fmt.Errorf("opening %s: %w", file, err)
+			}
+			parsed, err := ParseRepoFile(f)
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("parsing %s: %w", file, err)
+			}
+			repos = append(repos, parsed...)
+		}
+	}
+
+	return repos, nil
+}
+
+// ExpandRepoVars substitutes $releasever and $basearch (and their ${...} forms)
+func ExpandRepoVars(s, release string, arch Architecture) string {
+	return strings.NewReplacer(
+		"${releasever}", release,
+		"$releasever", release,
+		"${basearch}", string(arch),
+		"$basearch", string(arch),
+		"${arch}", string(arch),
+		"$arch", string(arch),
+	).Replace(s)
+}
+
+// fedoraRepo returns the built-in Fedora repository with the given name.
+// Rawhide has a single repository, so only "releases" maps to it there.
+func fedoraRepo(name, repositoryURL, release string) (*Repo, error) {
+	base := strings.TrimSuffix(repositoryURL, "/")
+
+	repo := &Repo{Enabled: true, GPGCheck: true, GPGKey: FedoraGPGKeys}
+
+	if release == ReleaseRawhide {
+		if name != RepoReleases {
+			return nil, nil
+		}
+		repo.ID = "rawhide"
+		repo.BaseURL = base + "/development/rawhide/Everything/$basearch/os"
+		return repo, nil
+	}
+
+	switch name {
+	case RepoReleases:
+		repo.ID = "fedora"
+		repo.BaseURL = base + "/releases/$releasever/Everything/$basearch/os"
+	case RepoUpdates:
+		repo.ID = "updates"
+		repo.BaseURL = base + "/updates/$releasever/Everything/$basearch"
+	case RepoUpdatesTesting:
+		repo.ID = "updates-testing"
+		repo.BaseURL = base + "/updates/testing/$releasever/Everything/$basearch"
+	default:
+		return nil, fmt.Errorf("unknown Fedora repository %q", name)
+	}
+
+	return repo, nil
+}
+
+// repositories returns every enabled repository to index: the built-in
+// Fedora ones first, then those from .repo files and Config.Repos
+func (pm *PackageManager) repositories() ([]*Repo, error) {
+	var repos []*Repo
+
+	for _, name := range pm.config.Repositories {
+		repo, err := fedoraRepo(name, pm.config.RepositoryURL, pm.config.Release)
+		if err != nil {
+			return nil, err
+		}
+		if repo != nil {
+			repos = append(repos, repo)
+		}
+	}
+
+	if len(pm.config.RepoFiles) > 0 {
+		fromFiles, err := LoadRepoFiles(pm.config.RepoFiles)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, fromFiles...)
+	}
+
+	repos = append(repos, pm.config.Repos...)
+
+	enabled := repos[:0]
+	for _, repo := range repos {
+		if repo.Enabled {
+			enabled = append(enabled, repo)
+		}
+	}
+
+	return enabled, nil
+}
+
+// resolveBaseURL returns the repository's base URL for arch, asking the
+// metalink or mirrorlist for a mirror when no baseurl is given. A metalink
+// is returned too, so the mirror's repomd.xml can be checked against it.
+func (pm *PackageManager) resolveBaseURL(ctx context.Context, repo *Repo, arch Architecture) (string, *Metalink, error) {
+	if repo.BaseURL != "" {
+		return strings.TrimSuffix(ExpandRepoVars(repo.BaseURL, pm.config.Release, arch), "/"), nil, nil
+	}
+
+	if repo.Metalink != "" {
+		url := ExpandRepoVars(repo.Metalink, pm.config.Release, arch)
+		resp, err := pm.client.Get(ctx, url)
+		if err != nil {
+			return "", nil, fmt.Errorf("fetching metalink: %w", err)
+		}
+		defer resp.Body.Close()
+
+		metalink, err := ParseMetalink(resp.Body)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(metalink.URLs) == 0 {
+			return "", nil, fmt.Errorf("metalink %s lists no http mirrors", url)
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(metalink.URLs[0], "repodata/repomd.xml"), "/"), metalink, nil
+	}
+
+	if repo.MirrorList != "" {
+		url := ExpandRepoVars(repo.MirrorList, pm.config.Release, arch)
+		resp, err := pm.client.Get(ctx, url)
+		if err != nil {
+			return "", nil, fmt.Errorf("fetching mirrorlist: %w", err)
+		}
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") {
+				return strings.TrimSuffix(line, "/"), nil, nil
+			}
+		}
+		return "", nil, fmt.Errorf("mirrorlist %s lists no http mirrors", url)
+	}
+
+	return "", nil, fmt.Errorf("repository %s has no baseurl, metalink or mirrorlist", repo.ID)
+}
+
+// Verify checks repomd.xml against the strongest digest type the metalink
+// lists. Any current or alternate version matches; a metalink without a
+// sha256 or sha512 digest fails.
+func (m *Metalink) Verify(repomd []byte) error {
+	var actual string
+	var expected []string
+	switch {
+	case len(m.Hashes["sha512"]) > 0:
+		sum := sha512.Sum512(repomd)
+		actual, expected = hex.EncodeToString(sum[:]), m.Hashes["sha512"]
+	case len(m.Hashes["sha256"]) > 0:
+		sum := sha256.Sum256(repomd)
+		actual, expected = hex.EncodeToString(sum[:]), m.Hashes["sha256"]
+	default:
+		return fmt.Errorf("metalink lists no sha256 or sha512 digest of repomd.xml")
+	}
+
+	for _, digest := range expected {
+		if digest == actual {
+			return nil
+		}
+	}
+	return fmt.Errorf("repomd.xml digest %s does not match the metalink", actual)
+}
+
+// parseRepoBool parses the boolean spellings dnf accepts
+func parseRepoBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "1", "yes", "true", "on":
+		return true
+	default:
+		return false
+	}
+}
+
+// isListSeparator splits .repo list values on commas and whitespace
+func isListSeparator(r rune) bool {
+	return r == ',' || r == ' ' || r == '\t'
+}
@@ -0,0 +1,82 @@
+// pkg/dnf/signature.go
+package dnf
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/arc-language/upkg/pkg/rpm"
+)
+
+// verifyPackage checks a downloaded RPM against its own header. The header
+// and payload digests are verified whenever digests is set; the PGP signature
+// is verified when the package's repository has gpgcheck enabled. Together with
+// the repodata checksum this catches a mirror serving a tampered repodata/RPM pair.
+func (pm *PackageManager) verifyPackage(ctx context.Context, pkgInfo *PackageInfo, rpmPath string, arch Architecture, digests bool) error {
+	repo := pm.cache.repos[pkgInfo.RepoID]
+	gpgcheck := repo != nil && repo.GPGCheck && !pm.config.NoGPGCheck
+
+	if !gpgcheck {
+		if !digests {
+			return nil
+		}
+		if _, err := rpm.Verify(rpmPath, nil); err != nil {
+			return fmt.Errorf("verifying %s digests: %w", filepath.Base(rpmPath), err)
+		}
+		return nil
+	}
+
+	keyring, err := pm.keyring(ctx, repo, arch)
+	if err != nil {
+		return err
+	}
+
+	result, err := rpm.Verify(rpmPath, keyring)
+	if err != nil {
+		return fmt.Errorf("verifying %s signature: %w", filepath.Base(rpmPath), err)
+	}
+
+	if pm.config.Debug {
+		pm.logger.Printf("  Signature OK: %s", strings.Join(result.Signers, ", "))
+	}
+
+	return nil
+}
+
+// keyring returns the keys trusted for a repository, loading them on first use
+func (pm *PackageManager) keyring(ctx context.Context, repo *Repo, arch Architecture) (*rpm.Keyring, error) {
+	if keyring, ok := pm.keyrings[repo.ID]; ok {
+		return keyring, nil
+	}
+
+	var sources []string
+	for _, keys := range [][]string{repo.GPGKey, pm.config.GPGKeys} {
+		for _, key := range keys {
+			sources = append(sources, ExpandRepoVars(key, pm.config.Release, arch))
+		}
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("repository %s has gpgcheck enabled but no gpgkey", repo.ID)
+	}
+
+	fetch := func(ctx context.Context, url string) (io.ReadCloser, error) {
+		resp, err := pm.client.Get(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+
+	keyring, err := rpm.LoadKeyring(ctx, sources, filepath.Join(pm.config.CachePath, "keys"), fetch)
+	if err != nil {
+		return nil, fmt.Errorf("loading keys for repository %s: %w", repo.ID, err)
+	}
+
+	pm.logger.Printf("  Loaded %d keys for %s from %s", keyring.Len(), repo.ID, strings.Join(keyring.Sources(), ", "))
+	pm.keyrings[repo.ID] = keyring
+
+	return keyring, nil
+}
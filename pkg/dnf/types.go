@@ -4,13 +4,21 @@ package dnf
 import (
 	"log"
 	"time"
+
+	"github.com/arc-language/upkg/pkg/rpm"
 )
 
 // Config configures the Fedora/DNF package manager
 type Config struct {
 	RepositoryURL string        // Default: https://dl.fedoraproject.org/pub/fedora/linux
 	Release       string        // Fedora release (42, 41, etc.)
-	Repository    string        // Repository name (releases, updates, etc.)
+	Repository    string        // Single repository name (releases, updates); used when Repositories is empty
+	Repositories  []string      // Fedora repositories to index, merged by highest EVR (default: releases, updates)
+	RepoFiles     []string      // yum .repo files or directories of them (e.g. /etc/yum.repos.d)
+	Repos         []*Repo       // Additional repositories (EPEL, internal mirrors, ...)
+	NoFedora      bool          // Index only RepoFiles and Repos, for distributions with their own default repositories (EL)
+	GPGKeys       []string      // Extra trusted keys (paths or URLs), added to every repository's gpgkey list
+	NoGPGCheck    bool          // Skip RPM signature checks everywhere; header and payload digests are still verified
 	InstallPath   string        // Where to install packages
 	CachePath     string        // Where to cache downloaded files
 	Timeout       time.Duration
@@ -24,6 +32,8 @@ type PackageManager struct {
 	config *Config
 	logger *log.Logger
 	cache  *PackageCache
+
+	keyrings map[string]*rpm.Keyring // Per-repository keyrings, loaded on first use
 }
 
 // PackageInfo contains metadata about a Fedora package from repodata
@@ -42,12 +52,15 @@ type PackageInfo struct {
 	Size         int64    // Package size
 	InstalledSize int64   // Installed size
 	Location     string   // File location in repository
+	RepoID       string   // ID of the repository the package was indexed from
+	BaseURL      string   // Base URL of that repository; Location is relative to it
 	Checksum     string   // SHA256 checksum
 	ChecksumType string   // Checksum type (sha256, sha512, etc.)
 	Requires     []string // Dependencies
 	Provides     []string // Provides
 	Conflicts    []string // Conflicts
 	Obsoletes    []string // Obsoletes
+	Files        []string // Files listed in primary.xml (binaries and /etc); the rest live in filelists
 }
 
 // DownloadOptions configures package download and extraction
@@ -64,10 +77,43 @@ type DownloadOptions struct {
 type PackageCache struct {
 	packages      map[string]*PackageInfo   // key: package_name
 	providers     map[string][]*PackageInfo // key: virtual_provide -> list of packages
+	files         map[string][]*PackageInfo // key: file path -> owning packages
+	baseNames     map[string][]*PackageInfo // key: file base name -> owning packages
+	filelists     []*filelistSource         // filelists of each indexed repository, fetched on first use
+	filesIndexed  bool                      // filelists have been added to files and baseNames
+	repos         map[string]*Repo          // key: repository ID
 	lastUpdate    time.Time
 	cacheDuration time.Duration
 }
 
+// filelistSource locates a repository's filelists metadata and its local copy
+type filelistSource struct {
+	repoID       string // Repository the filelists belong to
+	url          string // Remote filelists (or filelists-ext) location
+	checksum     string // Checksum of the compressed file, from repomd.xml
+	checksumType string // sha256 or sha512
+	path         string // Local cached copy, set once downloaded and verified
+}
+
+// Repo describes an RPM repository, as found in a yum .repo file section.
+// URLs may contain $releasever and $basearch, substituted when indexing.
+type Repo struct {
+	ID         string   // Section name ([epel])
+	Name       string   // Human readable name
+	BaseURL    string   // baseurl (first entry when several are listed)
+	Metalink   string   // metalink URL, used when BaseURL is empty
+	MirrorList string   // mirrorlist URL, used when BaseURL and Metalink are empty
+	Enabled    bool     // enabled (default: true)
+	GPGCheck   bool     // gpgcheck: verify package signatures against GPGKey (default: true)
+	GPGKey     []string // gpgkey paths or URLs
+}
+
+// Metalink is what a repository's metalink says about its repomd.xml
+type Metalink struct {
+	URLs   []string            // http(s) mirrors of repomd.xml, most preferred first
+	Hashes map[string][]string // key: hash type (sha256, sha512) -> digests of acceptable repomd.xml versions
+}
+
 // RepoMD represents the repomd.xml file structure
 type RepoMD struct {
 	Revision string
@@ -79,6 +125,7 @@ type RepoData struct {
 	Type         string
 	Location     string
 	Checksum     string
+	ChecksumType string
 	OpenChecksum string
 	Timestamp    int64
 	Size         int64
@@ -33,4 +33,7 @@ const (
 	ComponentContrib      = "contrib"
 	ComponentNonFree      = "non-free"
 	ComponentNonFreeFirm = "non-free-firmware"
-)
\ No newline at end of file
+)
+
+// DefaultComponents are the components indexed when Config.Components is empty
+var DefaultComponents = []string{ComponentMain, ComponentContrib, ComponentNonFree, ComponentNonFreeFirm}
\ No newline at end of file
@@ -39,6 +39,9 @@ func NewPackageManager(cfg *Config) *PackageManager {
 	if cfg.Component == "" {
 		cfg.Component = DefaultComponent
 	}
+	if len(cfg.Components) == 0 {
+		cfg.Components = DefaultComponents
+	}
 	if cfg.InstallPath == "" {
 		cfg.InstallPath = DefaultInstallPath
 	}
@@ -74,7 +77,7 @@ func NewPackageManager(cfg *Config) *PackageManager {
 		pm.logger.Printf("Initialized Debian PackageManager")
 		pm.logger.Printf("  Repository: %s", cfg.RepositoryURL)
 		pm.logger.Printf("  Release: %s", cfg.Release)
-		pm.logger.Printf("  Component: %s", cfg.Component)
+		pm.logger.Printf("  Components: %s", strings.Join(cfg.Components, ", "))
 		pm.logger.Printf("  InstallPath: %s", cfg.InstallPath)
 		pm.logger.Printf("  CachePath: %s", cfg.CachePath)
 	}
@@ -195,11 +198,8 @@ func (pm *PackageManager) updatePackageIndex(ctx context.Context, arch Architect
 
 	pm.logger.Printf("Fetching package index from repository...")
 
-	// Common Debian components to search
-	components := []string{"main", "contrib", "non-free", "non-free-firmware"}
-	
 	totalPackages := 0
-	for _, component := range components {
+	for _, component := range pm.config.Components {
 		// Construct URL for Packages.gz
 		url := fmt.Sprintf("%s/dists/%s/%s/binary-%s/Packages.gz",
 			pm.config.RepositoryURL,
@@ -12,6 +12,7 @@ type Config struct {
 	SecurityURL   string        // Security updates repository
 	Release       string        // Debian release (bookworm, bullseye, etc.)
 	Component     string        // Repository component (main, contrib, non-free)
+	Components    []string      // Components to index (default: DefaultComponents)
 	InstallPath   string        // Where to install packages
 	CachePath     string        // Where to cache downloaded files
 	Timeout       time.Duration
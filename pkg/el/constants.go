@@ -0,0 +1,69 @@
+// pkg/el/constants.go
+package el
+
+const (
+	// DefaultDistro is used when the release does not name a distribution
+	DefaultDistro = DistroRocky
+
+	// DefaultRelease is the default Enterprise Linux major version
+	DefaultRelease = "9"
+
+	// DefaultInstallPath is where packages will be extracted
+	DefaultInstallPath = "/opt/upkg"
+
+	// EPELURL is the Fedora-hosted EPEL repository, shared by every EL rebuild
+	EPELURL = "https://dl.fedoraproject.org/pub/epel"
+)
+
+// Supported Enterprise Linux distributions
+const (
+	DistroRocky        = "rocky"         // Rocky Linux
+	DistroAlma         = "almalinux"     // AlmaLinux
+	DistroCentOSStream = "centos-stream" // CentOS Stream
+	DistroUBI          = "ubi"           // Red Hat Universal Base Image (public RHEL subset)
+)
+
+// Default mirrors per distribution
+const (
+	RockyURL        = "https://dl.rockylinux.org/pub/rocky"
+	AlmaURL         = "https://repo.almalinux.org/almalinux"
+	CentOSStreamURL = "https://mirror.stream.centos.org"
+	UBIURL          = "https://cdn-ubi.redhat.com/content/public/ubi/dist"
+)
+
+// Release keys per distribution: the copy the distribution installs under
+// /etc/pki/rpm-gpg, then the one published by the vendor (not the mirror),
+// fetched once and pinned in the cache. $releasever is substituted by dnf.
+var (
+	RockyGPGKeys = []string{
+		"file:///etc/pki/rpm-gpg/RPM-GPG-KEY-Rocky-$releasever",
+		RockyURL + "/RPM-GPG-KEY-Rocky-$releasever",
+	}
+	AlmaGPGKeys = []string{
+		"file:///etc/pki/rpm-gpg/RPM-GPG-KEY-AlmaLinux-$releasever",
+		AlmaURL + "/RPM-GPG-KEY-AlmaLinux-$releasever",
+	}
+	CentOSStreamGPGKeys = []string{
+		"file:///etc/pki/rpm-gpg/RPM-GPG-KEY-centosofficial",
+		"https://www.centos.org/keys/RPM-GPG-KEY-CentOS-Official-SHA256",
+	}
+	UBIGPGKeys = []string{
+		"file:///etc/pki/rpm-gpg/RPM-GPG-KEY-redhat-release",
+		"https://www.redhat.com/security/data/fd431d51.txt",
+	}
+	EPELGPGKeys = []string{
+		"file:///etc/pki/rpm-gpg/RPM-GPG-KEY-EPEL-$releasever",
+		EPELURL + "/RPM-GPG-KEY-EPEL-$releasever",
+	}
+)
+
+// Enterprise Linux repositories
+const (
+	RepoBaseOS    = "baseos"    // Core operating system
+	RepoAppStream = "appstream" // Applications, runtimes and most -devel packages
+	RepoCRB       = "crb"       // CodeReady Linux Builder (PowerTools on EL8): build-only -devel packages
+	RepoEPEL      = "epel"      // Extra Packages for Enterprise Linux (opt-in)
+)
+
+// DefaultRepos are indexed when Config.Repos is empty
+var DefaultRepos = []string{RepoBaseOS, RepoAppStream, RepoCRB}
@@ -0,0 +1,197 @@
+// pkg/el/manager.go
+package el
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/arc-language/upkg/pkg/dnf"
+)
+
+// NewPackageManager creates a new Enterprise Linux package manager
+func NewPackageManager(cfg *Config) (*PackageManager, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	// Set defaults
+	if cfg.Distro == "" {
+		cfg.Distro = DefaultDistro
+	}
+	if cfg.Release == "" {
+		cfg.Release = DefaultRelease
+	}
+	if len(cfg.Repos) == 0 {
+		cfg.Repos = DefaultRepos
+	}
+	if cfg.InstallPath == "" {
+		cfg.InstallPath = DefaultInstallPath
+	}
+	if cfg.CachePath == "" {
+		homeDir, _ := os.UserHomeDir()
+		cfg.CachePath = filepath.Join(homeDir, ".cache", "upkg", "el")
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 2 * time.Minute
+	}
+
+	// Setup logger
+	logger := cfg.Logger
+	if logger == nil {
+		if cfg.Debug {
+			logger = log.New(os.Stdout, "[EL] ", log.LstdFlags)
+		} else {
+			logger = log.New(io.Discard, "", 0)
+		}
+	}
+
+	repos, err := Repositories(cfg.Distro, cfg.Release, cfg.MirrorURL, cfg.Repos)
+	if err != nil {
+		return nil, err
+	}
+
+	pm := &PackageManager{
+		dnf: dnf.NewPackageManager(&dnf.Config{
+			Release:     cfg.Release,
+			Repos:       repos,
+			NoFedora:    true, // EL supplies its own defaults (DefaultRepos)
+			GPGKeys:     cfg.GPGKeys,
+			NoGPGCheck:  cfg.NoGPGCheck,
+			InstallPath: cfg.InstallPath,
+			CachePath:   cfg.CachePath,
+			Timeout:     cfg.Timeout,
+			Debug:       cfg.Debug,
+			Logger:      logger,
+		}),
+		config: cfg,
+		logger: logger,
+	}
+
+	if cfg.Debug {
+		pm.logger.Printf("Initialized Enterprise Linux PackageManager")
+		pm.logger.Printf("  Distro: %s %s", cfg.Distro, cfg.Release)
+		pm.logger.Printf("  Repos: %s", strings.Join(cfg.Repos, ", "))
+	}
+
+	return pm, nil
+}
+
+// Repositories builds the repository list for a distribution and major
+// version. URLs keep $releasever and $basearch for dnf to substitute.
+func Repositories(distro, release, mirrorURL string, names []string) ([]*dnf.Repo, error) {
+	var base string
+	var keys []string
+	switch distro {
+	case DistroRocky:
+		base, keys = RockyURL, RockyGPGKeys
+	case DistroAlma:
+		base, keys = AlmaURL, AlmaGPGKeys
+	case DistroCentOSStream:
+		base, keys = CentOSStreamURL, CentOSStreamGPGKeys
+	case DistroUBI:
+		base, keys = UBIURL, UBIGPGKeys
+	default:
+		return nil, fmt.Errorf("unsupported Enterprise Linux distribution: %s", distro)
+	}
+	if mirrorURL != "" {
+		base = mirrorURL
+	}
+	base = strings.TrimSuffix(base, "/")
+
+	var repos []*dnf.Repo
+	for _, name := range names {
+		name = strings.ToLower(name)
+
+		if name == RepoEPEL {
+			repos = append(repos, &dnf.Repo{
+				ID:       "epel",
+				Name:     "Extra Packages for Enterprise Linux $releasever",
+				BaseURL:  EPELURL + "/$releasever/Everything/$basearch",
+				Enabled:  true,
+				GPGCheck: true,
+				GPGKey:   EPELGPGKeys,
+			})
+			continue
+		}
+
+		dir, err := repoDir(distro, release, name)
+		if err != nil {
+			return nil, err
+		}
+
+		var url string
+		switch distro {
+		case DistroUBI:
+			url = fmt.Sprintf("%s/ubi$releasever/$releasever/$basearch/%s/os", base, dir)
+		case DistroCentOSStream:
+			url = fmt.Sprintf("%s/$releasever-stream/%s/$basearch/os", base, dir)
+		default:
+			url = fmt.Sprintf("%s/$releasever/%s/$basearch/os", base, dir)
+		}
+
+		repos = append(repos, &dnf.Repo{
+			ID:       fmt.Sprintf("%s-%s", distro, name),
+			BaseURL:  url,
+			Enabled:  true,
+			GPGCheck: true,
+			GPGKey:   keys,
+		})
+	}
+
+	return repos, nil
+}
+
+// repoDir returns the directory name a distribution uses for a repository
+func repoDir(distro, release, name string) (string, error) {
+	if distro == DistroUBI {
+		switch name {
+		case RepoBaseOS:
+			return "baseos", nil
+		case RepoAppStream:
+			return "appstream", nil
+		case RepoCRB:
+			return "codeready-builder", nil
+		}
+		return "", fmt.Errorf("unknown repository %q for %s", name, distro)
+	}
+
+	switch name {
+	case RepoBaseOS:
+		return "BaseOS", nil
+	case RepoAppStream:
+		return "AppStream", nil
+	case RepoCRB:
+		// CRB was called PowerTools before EL9
+		if release == "8" {
+			return "PowerTools", nil
+		}
+		return "CRB", nil
+	}
+	return "", fmt.Errorf("unknown repository %q for %s", name, distro)
+}
+
+// Download downloads and installs a package and its dependencies
+func (pm *PackageManager) Download(ctx context.Context, opts *DownloadOptions) error {
+	return pm.dnf.Download(ctx, opts)
+}
+
+// GetPackageInfo retrieves information about a package
+func (pm *PackageManager) GetPackageInfo(ctx context.Context, name string, arch Architecture) (*PackageInfo, error) {
+	return pm.dnf.GetPackageInfo(ctx, name, arch)
+}
+
+// SearchPackages searches for packages by name
+func (pm *PackageManager) SearchPackages(ctx context.Context, query string, arch Architecture) ([]*PackageInfo, error) {
+	return pm.dnf.SearchPackages(ctx, query, arch)
+}
+
+// FindFileOwners returns the packages that ship a path (or bare file name)
+func (pm *PackageManager) FindFileOwners(ctx context.Context, path string, arch Architecture) ([]*PackageInfo, error) {
+	return pm.dnf.FindFileOwners(ctx, path, arch)
+}
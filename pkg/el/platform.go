@@ -0,0 +1,71 @@
+// pkg/el/platform.go
+package el
+
+import (
+	"strings"
+
+	"github.com/arc-language/upkg/pkg/dnf"
+)
+
+// Architecture represents an RPM architecture (x86_64, aarch64, ...)
+type Architecture = dnf.Architecture
+
+// DetectArchitecture automatically detects the current architecture
+func DetectArchitecture() (Architecture, error) {
+	return dnf.DetectArchitecture()
+}
+
+// ReleaseFor maps an os-release ID and VERSION_ID to a release string
+// understood by ParseRelease, e.g. ("rocky", "9.4") -> "rocky-9".
+// RHEL itself maps to UBI, the subset Red Hat publishes without a subscription;
+// other rebuilds (Oracle, EuroLinux, ...) map to Rocky.
+func ReleaseFor(id, versionID string) string {
+	major, _, _ := strings.Cut(versionID, ".")
+	if major == "" {
+		major = DefaultRelease
+	}
+
+	switch id {
+	case "rocky":
+		return DistroRocky + "-" + major
+	case "almalinux":
+		return DistroAlma + "-" + major
+	case "centos":
+		return DistroCentOSStream + "-" + major
+	case "rhel":
+		return DistroUBI + "-" + major
+	default:
+		return DefaultDistro + "-" + major
+	}
+}
+
+// ParseRelease splits a release such as "rocky-9", "alma9", "centos-stream-10",
+// "ubi9" or a bare "9" into a distribution and major version
+func ParseRelease(release string) (distro, major string) {
+	release = strings.ToLower(strings.TrimSpace(release))
+
+	aliases := []struct {
+		prefix string
+		distro string
+	}{
+		{"centos-stream", DistroCentOSStream},
+		{"centos", DistroCentOSStream},
+		{"stream", DistroCentOSStream},
+		{"almalinux", DistroAlma},
+		{"alma", DistroAlma},
+		{"rocky", DistroRocky},
+		{"rhel", DistroUBI},
+		{"ubi", DistroUBI},
+	}
+
+	for _, alias := range aliases {
+		if rest, ok := strings.CutPrefix(release, alias.prefix); ok {
+			distro = alias.distro
+			release = strings.TrimLeft(rest, "-:")
+			break
+		}
+	}
+
+	major, _, _ = strings.Cut(release, ".")
+	return distro, major
+}
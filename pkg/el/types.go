@@ -0,0 +1,39 @@
+// pkg/el/types.go
+package el
+
+import (
+	"log"
+	"time"
+
+	"github.com/arc-language/upkg/pkg/dnf"
+)
+
+// Config configures the Enterprise Linux package manager
+type Config struct {
+	Distro      string        // Distribution (rocky, almalinux, centos-stream, ubi)
+	Release     string        // Major version (8, 9, 10)
+	MirrorURL   string        // Overrides the distribution's default mirror
+	Repos       []string      // Repositories to index (baseos, appstream, crb, epel)
+	GPGKeys     []string      // Extra trusted keys (paths or URLs), added to the distribution's own
+	NoGPGCheck  bool          // Skip RPM signature checks; header and payload digests are still verified
+	InstallPath string        // Where to install packages
+	CachePath   string        // Where to cache downloaded files
+	Timeout     time.Duration // Network timeout
+	Debug       bool          // Enable debug logging
+	Logger      *log.Logger   // Custom logger (optional)
+}
+
+// PackageManager handles Enterprise Linux package operations.
+// Repository metadata, dependency resolution and RPM extraction are
+// shared with the dnf package; only the repository layout differs.
+type PackageManager struct {
+	dnf    *dnf.PackageManager
+	config *Config
+	logger *log.Logger
+}
+
+// PackageInfo contains metadata about a package from repodata
+type PackageInfo = dnf.PackageInfo
+
+// DownloadOptions configures package download and extraction
+type DownloadOptions = dnf.DownloadOptions
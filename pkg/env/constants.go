@@ -2,6 +2,7 @@
 package env
 
 import (
+    "os"
     "path/filepath"
     "runtime"
 )
@@ -12,10 +13,10 @@ func GetPackageLayout(backend string) PackageLayout {
     switch backend {
     case "apt", "dpkg":
         return getDebianLayout()
-    case "dnf", "yum":
+    case "dnf", "yum", "el":
         return getFedoraLayout()
     case "brew":
-        return getBrewLayout()
+        return getBrewLayout("")
     case "pacman":
         return getArchLayout()
     case "apk":
@@ -24,8 +25,10 @@ func GetPackageLayout(backend string) PackageLayout {
         return getOpenSUSELayout()
     case "choco":
         return getChocoLayout()
-    case "nix":
+    case "nix", "guix":
         return getNixLayout()
+    case "msys2":
+        return getMSYS2Layout()
     default:
         return getDefaultLayout()
     }
@@ -93,10 +96,11 @@ func getFedoraLayout() PackageLayout {
 }
 
 // Homebrew uses a flat structure when extracted
-func getBrewLayout() PackageLayout {
-    // Homebrew bottles extract to a simple structure
-    // bottle contents: lib/libssl.dylib, include/openssl/
-    return PackageLayout{
+func getBrewLayout(installPath string) PackageLayout {
+    // Kegs under Cellar/ are linked into the prefix: lib/libssl.dylib,
+    // include/openssl/. Keg-only formulae, and kegs left unlinked by a
+    // conflict, are only reachable through opt/<formula>.
+    layout := PackageLayout{
         Libraries: []string{
             "lib",
         },
@@ -110,21 +114,55 @@ func getBrewLayout() PackageLayout {
             "bin",
         },
     }
+    if installPath == "" {
+        return layout
+    }
+
+    for _, formula := range unlinkedFormulae(installPath) {
+        prefix := filepath.Join("opt", formula)
+        layout.Libraries = append(layout.Libraries, filepath.Join(prefix, "lib"))
+        layout.Includes = append(layout.Includes, filepath.Join(prefix, "include"))
+        layout.PkgConfig = append(layout.PkgConfig, filepath.Join(prefix, "lib", "pkgconfig"))
+        layout.Binaries = append(layout.Binaries, filepath.Join(prefix, "bin"))
+    }
+    return layout
+}
+
+// unlinkedFormulae lists the formulae under opt/ that brew has not linked
+// into the prefix, i.e. that have no var/homebrew/linked/<formula> record
+func unlinkedFormulae(installPath string) []string {
+    entries, err := os.ReadDir(filepath.Join(installPath, "opt"))
+    if err != nil {
+        return nil
+    }
+
+    var formulae []string
+    for _, entry := range entries {
+        linked := filepath.Join(installPath, "var", "homebrew", "linked", entry.Name())
+        if _, err := os.Lstat(linked); err == nil {
+            continue
+        }
+        formulae = append(formulae, entry.Name())
+    }
+    return formulae
 }
 
 // Arch Linux packages use simple /usr structure
 func getArchLayout() PackageLayout {
     return PackageLayout{
-        // Pacman packages contain: usr/lib/libssl.so
+        // Pacman packages contain: usr/lib/libssl.so; multilib lib32-*
+        // packages: usr/lib32/libssl.so
         Libraries: []string{
             filepath.Join("usr", "lib"),
             filepath.Join("lib"),
+            filepath.Join("usr", "lib32"),
         },
         Includes: []string{
             filepath.Join("usr", "include"),
         },
         PkgConfig: []string{
             filepath.Join("usr", "lib", "pkgconfig"),
+            filepath.Join("usr", "lib32", "pkgconfig"),
             filepath.Join("usr", "share", "pkgconfig"),
         },
         Binaries: []string{
@@ -202,42 +240,67 @@ func getChocoLayout() PackageLayout {
     }
 }
 
-// Nix packages use a flat structure when extracted
+// Nix packages extract to <NameVersion>/<output>/ and are merged into the
+// environment's profile (see RebuildProfile)
 func getNixLayout() PackageLayout {
     return PackageLayout{
-        // Nix store paths extract to: lib/, include/, bin/
+        // The profile links every output's lib/, include/, bin/ and share/
         Libraries: []string{
-            "lib",
-            "lib64",
+            filepath.Join(ProfileDir, "lib"),
         },
         Includes: []string{
-            "include",
+            filepath.Join(ProfileDir, "include"),
         },
         PkgConfig: []string{
-            filepath.Join("lib", "pkgconfig"),
+            filepath.Join(ProfileDir, "lib", "pkgconfig"),
+            filepath.Join(ProfileDir, "share", "pkgconfig"),
         },
         Binaries: []string{
-            "bin",
+            filepath.Join(ProfileDir, "bin"),
         },
     }
 }
 
-// Default layout for unknown backends (FHS-like)
+// MSYS2 packages install under their environment prefix
+func getMSYS2Layout() PackageLayout {
+    // MSYS2 packages contain: ucrt64/lib/libssl.dll.a, ucrt64/bin/libssl-3-x64.dll
+    var layout PackageLayout
+    for _, prefix := range []string{"ucrt64", "mingw64", "clang64", "clangarm64"} {
+        layout.Libraries = append(layout.Libraries,
+            filepath.Join(prefix, "lib"),
+            filepath.Join(prefix, "bin"), // DLLs live next to executables
+        )
+        layout.Includes = append(layout.Includes, filepath.Join(prefix, "include"))
+        layout.PkgConfig = append(layout.PkgConfig,
+            filepath.Join(prefix, "lib", "pkgconfig"),
+            filepath.Join(prefix, "share", "pkgconfig"),
+        )
+        layout.Binaries = append(layout.Binaries, filepath.Join(prefix, "bin"))
+    }
+    return layout
+}
+
+// Default layout for unknown backends (FHS-like), plus the nix profile
+// for auto environments that resolved packages to nix
 func getDefaultLayout() PackageLayout {
     return PackageLayout{
         Libraries: []string{
             filepath.Join("usr", "lib"),
             filepath.Join("lib"),
+            filepath.Join(ProfileDir, "lib"),
         },
         Includes: []string{
             filepath.Join("usr", "include"),
+            filepath.Join(ProfileDir, "include"),
         },
         PkgConfig: []string{
             filepath.Join("usr", "lib", "pkgconfig"),
+            filepath.Join(ProfileDir, "lib", "pkgconfig"),
         },
         Binaries: []string{
             filepath.Join("usr", "bin"),
             filepath.Join("bin"),
+            filepath.Join(ProfileDir, "bin"),
         },
     }
 }
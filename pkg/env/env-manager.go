@@ -14,10 +14,23 @@ type EnvSpec struct {
     Name        string            `json:"name"`
     InstallPath string            `json:"install_path"`
     Backend     string            `json:"backend"`
+    EnvSettings                   // Backend settings, stored inline in env.json
     Packages    map[string]string `json:"packages"` // name -> version
     CreatedAt   string            `json:"created_at"`
 }
 
+// EnvSettings holds the backend settings an environment is pinned to.
+// Empty fields fall back to the backend's defaults (or host detection).
+type EnvSettings struct {
+    Release        string   `json:"release,omitempty"`         // Distribution release (jammy, 41, v3.20, 15.6, ...)
+    Arch           string   `json:"arch,omitempty"`            // Target architecture in the backend's terms
+    Mirror         string   `json:"mirror,omitempty"`          // Repository base URL replacing the default mirror
+    Repos          []string `json:"repos,omitempty"`           // Repositories or components to index
+    GPGKeys        []string `json:"gpg_keys,omitempty"`        // Extra trusted package signing keys (paths or URLs)
+    Relocate       bool     `json:"relocate,omitempty"`        // Rewrite ELF RUNPATHs to resolve inside the environment
+    SetInterpreter bool     `json:"set_interpreter,omitempty"` // Point executables at the environment's own dynamic loader (implies Relocate)
+}
+
 // EnvironmentManager manages conda-style environments
 type EnvironmentManager struct {
     rootDir string // ~/.upkg/envs
@@ -39,6 +52,11 @@ func NewEnvironmentManager(rootDir string) *EnvironmentManager {
 
 // CreateEnv creates a new isolated environment
 func (em *EnvironmentManager) CreateEnv(name, backend string) (*EnvSpec, error) {
+    return em.CreateEnvWithSettings(name, backend, EnvSettings{})
+}
+
+// CreateEnvWithSettings creates a new isolated environment pinned to the given backend settings
+func (em *EnvironmentManager) CreateEnvWithSettings(name, backend string, settings EnvSettings) (*EnvSpec, error) {
     envPath := filepath.Join(em.rootDir, name)
     
     if _, err := os.Stat(envPath); err == nil {
@@ -54,6 +72,7 @@ func (em *EnvironmentManager) CreateEnv(name, backend string) (*EnvSpec, error)
         Name:        name,
         InstallPath: envPath,
         Backend:     backend,
+        EnvSettings: settings,
         Packages:    make(map[string]string),
         CreatedAt:   time.Now().Format(time.RFC3339),
     }
@@ -156,6 +175,11 @@ func (e *EnvSpec) AddPackage(name, version string) {
     e.Packages[name] = version
 }
 
+// RemovePackage forgets a package installation
+func (e *EnvSpec) RemovePackage(name string) {
+    delete(e.Packages, name)
+}
+
 // saveEnv saves environment metadata
 func (em *EnvironmentManager) saveEnv(env *EnvSpec) error {
     metaPath := filepath.Join(env.InstallPath, "env.json")
@@ -16,9 +16,18 @@ func New(installPath, backendType string) *Environment {
     }
 }
 
+// layout returns the backend's package layout. For brew it also covers
+// the formulae under opt/ that are not linked into the prefix.
+func (e *Environment) layout() PackageLayout {
+    if e.BackendType == "brew" {
+        return getBrewLayout(e.InstallPath)
+    }
+    return GetPackageLayout(e.BackendType)
+}
+
 // GetLibraryPaths returns absolute paths to library directories
 func (e *Environment) GetLibraryPaths() []string {
-    layout := GetPackageLayout(e.BackendType)
+    layout := e.layout()
     
     paths := make([]string, 0, len(layout.Libraries))
     seen := make(map[string]bool)
@@ -81,7 +90,7 @@ func containsSharedLibs(path string) bool {
 
 // GetIncludePaths returns absolute paths to include directories
 func (e *Environment) GetIncludePaths() []string {
-    layout := GetPackageLayout(e.BackendType)
+    layout := e.layout()
     
     paths := make([]string, 0, len(layout.Includes))
     for _, relPath := range layout.Includes {
@@ -96,7 +105,7 @@ func (e *Environment) GetIncludePaths() []string {
 
 // GetPkgConfigPaths returns absolute paths to pkg-config directories
 func (e *Environment) GetPkgConfigPaths() []string {
-    layout := GetPackageLayout(e.BackendType)
+    layout := e.layout()
     
     paths := make([]string, 0, len(layout.PkgConfig))
     for _, relPath := range layout.PkgConfig {
@@ -111,7 +120,7 @@ func (e *Environment) GetPkgConfigPaths() []string {
 
 // GetBinaryPaths returns absolute paths to binary directories
 func (e *Environment) GetBinaryPaths() []string {
-    layout := GetPackageLayout(e.BackendType)
+    layout := e.layout()
     
     paths := make([]string, 0, len(layout.Binaries))
     for _, relPath := range layout.Binaries {
@@ -0,0 +1,282 @@
+// pkg/env/profile.go
+package env
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+)
+
+// ProfileDir is the directory inside an environment holding its merged
+// profile, a symlink farm like the one nix's buildEnv produces
+const ProfileDir = "profile"
+
+// ProfileDirs are the top-level directories merged into a profile
+var ProfileDirs = []string{"bin", "lib", "include", "share"}
+
+// ProfileSource is one prefix linked into a profile
+type ProfileSource struct {
+    Path string   // Absolute path of the prefix (e.g. .../hello-2.12/out)
+    Dirs []string // Top-level directories to link (default: ProfileDirs)
+}
+
+// Collision is a profile path provided by more than one source. The
+// source linked first wins; later ones are ignored.
+type Collision struct {
+    Path    string // Path relative to the profile (e.g. bin/python3)
+    Kept    string // File the profile links to
+    Ignored string // File that lost
+}
+
+// Profile describes a built profile
+type Profile struct {
+    Path       string      // Absolute path of the profile directory
+    Links      int         // Number of symlinks created
+    Collisions []Collision // Paths more than one source provides
+}
+
+// BuildProfile replaces profileDir with a symlink farm merging the top-level
+// directories of sources. Directories are linked whole until a second source
+// contributes to them, at which point they are unfolded into real
+// directories of links. The new profile is assembled next to the old one
+// and swapped in, so a failed build leaves the previous profile intact.
+func BuildProfile(profileDir string, sources []ProfileSource) (*Profile, error) {
+    tmp := profileDir + ".tmp"
+    os.RemoveAll(tmp)
+    if err := os.MkdirAll(tmp, 0755); err != nil {
+        return nil, fmt.Errorf("creating profile: %w", err)
+    }
+
+    b := &profileBuilder{
+        root:   tmp,
+        owners: make(map[string]string),
+        profile: &Profile{
+            Path: profileDir,
+        },
+    }
+
+    for _, source := range sources {
+        dirs := source.Dirs
+        if dirs == nil {
+            dirs = ProfileDirs
+        }
+        for _, dir := range dirs {
+            src := filepath.Join(source.Path, dir)
+            if !dirExists(src) {
+                continue
+            }
+            if err := b.link(src, dir); err != nil {
+                os.RemoveAll(tmp)
+                return nil, err
+            }
+        }
+    }
+
+    if err := os.RemoveAll(profileDir); err != nil {
+        os.RemoveAll(tmp)
+        return nil, fmt.Errorf("removing old profile: %w", err)
+    }
+    if err := os.Rename(tmp, profileDir); err != nil {
+        os.RemoveAll(tmp)
+        return nil, fmt.Errorf("installing profile: %w", err)
+    }
+
+    return b.profile, nil
+}
+
+// profileBuilder tracks which source each linked profile path points to
+type profileBuilder struct {
+    root    string            // Directory the profile is assembled in
+    owners  map[string]string // Profile-relative path -> source it links to
+    profile *Profile
+}
+
+// link adds src to the profile at rel, merging directories and recording
+// collisions between files
+func (b *profileBuilder) link(src, rel string) error {
+    dst := filepath.Join(b.root, rel)
+    srcIsDir := dirExists(src)
+
+    existing, err := os.Lstat(dst)
+    if os.IsNotExist(err) {
+        return b.symlink(src, rel)
+    }
+    if err != nil {
+        return err
+    }
+
+    owner, linked := b.owners[rel]
+    switch {
+    case existing.IsDir() && srcIsDir:
+        // Already unfolded: merge the new directory's entries
+        return b.linkChildren(src, rel)
+
+    case linked && srcIsDir && dirExists(owner):
+        // A whole directory of another source is linked here: replace the
+        // link by a real directory holding links to both
+        if err := os.Remove(dst); err != nil {
+            return err
+        }
+        delete(b.owners, rel)
+        b.profile.Links--
+        if err := os.Mkdir(dst, 0755); err != nil {
+            return err
+        }
+        if err := b.linkChildren(owner, rel); err != nil {
+            return err
+        }
+        return b.linkChildren(src, rel)
+    }
+
+    // Two sources ship the same file; identical targets are not a conflict
+    if linked && sameFile(owner, src) {
+        return nil
+    }
+    if !linked {
+        owner = filepath.Join(b.profile.Path, rel) // A directory merged from several sources
+    }
+    b.profile.Collisions = append(b.profile.Collisions, Collision{
+        Path:    filepath.ToSlash(rel),
+        Kept:    owner,
+        Ignored: src,
+    })
+    return nil
+}
+
+// linkChildren links every entry of directory src into the profile at rel
+func (b *profileBuilder) linkChildren(src, rel string) error {
+    entries, err := os.ReadDir(src)
+    if err != nil {
+        return err
+    }
+    for _, entry := range entries {
+        name := entry.Name()
+        if err := b.link(filepath.Join(src, name), filepath.Join(rel, name)); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// symlink creates the profile link for rel, relative to the profile so the
+// environment can be moved as a whole
+func (b *profileBuilder) symlink(src, rel string) error {
+    dst := filepath.Join(b.root, rel)
+    if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+        return err
+    }
+
+    // Link targets are computed against the final profile location
+    final := filepath.Join(b.profile.Path, rel)
+    target, err := filepath.Rel(filepath.Dir(final), src)
+    if err != nil {
+        target = src
+    }
+    if err := os.Symlink(target, dst); err != nil {
+        return fmt.Errorf("linking %s: %w", rel, err)
+    }
+
+    b.owners[rel] = src
+    b.profile.Links++
+    return nil
+}
+
+// sameFile reports whether two paths resolve to the same file
+func sameFile(a, b string) bool {
+    ra, errA := filepath.EvalSymlinks(a)
+    rb, errB := filepath.EvalSymlinks(b)
+    if errA != nil || errB != nil {
+        return a == b
+    }
+    return ra == rb
+}
+
+// ProfilePath returns the environment's merged profile directory
+func (e *Environment) ProfilePath() string {
+    return filepath.Join(e.InstallPath, ProfileDir)
+}
+
+// RebuildProfile rebuilds the environment's profile from the packages
+// installed in it. Only nix and guix environments keep packages in separate
+// prefixes and have a profile; for other backends it returns nil.
+func (e *Environment) RebuildProfile() (*Profile, error) {
+    if e.BackendType != "nix" && e.BackendType != "guix" {
+        return nil, nil
+    }
+
+    sources, err := nixProfileSources(e.InstallPath)
+    if err != nil {
+        return nil, err
+    }
+    return BuildProfile(e.ProfilePath(), sources)
+}
+
+// nixProfileSources lists the prefixes of a nix install path: every output
+// of every installed package (<NameVersion>/<output>/), then the lib/ of
+// each dependency store path (<hash>-<name>/) so their shared libraries
+// resolve without leaking dependency tools into PATH
+func nixProfileSources(installPath string) ([]ProfileSource, error) {
+    entries, err := os.ReadDir(installPath)
+    if err != nil {
+        if os.IsNotExist(err) {
+            return nil, nil
+        }
+        return nil, err
+    }
+
+    var packages, deps []ProfileSource
+    for _, entry := range entries {
+        name := entry.Name()
+        if !entry.IsDir() || name == ProfileDir || strings.HasPrefix(name, ".") || isPartial(name) {
+            continue
+        }
+        dir := filepath.Join(installPath, name)
+
+        if isStorePathName(name) {
+            deps = append(deps, ProfileSource{Path: dir, Dirs: []string{"lib"}})
+            continue
+        }
+
+        outputs, err := os.ReadDir(dir)
+        if err != nil {
+            continue
+        }
+        names := make([]string, 0, len(outputs))
+        for _, output := range outputs {
+            if output.IsDir() && !isPartial(output.Name()) {
+                names = append(names, output.Name())
+            }
+        }
+        // "out" wins collisions between a package's own outputs
+        sort.SliceStable(names, func(i, j int) bool {
+            return names[i] == "out" && names[j] != "out"
+        })
+        for _, output := range names {
+            packages = append(packages, ProfileSource{Path: filepath.Join(dir, output)})
+        }
+    }
+
+    return append(packages, deps...), nil
+}
+
+// isPartial reports whether name is an unfinished download or profile build
+func isPartial(name string) bool {
+    return strings.HasSuffix(name, ".partial") || strings.HasSuffix(name, ".tmp")
+}
+
+// isStorePathName reports whether name looks like a store path basename:
+// 32 nix-base32 characters, a dash and a name
+func isStorePathName(name string) bool {
+    const alphabet = "0123456789abcdfghijklmnpqrsvwxyz"
+    if len(name) < 34 || name[32] != '-' {
+        return false
+    }
+    for _, c := range name[:32] {
+        if !strings.ContainsRune(alphabet, c) {
+            return false
+        }
+    }
+    return true
+}
@@ -0,0 +1,72 @@
+// pkg/env/relocate.go
+package env
+
+import (
+    "os"
+    "path/filepath"
+
+    "github.com/arc-language/upkg/pkg/patchelf"
+)
+
+// RelocateBinaries rewrites the RUNPATH of every ELF file in the environment
+// so shared libraries resolve inside it, relative to each binary's $ORIGIN.
+// With setInterpreter, executables also get the environment's own dynamic
+// loader. Backends whose packages are not ELF, and brew, which relocates
+// its own kegs when pouring bottles, are left alone and return nil.
+func (e *Environment) RelocateBinaries(setInterpreter bool) (*patchelf.Report, error) {
+    prefixes := relocatePrefixes(e.BackendType, e.InstallPath)
+    if prefixes == nil {
+        return nil, nil
+    }
+
+    return patchelf.Relocate(&patchelf.Options{
+        Root:           e.InstallPath,
+        PrefixMap:      prefixes,
+        LibraryDirs:    e.GetLibraryPaths(),
+        SetInterpreter: setInterpreter,
+        Skip:           []string{ProfileDir},
+    })
+}
+
+// relocatePrefixes maps the absolute paths a backend's binaries were built
+// against to where they land in the environment
+func relocatePrefixes(backend, installPath string) map[string]string {
+    switch backend {
+    case "nix":
+        return nixPrefixes(installPath)
+    case "guix":
+        return map[string]string{"/gnu/store": installPath}
+    case "apt", "dpkg", "dnf", "el", "yum", "pacman", "apk", "zypper":
+        // FHS packages are extracted as a root filesystem
+        return map[string]string{"/": installPath}
+    default:
+        return nil
+    }
+}
+
+// nixPrefixes maps dependency store paths, installed as <hash>-<name>/, to
+// InstallPath, and each package output to <NameVersion>/<output>/ through
+// the <hash>-<name> link the nix backend leaves for it
+func nixPrefixes(installPath string) map[string]string {
+    prefixes := map[string]string{"/nix/store": installPath}
+
+    entries, err := os.ReadDir(installPath)
+    if err != nil {
+        return prefixes
+    }
+    for _, entry := range entries {
+        name := entry.Name()
+        if entry.Type()&os.ModeSymlink == 0 || !isStorePathName(name) {
+            continue
+        }
+        target, err := os.Readlink(filepath.Join(installPath, name))
+        if err != nil {
+            continue
+        }
+        if !filepath.IsAbs(target) {
+            target = filepath.Join(installPath, target)
+        }
+        prefixes["/nix/store/"+name] = target
+    }
+    return prefixes
+}
@@ -0,0 +1,41 @@
+// pkg/guix/constants.go
+package guix
+
+const (
+	// DefaultStoreDir is the Guix store
+	DefaultStoreDir = "/gnu/store"
+
+	// DefaultInstallPath is where packages will be extracted
+	DefaultInstallPath = "/opt/upkg"
+
+	// IndexName prefixes the cached package index files (guix_x86_64_linux.json)
+	IndexName = "guix"
+)
+
+var (
+	// DefaultSubstituteURLs are the official build farms, in the order
+	// guix-daemon tries them
+	DefaultSubstituteURLs = []string{
+		"https://bordeaux.guix.gnu.org",
+		"https://ci.guix.gnu.org",
+	}
+
+	// DefaultAuthorizedKeys are the signing keys of the official build
+	// farms, as shipped in guix's etc/substitutes/*.pub
+	DefaultAuthorizedKeys = []string{
+		// bordeaux.guix.gnu.org
+		"(public-key (ecc (curve Ed25519) (q #7D602902D3A2DBB83F8A0FB98602A754C5493B0B778C8D1DD4E0F41DE14DE34F#)))",
+		// ci.guix.gnu.org
+		"(public-key (ecc (curve Ed25519) (q #8D156F295D24B0D9A86FA5741A840FF2D24F60F7B6C4134814AD55625971B394#)))",
+	}
+)
+
+// Guix systems; armhf-linux is what Nix calls armv7l-linux
+const (
+	SystemX8664Linux     = "x86_64-linux"
+	SystemI686Linux      = "i686-linux"
+	SystemAarch64Linux   = "aarch64-linux"
+	SystemArmhfLinux     = "armhf-linux"
+	SystemPowerPC64Linux = "powerpc64le-linux"
+	SystemRiscv64Linux   = "riscv64-linux"
+)
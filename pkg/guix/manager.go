@@ -0,0 +1,234 @@
+// pkg/guix/manager.go
+package guix
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arc-language/upkg/pkg/nix"
+)
+
+// NewPackageManager creates a new Guix package manager
+func NewPackageManager(cfg *Config) *PackageManager {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	// Set defaults
+	if len(cfg.SubstituteURLs) == 0 {
+		cfg.SubstituteURLs = DefaultSubstituteURLs
+	}
+	if len(cfg.AuthorizedKeys) == 0 {
+		cfg.AuthorizedKeys = DefaultAuthorizedKeys
+	}
+	if cfg.System == "" {
+		cfg.System = DetectSystem()
+	}
+	if cfg.InstallPath == "" {
+		cfg.InstallPath = DefaultInstallPath
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	// Setup logger
+	logger := cfg.Logger
+	if logger == nil {
+		if cfg.Debug {
+			logger = log.New(os.Stdout, "[DEBUG] ", log.LstdFlags)
+		} else {
+			logger = log.New(io.Discard, "", 0)
+		}
+	}
+
+	pm := &PackageManager{
+		client: nix.NewClientWithTimeout(cfg.Timeout),
+		config: cfg,
+		logger: logger,
+	}
+
+	if cfg.Debug {
+		pm.logger.Printf("Initialized Guix PackageManager")
+		for _, url := range cfg.SubstituteURLs {
+			pm.logger.Printf("  Substitute URL: %s", url)
+		}
+		pm.logger.Printf("  System: %s", cfg.System)
+	}
+
+	// Everything but narinfo lookups is the nix machinery, pointed at the
+	// Guix store, the guix_<system>.json index and this fetcher
+	pm.nix = nix.NewPackageManager(&nix.Config{
+		CacheURL:    cfg.SubstituteURLs[0],
+		Platform:    nix.Platform(cfg.System),
+		InstallPath: cfg.InstallPath,
+		StoreDir:    DefaultStoreDir,
+		CachePath:   cfg.CachePath,
+		IndexName:   IndexName,
+		Fetcher:     pm,
+		Timeout:     cfg.Timeout,
+		Debug:       cfg.Debug,
+		Logger:      logger,
+	})
+
+	return pm
+}
+
+// DetectSystem returns the Guix system of the host
+func DetectSystem() string {
+	switch runtime.GOARCH {
+	case "386":
+		return SystemI686Linux
+	case "arm64":
+		return SystemAarch64Linux
+	case "arm":
+		return SystemArmhfLinux
+	case "ppc64le":
+		return SystemPowerPC64Linux
+	case "riscv64":
+		return SystemRiscv64Linux
+	default:
+		return SystemX8664Linux
+	}
+}
+
+// FetchNARInfo looks a store hash up on each substitute server in turn and
+// returns the first narinfo signed by an authorized key
+func (pm *PackageManager) FetchNARInfo(ctx context.Context, hash string) (*nix.NARInfo, error) {
+	var lastErr error
+	for _, server := range pm.config.SubstituteURLs {
+		server = strings.TrimSuffix(server, "/")
+		info, err := pm.fetchNARInfo(ctx, server, hash)
+		if err == nil {
+			return info, nil
+		}
+		pm.logger.Printf("✗ %s: %v", server, err)
+		lastErr = fmt.Errorf("%s: %w", server, err)
+	}
+	return nil, lastErr
+}
+
+// fetchNARInfo fetches, verifies and parses a narinfo from one server
+func (pm *PackageManager) fetchNARInfo(ctx context.Context, server, hash string) (*nix.NARInfo, error) {
+	url := fmt.Sprintf("%s/%s.narinfo", server, hash)
+	pm.logger.Printf("Fetching narinfo from: %s", url)
+
+	content, err := pm.client.GetString(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if !pm.config.AllowUnsigned {
+		keys, err := pm.authorizedKeys()
+		if err != nil {
+			return nil, err
+		}
+		host, err := VerifyNARInfo(content, keys)
+		if err != nil {
+			return nil, err
+		}
+		pm.logger.Printf("  ✓ Signed by %s", host)
+	}
+
+	info, err := nix.ParseNARInfo(content)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(info.StorePath, DefaultStoreDir+"/") {
+		return nil, fmt.Errorf("%s is not in %s", info.StorePath, DefaultStoreDir)
+	}
+
+	nar, err := selectNAR(content)
+	if err != nil {
+		return nil, err
+	}
+	info.URL = nar.url
+	info.Compression = nar.compression
+	info.FileSize = nar.fileSize
+	info.CacheURL = server
+
+	return info, nil
+}
+
+// narFile is one of the URL/Compression/FileSize groups of a Guix narinfo
+type narFile struct {
+	url         string
+	compression string
+	fileSize    int64
+}
+
+// compressionPreference orders the NAR compressions we can decode; Guix
+// also offers lzip, which is skipped
+var compressionPreference = []string{nix.CompressionZstd, nix.CompressionGzip, nix.CompressionNone}
+
+// selectNAR picks the best supported NAR of a narinfo. Guix lists one
+// URL, Compression and FileSize group per available compression.
+func selectNAR(content string) (*narFile, error) {
+	var files []*narFile
+	for _, line := range strings.Split(content, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.TrimSpace(key) {
+		case "URL":
+			files = append(files, &narFile{url: value, compression: nix.CompressionNone})
+		case "Compression":
+			if len(files) > 0 {
+				files[len(files)-1].compression = value
+			}
+		case "FileSize":
+			if len(files) > 0 {
+				files[len(files)-1].fileSize, _ = strconv.ParseInt(value, 10, 64)
+			}
+		}
+	}
+
+	for _, compression := range compressionPreference {
+		for _, file := range files {
+			if file.compression == compression {
+				return file, nil
+			}
+		}
+	}
+
+	offered := make([]string, len(files))
+	for i, file := range files {
+		offered[i] = file.compression
+	}
+	return nil, fmt.Errorf("no supported NAR compression (offered: %s)", strings.Join(offered, ", "))
+}
+
+// Download installs a package's outputs and runtime closure from the Guix
+// substitute servers
+func (pm *PackageManager) Download(ctx context.Context, name string, opts *DownloadOptions) error {
+	return pm.nix.Download(ctx, name, opts)
+}
+
+// Remove deletes an installed package's outputs, or only the given ones
+func (pm *PackageManager) Remove(name string, outputs []string) error {
+	return pm.nix.Remove(name, outputs)
+}
+
+// LookupPackage finds a package by name in the index of Config.System
+func (pm *PackageManager) LookupPackage(name string) (*Package, error) {
+	return pm.nix.LookupPackage(name)
+}
+
+// Search returns the packages matching query, best matches first
+func (pm *PackageManager) Search(query string) ([]Package, error) {
+	return pm.nix.Search(query, "")
+}
+
+// System returns the Guix system used when none is requested
+func (pm *PackageManager) System() string {
+	return pm.config.System
+}
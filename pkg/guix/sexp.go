@@ -0,0 +1,225 @@
+// pkg/guix/sexp.go
+package guix
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sexp is an S-expression as used by libgcrypt and Guix: either an atom
+// (a byte string) or a list
+type sexp struct {
+	atom []byte
+	list []*sexp
+}
+
+// isList reports whether s is a list
+func (s *sexp) isList() bool {
+	return s.atom == nil
+}
+
+// head returns the first atom of a list ("public-key" in (public-key ...))
+func (s *sexp) head() string {
+	if !s.isList() || len(s.list) == 0 || s.list[0].isList() {
+		return ""
+	}
+	return string(s.list[0].atom)
+}
+
+// find returns the first list named name, searching depth first
+func (s *sexp) find(name string) *sexp {
+	if !s.isList() {
+		return nil
+	}
+	if s.head() == name {
+		return s
+	}
+	for _, child := range s.list {
+		if found := child.find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// findAll returns every list named name, outermost first
+func (s *sexp) findAll(name string) []*sexp {
+	if !s.isList() {
+		return nil
+	}
+	if s.head() == name {
+		return []*sexp{s}
+	}
+	var found []*sexp
+	for _, child := range s.list {
+		found = append(found, child.findAll(name)...)
+	}
+	return found
+}
+
+// value returns the atom following the name of the list named name:
+// value("q") of (ecc (curve Ed25519) (q #...#)) is the q bytes
+func (s *sexp) value(name string) []byte {
+	list := s.find(name)
+	if list == nil || len(list.list) < 2 || list.list[1].isList() {
+		return nil
+	}
+	return list.list[1].atom
+}
+
+// parseSexp parses one S-expression in canonical ("3:abc") or advanced
+// transport form (tokens, #hex#, "strings", |base64|), the latter being
+// what Guix embeds in narinfo signatures and ships as .pub files
+func parseSexp(data []byte) (*sexp, error) {
+	p := &sexpParser{data: data}
+	s, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos != len(p.data) {
+		return nil, fmt.Errorf("sexp: trailing data at offset %d", p.pos)
+	}
+	return s, nil
+}
+
+type sexpParser struct {
+	data []byte
+	pos  int
+}
+
+func (p *sexpParser) skipSpace() {
+	for p.pos < len(p.data) && strings.IndexByte(" \t\r\n", p.data[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *sexpParser) parse() (*sexp, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil, fmt.Errorf("sexp: unexpected end of input")
+	}
+
+	c := p.data[p.pos]
+	switch {
+	case c == '(':
+		p.pos++
+		list := &sexp{list: []*sexp{}}
+		for {
+			p.skipSpace()
+			if p.pos >= len(p.data) {
+				return nil, fmt.Errorf("sexp: unterminated list")
+			}
+			if p.data[p.pos] == ')' {
+				p.pos++
+				return list, nil
+			}
+			child, err := p.parse()
+			if err != nil {
+				return nil, err
+			}
+			list.list = append(list.list, child)
+		}
+
+	case c == '#':
+		end := strings.IndexByte(string(p.data[p.pos+1:]), '#')
+		if end < 0 {
+			return nil, fmt.Errorf("sexp: unterminated hex string")
+		}
+		digits := strings.Join(strings.Fields(string(p.data[p.pos+1:p.pos+1+end])), "")
+		p.pos += end + 2
+		raw, err := hex.DecodeString(digits)
+		if err != nil {
+			return nil, fmt.Errorf("sexp: %w", err)
+		}
+		return &sexp{atom: raw}, nil
+
+	case c == '|':
+		end := strings.IndexByte(string(p.data[p.pos+1:]), '|')
+		if end < 0 {
+			return nil, fmt.Errorf("sexp: unterminated base64 string")
+		}
+		encoded := strings.Join(strings.Fields(string(p.data[p.pos+1:p.pos+1+end])), "")
+		p.pos += end + 2
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("sexp: %w", err)
+		}
+		return &sexp{atom: raw}, nil
+
+	case c == '"':
+		return p.parseQuoted()
+
+	case c >= '0' && c <= '9':
+		// Canonical "<length>:<bytes>", or a plain token starting with a digit
+		start := p.pos
+		for p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '9' {
+			p.pos++
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == ':' {
+			n, err := strconv.Atoi(string(p.data[start:p.pos]))
+			if err != nil || p.pos+1+n > len(p.data) {
+				return nil, fmt.Errorf("sexp: bad length prefix at offset %d", start)
+			}
+			atom := p.data[p.pos+1 : p.pos+1+n]
+			p.pos += 1 + n
+			return &sexp{atom: append([]byte{}, atom...)}, nil
+		}
+		p.pos = start
+		return p.parseToken()
+
+	case c == ')':
+		return nil, fmt.Errorf("sexp: unexpected ')' at offset %d", p.pos)
+
+	default:
+		return p.parseToken()
+	}
+}
+
+// parseToken reads a bare token such as ecc, Ed25519 or sha256
+func (p *sexpParser) parseToken() (*sexp, error) {
+	start := p.pos
+	for p.pos < len(p.data) && strings.IndexByte(" \t\r\n()#|\"", p.data[p.pos]) < 0 {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, fmt.Errorf("sexp: unexpected %q at offset %d", p.data[p.pos], p.pos)
+	}
+	return &sexp{atom: append([]byte{}, p.data[start:p.pos]...)}, nil
+}
+
+// parseQuoted reads a "quoted string" with backslash escapes
+func (p *sexpParser) parseQuoted() (*sexp, error) {
+	p.pos++ // opening quote
+	var atom []byte
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return &sexp{atom: append([]byte{}, atom...)}, nil
+		case '\\':
+			if p.pos >= len(p.data) {
+				return nil, fmt.Errorf("sexp: unterminated string")
+			}
+			escaped := p.data[p.pos]
+			p.pos++
+			switch escaped {
+			case 'n':
+				atom = append(atom, '\n')
+			case 't':
+				atom = append(atom, '\t')
+			case 'r':
+				atom = append(atom, '\r')
+			default:
+				atom = append(atom, escaped)
+			}
+		default:
+			atom = append(atom, c)
+		}
+	}
+	return nil, fmt.Errorf("sexp: unterminated string")
+}
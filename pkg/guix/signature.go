@@ -0,0 +1,227 @@
+// pkg/guix/signature.go
+package guix
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PublicKey is an Ed25519 substitute signing key, as found in a Guix .pub
+// file or /etc/guix/acl entry: (public-key (ecc (curve Ed25519) (q #...#)))
+type PublicKey struct {
+	Key ed25519.PublicKey
+}
+
+// String returns the key's q in hex, as Guix prints it
+func (k *PublicKey) String() string {
+	return strings.ToUpper(hex.EncodeToString(k.Key))
+}
+
+// ParseAuthorizedKeys parses every public key in a .pub file or an acl
+func ParseAuthorizedKeys(data []byte) ([]*PublicKey, error) {
+	s, err := parseSexp(bytes.TrimSpace(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*PublicKey
+	for _, pk := range s.findAll("public-key") {
+		key, err := publicKeyFromSexp(pk)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no public-key in sexp")
+	}
+	return keys, nil
+}
+
+// publicKeyFromSexp extracts an Ed25519 key from (public-key (ecc ...))
+func publicKeyFromSexp(s *sexp) (*PublicKey, error) {
+	ecc := s.find("ecc")
+	if ecc == nil {
+		return nil, fmt.Errorf("unsupported public key: only ecc keys are used for substitutes")
+	}
+	if curve := string(ecc.value("curve")); curve != "Ed25519" {
+		return nil, fmt.Errorf("unsupported curve %q", curve)
+	}
+
+	q := ecc.value("q")
+	// libgcrypt may prefix compressed EdDSA points with 0x40
+	if len(q) == ed25519.PublicKeySize+1 && q[0] == 0x40 {
+		q = q[1:]
+	}
+	if len(q) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid Ed25519 key: %d bytes", len(q))
+	}
+	return &PublicKey{Key: ed25519.PublicKey(q)}, nil
+}
+
+// Signature is the decoded Signature line of a Guix narinfo:
+// "1;<host>;<base64 sexp>", where the sexp holds the signed hash, the
+// EdDSA signature and the signer's public key
+type Signature struct {
+	Host   string
+	Hash   []byte // sha256 of the narinfo up to its Signature line
+	Sig    []byte // r || s
+	Signer *PublicKey
+}
+
+// ParseSignature decodes a narinfo Signature value
+func ParseSignature(value string) (*Signature, error) {
+	parts := strings.SplitN(strings.TrimSpace(value), ";", 3)
+	if len(parts) != 3 || parts[0] != "1" {
+		return nil, fmt.Errorf("unsupported signature format %q", truncate(value))
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decoding signature: %w", err)
+	}
+	s, err := parseSexp(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing signature: %w", err)
+	}
+	if s.head() != "signature" {
+		return nil, fmt.Errorf("parsing signature: not a signature sexp")
+	}
+
+	data := s.find("data")
+	if data == nil {
+		return nil, fmt.Errorf("signature has no data")
+	}
+	hash := data.find("hash")
+	if hash == nil || len(hash.list) < 3 || string(hash.list[1].atom) != "sha256" {
+		return nil, fmt.Errorf("signature data is not a sha256 hash")
+	}
+
+	sigVal := s.find("sig-val")
+	if sigVal == nil || sigVal.find("eddsa") == nil {
+		return nil, fmt.Errorf("signature is not an EdDSA signature")
+	}
+	r, sv := sigVal.value("r"), sigVal.value("s")
+	if len(r) > 32 || len(sv) > 32 || len(r) == 0 || len(sv) == 0 {
+		return nil, fmt.Errorf("malformed EdDSA signature")
+	}
+
+	pk := s.find("public-key")
+	if pk == nil {
+		return nil, fmt.Errorf("signature has no public key")
+	}
+	signer, err := publicKeyFromSexp(pk)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Signature{
+		Host:   parts[1],
+		Hash:   hash.list[2].atom,
+		Sig:    append(leftPad(r, 32), leftPad(sv, 32)...),
+		Signer: signer,
+	}, nil
+}
+
+// leftPad restores the leading zero bytes libgcrypt may strip from an MPI
+func leftPad(b []byte, n int) []byte {
+	if len(b) >= n {
+		return b
+	}
+	return append(make([]byte, n-len(b)), b...)
+}
+
+// signedBody returns the part of a narinfo its signature covers: everything
+// before the Signature line
+func signedBody(content string) (string, string, error) {
+	i := strings.Index(content, "\nSignature:")
+	if i < 0 {
+		return "", "", fmt.Errorf("narinfo is not signed")
+	}
+
+	line := content[i+1:]
+	if j := strings.IndexByte(line, '\n'); j >= 0 {
+		line = line[:j]
+	}
+	return content[:i+1], strings.TrimSpace(strings.TrimPrefix(line, "Signature:")), nil
+}
+
+// VerifyNARInfo checks that a raw narinfo is signed by one of keys and
+// returns the signing host
+func VerifyNARInfo(content string, keys []*PublicKey) (string, error) {
+	body, value, err := signedBody(content)
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := ParseSignature(value)
+	if err != nil {
+		return "", err
+	}
+
+	// Guix only trusts keys in its ACL; the embedded key just names the signer
+	trusted := false
+	for _, key := range keys {
+		if key.Key.Equal(sig.Signer.Key) {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return "", fmt.Errorf("signed by unauthorized key %s (%s)", sig.Signer, sig.Host)
+	}
+
+	digest := sha256.Sum256([]byte(body))
+	if !bytes.Equal(digest[:], sig.Hash) {
+		return "", fmt.Errorf("signature by %s does not cover this narinfo", sig.Host)
+	}
+
+	// libgcrypt's EdDSA signs the hash value itself as the message
+	if !ed25519.Verify(sig.Signer.Key, sig.Hash, sig.Sig) {
+		return "", fmt.Errorf("bad signature by %s", sig.Host)
+	}
+	return sig.Host, nil
+}
+
+// authorizedKeys parses Config.AuthorizedKeys once. Entries starting with
+// "(" are sexps; anything else is read as a .pub or acl file.
+func (pm *PackageManager) authorizedKeys() ([]*PublicKey, error) {
+	if pm.keys != nil {
+		return pm.keys, nil
+	}
+
+	var keys []*PublicKey
+	for _, entry := range pm.config.AuthorizedKeys {
+		data := []byte(entry)
+		if !strings.HasPrefix(strings.TrimSpace(entry), "(") {
+			content, err := os.ReadFile(entry)
+			if err != nil {
+				return nil, fmt.Errorf("reading authorized key: %w", err)
+			}
+			data = content
+		}
+
+		parsed, err := ParseAuthorizedKeys(data)
+		if err != nil {
+			return nil, fmt.Errorf("authorized key %s: %w", truncate(entry), err)
+		}
+		keys = append(keys, parsed...)
+	}
+
+	pm.keys = keys
+	return keys, nil
+}
+
+// truncate shortens long values for error messages
+func truncate(s string) string {
+	if len(s) > 60 {
+		return s[:57] + "..."
+	}
+	return s
+}
@@ -0,0 +1,41 @@
+// pkg/guix/types.go
+package guix
+
+import (
+	"log"
+	"time"
+
+	"github.com/arc-language/upkg/pkg/nix"
+)
+
+// Config configures the Guix package manager
+type Config struct {
+	SubstituteURLs []string      // Substitute servers, tried in order (default: bordeaux and ci.guix.gnu.org)
+	AuthorizedKeys []string      // Public-key sexps or paths to .pub/acl files (default: the official build farms)
+	AllowUnsigned  bool          // Skip narinfo signature checks
+	System         string        // Index used when a download names none (default: host, e.g. x86_64-linux)
+	InstallPath    string        // Where to install store paths
+	CachePath      string        // Location of local cache/index files
+	Timeout        time.Duration // Network timeout
+	Debug          bool          // Enable debug logging
+	Logger         *log.Logger   // Custom logger (optional)
+}
+
+// PackageManager handles Guix package operations. Guix substitutes use the
+// narinfo and NAR formats of Nix, so index lookups, closure walking, NAR
+// download, verification and extraction are shared with the nix package;
+// only narinfo signatures (canonical sexps signed by an authorized key) and
+// the store directory differ.
+type PackageManager struct {
+	nix    *nix.PackageManager
+	client *nix.Client
+	config *Config
+	logger *log.Logger
+	keys   []*PublicKey // Parsed AuthorizedKeys, loaded on first use
+}
+
+// Package is an entry of the Guix index; it uses the nix index format
+type Package = nix.Package
+
+// DownloadOptions configures package download and extraction
+type DownloadOptions = nix.DownloadOptions
@@ -1,11 +1,13 @@
 package index
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 
+	"github.com/arc-language/upkg/pkg/brew"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 )
@@ -15,8 +17,10 @@ const (
 	RepoBranch = "main"
 )
 
-// Sync clones the repo and copies the 3 things we need into the cache
-func Sync(cacheDir string) error {
+// Sync clones the repo and copies the indexes and registry we need into the
+// cache, and refreshes the Homebrew formula index from brewAPIURL (default:
+// brew.DefaultAPIURL) if brew has fetched one
+func Sync(cacheDir, brewAPIURL string) error {
 	tempDir, err := os.MkdirTemp("", "upkg-clone-*")
 	if err != nil {
 		return fmt.Errorf("creating temp dir: %w", err)
@@ -39,12 +43,15 @@ func Sync(cacheDir string) error {
 	indexDir := filepath.Join(cacheDir, "index")
 	os.MkdirAll(indexDir, 0755)
 
-	// 1. Nix index
-	if err := copyFile(
-		filepath.Join(tempDir, "packages", "nix", "x86_64_linux.json"),
-		filepath.Join(indexDir, "nix_x86_64_linux.json"),
-	); err != nil {
-		fmt.Printf("Warning: nix index: %v\n", err)
+	// 1. Nix and Guix indexes, one per platform (x86_64_linux.json, aarch64_darwin.json, ...)
+	for _, name := range []string{"nix", "guix"} {
+		if err := copyPlatformIndexes(
+			filepath.Join(tempDir, "packages", name),
+			indexDir,
+			name,
+		); err != nil {
+			fmt.Printf("Warning: %s index: %v\n", name, err)
+		}
 	}
 
 	// 2. Winget index
@@ -55,7 +62,19 @@ func Sync(cacheDir string) error {
 		fmt.Printf("Warning: winget index: %v\n", err)
 	}
 
-	// 3. deps/ registry
+	// 3. Homebrew bulk formula index. The brew backend fetches it on first
+	// use; a sync only refreshes a copy that is already there.
+	if brewAPIURL == "" {
+		brewAPIURL = brew.DefaultAPIURL
+	}
+	brewIndex := filepath.Join(indexDir, brew.IndexFile)
+	if _, err := os.Stat(brewIndex); err == nil {
+		if err := brew.FetchIndex(context.Background(), brewAPIURL, brewIndex); err != nil {
+			fmt.Printf("Warning: brew index: %v\n", err)
+		}
+	}
+
+	// 4. deps/ registry
 	if err := copyDir(
 		filepath.Join(tempDir, "deps"),
 		filepath.Join(cacheDir, "deps"),
@@ -67,6 +86,24 @@ func Sync(cacheDir string) error {
 	return nil
 }
 
+// copyPlatformIndexes copies every platform index in src to dst as <name>_<platform>.json
+func copyPlatformIndexes(src, dst, name string) error {
+	matches, err := filepath.Glob(filepath.Join(src, "*.json"))
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no platform indexes in %s", src)
+	}
+
+	for _, match := range matches {
+		if err := copyFile(match, filepath.Join(dst, name+"_"+filepath.Base(match))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func copyFile(src, dst string) error {
 	in, err := os.Open(src)
 	if err != nil {
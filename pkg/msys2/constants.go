@@ -0,0 +1,36 @@
+// pkg/msys2/constants.go
+package msys2
+
+const (
+	// DefaultMirror is the MSYS2 mirror redirector
+	DefaultMirror = "https://mirror.msys2.org"
+
+	// DefaultEnvironment is the environment MSYS2 itself recommends
+	DefaultEnvironment = EnvUCRT64
+
+	// DefaultInstallPath is where packages will be extracted
+	DefaultInstallPath = "/opt/upkg"
+
+	// KeyringName is the MSYS2 keyring (msys2.gpg, msys2-keyring)
+	KeyringName = "msys2"
+
+	// KeyringRepo is the MSYS runtime repository that ships msys2-keyring
+	KeyringRepo = "msys"
+)
+
+// MSYS2 environments, named after their install prefix and repository
+const (
+	EnvMingw64    = "mingw64"    // GCC, msvcrt, x86_64
+	EnvUCRT64     = "ucrt64"     // GCC, UCRT, x86_64
+	EnvClang64    = "clang64"    // LLVM, UCRT, x86_64
+	EnvClangArm64 = "clangarm64" // LLVM, UCRT, aarch64
+)
+
+// Environments maps each supported environment to its package prefix and
+// target architecture
+var Environments = map[string]Environment{
+	EnvMingw64:    {Name: EnvMingw64, PackagePrefix: "mingw-w64-x86_64-", Architecture: "x86_64"},
+	EnvUCRT64:     {Name: EnvUCRT64, PackagePrefix: "mingw-w64-ucrt-x86_64-", Architecture: "x86_64"},
+	EnvClang64:    {Name: EnvClang64, PackagePrefix: "mingw-w64-clang-x86_64-", Architecture: "x86_64"},
+	EnvClangArm64: {Name: EnvClangArm64, PackagePrefix: "mingw-w64-clang-aarch64-", Architecture: "aarch64"},
+}
@@ -0,0 +1,144 @@
+// pkg/msys2/manager.go
+package msys2
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arc-language/upkg/pkg/pacman"
+)
+
+// NewPackageManager creates a new MSYS2 package manager
+func NewPackageManager(cfg *Config) (*PackageManager, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	// Set defaults
+	if cfg.Environment == "" {
+		cfg.Environment = DefaultEnvironment
+	}
+	if cfg.MirrorURL == "" {
+		cfg.MirrorURL = DefaultMirror
+	}
+	if cfg.InstallPath == "" {
+		cfg.InstallPath = DefaultInstallPath
+	}
+	if cfg.CachePath == "" {
+		homeDir, _ := os.UserHomeDir()
+		cfg.CachePath = filepath.Join(homeDir, ".cache", "upkg", "msys2")
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 2 * time.Minute
+	}
+
+	env, err := LookupEnvironment(cfg.Environment)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Environment = env.Name
+
+	// Setup logger
+	logger := cfg.Logger
+	if logger == nil {
+		if cfg.Debug {
+			logger = log.New(os.Stdout, "[MSYS2] ", log.LstdFlags)
+		} else {
+			logger = log.New(io.Discard, "", 0)
+		}
+	}
+
+	// e.g. https://mirror.msys2.org/mingw/ucrt64/ucrt64.db; the keyring
+	// package lives in the MSYS runtime repository instead, and is checked
+	// against the embedded msys2-trusted master key list like Arch's
+	mirror := strings.TrimSuffix(cfg.MirrorURL, "/")
+
+	pm := &PackageManager{
+		pacman: pacman.NewPackageManager(&pacman.Config{
+			MirrorURL:    mirror + "/mingw/$repo",
+			Architecture: env.Architecture,
+			Repos:        []string{env.Name},
+			KeyringName:  KeyringName,
+			KeyringDB:    fmt.Sprintf("%s/%s/x86_64/%s.db", mirror, KeyringRepo, KeyringRepo),
+			GPGKeys:      cfg.GPGKeys,
+			NoGPGCheck:   cfg.NoGPGCheck,
+			InstallPath:  cfg.InstallPath,
+			CachePath:    cfg.CachePath,
+			Timeout:      cfg.Timeout,
+			Debug:        cfg.Debug,
+			Logger:       logger,
+		}),
+		env:    env,
+		config: cfg,
+		logger: logger,
+	}
+
+	if cfg.Debug {
+		pm.logger.Printf("Initialized MSYS2 PackageManager")
+		pm.logger.Printf("  Environment: /%s (%s)", env.Name, env.Architecture)
+		pm.logger.Printf("  Mirror: %s", mirror)
+	}
+
+	return pm, nil
+}
+
+// LookupEnvironment returns a supported environment by name ("ucrt64" or "/ucrt64")
+func LookupEnvironment(name string) (Environment, error) {
+	name = strings.ToLower(strings.Trim(strings.TrimSpace(name), "/"))
+	env, ok := Environments[name]
+	if !ok {
+		names := make([]string, 0, len(Environments))
+		for n := range Environments {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Environment{}, fmt.Errorf("unsupported MSYS2 environment %q (supported: %s)", name, strings.Join(names, ", "))
+	}
+	return env, nil
+}
+
+// PackageName returns the full package name of a request in this
+// environment: "openssl" -> "mingw-w64-ucrt-x86_64-openssl". Names that
+// already carry the prefix are returned unchanged.
+func (pm *PackageManager) PackageName(name string) string {
+	if strings.HasPrefix(name, pm.env.PackagePrefix) {
+		return name
+	}
+	return pm.env.PackagePrefix + name
+}
+
+// ShortName strips the environment's package prefix from a package name
+func (pm *PackageManager) ShortName(name string) string {
+	return strings.TrimPrefix(name, pm.env.PackagePrefix)
+}
+
+// Environment returns the targeted environment
+func (pm *PackageManager) Environment() Environment {
+	return pm.env
+}
+
+// Download downloads and installs a package and its dependencies under
+// <InstallPath>/<environment>
+func (pm *PackageManager) Download(ctx context.Context, opts *DownloadOptions) error {
+	o := *opts
+	o.Package = pm.PackageName(opts.Package)
+	o.Architecture = pm.env.Architecture
+	return pm.pacman.Download(ctx, &o)
+}
+
+// GetPackageInfo retrieves information about a package
+func (pm *PackageManager) GetPackageInfo(ctx context.Context, name string) (*PackageInfo, error) {
+	return pm.pacman.GetPackageInfo(ctx, pm.PackageName(name))
+}
+
+// SearchPackages searches the environment's packages by name
+func (pm *PackageManager) SearchPackages(ctx context.Context, query string) ([]*PackageInfo, error) {
+	return pm.pacman.SearchPackages(ctx, pm.ShortName(query))
+}
@@ -0,0 +1,47 @@
+// pkg/msys2/types.go
+package msys2
+
+import (
+	"log"
+	"time"
+
+	"github.com/arc-language/upkg/pkg/pacman"
+)
+
+// Config configures the MSYS2 package manager
+type Config struct {
+	Environment string        // Environment to target: mingw64, ucrt64, clang64, clangarm64 (a leading "/" is accepted)
+	MirrorURL   string        // Mirror base URL holding mingw/ and msys/ (default: mirror.msys2.org)
+	GPGKeys     []string      // Extra trusted keys (paths or URLs), added to the msys2 keyring
+	NoGPGCheck  bool          // Skip database and package signature checks
+	InstallPath string        // Where to install packages; files land under <InstallPath>/<environment>
+	CachePath   string        // Where to cache downloaded files
+	Timeout     time.Duration // Network timeout
+	Debug       bool          // Enable debug logging
+	Logger      *log.Logger   // Custom logger (optional)
+}
+
+// Environment describes one MSYS2 environment: its repository (and install
+// prefix) name, the prefix of its package names and its target architecture
+type Environment struct {
+	Name          string
+	PackagePrefix string
+	Architecture  string
+}
+
+// PackageManager handles MSYS2 package operations. MSYS2 repositories use
+// the pacman database and package formats, so syncing, dependency
+// resolution, signature checks and extraction are shared with the pacman
+// package; only the repository layout and package naming differ.
+type PackageManager struct {
+	pacman *pacman.PackageManager
+	env    Environment
+	config *Config
+	logger *log.Logger
+}
+
+// PackageInfo contains metadata from the sync database
+type PackageInfo = pacman.PackageInfo
+
+// DownloadOptions configures package download
+type DownloadOptions = pacman.DownloadOptions
@@ -0,0 +1,147 @@
+// pkg/nix/closure.go
+package nix
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Closure is the runtime closure of a set of store paths: the paths
+// themselves plus everything their narinfo References reach
+type Closure struct {
+	Paths    []*NARInfo // Every store path, roots first, deduplicated by store hash
+	Missing  []*NARInfo // Paths not yet present locally, in download order
+	FileSize int64      // Compressed download size of the missing paths
+	NarSize  int64      // Unpacked size of the missing paths
+}
+
+// storeHash returns the hash part of a store path or store path basename:
+// "/nix/store/abc...-glibc-2.39" -> "abc..."
+func storeHash(storePath string) string {
+	hash, _, _ := strings.Cut(path.Base(storePath), "-")
+	return hash
+}
+
+// ResolveClosure walks the References of the given store hashes breadth
+// first and returns their closure. present reports whether a store path is
+// already installed; it may be nil.
+func (pm *PackageManager) ResolveClosure(ctx context.Context, roots []string, present func(*NARInfo) bool) (*Closure, error) {
+	return pm.resolveClosure(ctx, roots, present, true)
+}
+
+// resolveClosure fetches the narinfo of each root and, when follow is set,
+// of everything they reference
+func (pm *PackageManager) resolveClosure(ctx context.Context, roots []string, present func(*NARInfo) bool, follow bool) (*Closure, error) {
+	closure := &Closure{}
+	seen := make(map[string]bool)
+
+	queue := append([]string(nil), roots...)
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+
+		info, err := pm.GetNARInfo(ctx, hash)
+		if err != nil {
+			return nil, fmt.Errorf("getting narinfo for %s: %w", hash, err)
+		}
+		closure.Paths = append(closure.Paths, info)
+
+		if present == nil || !present(info) {
+			closure.Missing = append(closure.Missing, info)
+			closure.FileSize += info.FileSize
+			closure.NarSize += info.NarSize
+		}
+
+		if !follow {
+			continue
+		}
+
+		// References are basenames ("hash-name") and include the path itself
+		for _, ref := range info.References {
+			if refHash := storeHash(ref); !seen[refHash] {
+				queue = append(queue, refHash)
+			}
+		}
+	}
+
+	return closure, nil
+}
+
+// referencePath returns where a store path pulled in as a dependency is
+// installed: <InstallPath>/<hash-name>, mirroring the store layout
+func (pm *PackageManager) referencePath(info *NARInfo) string {
+	return filepath.Join(pm.config.InstallPath, path.Base(info.StorePath))
+}
+
+// linkOutput points <InstallPath>/<hash-name> at an output installed under
+// <InstallPath>/<NameVersion>/<output>, so the output counts as present for
+// packages that reference it and relocation can map its store path. A copy
+// already installed there as a dependency is left in place.
+func (pm *PackageManager) linkOutput(info *NARInfo, dir string) error {
+	link := pm.referencePath(info)
+	if fi, err := os.Lstat(link); err == nil {
+		if fi.Mode()&os.ModeSymlink == 0 {
+			return nil
+		}
+		if err := os.Remove(link); err != nil {
+			return err
+		}
+	}
+
+	target, err := filepath.Rel(pm.config.InstallPath, dir)
+	if err != nil {
+		target = dir
+	}
+	return os.Symlink(target, link)
+}
+
+// pruneOutputLinks removes the output links whose output has been removed
+func (pm *PackageManager) pruneOutputLinks() {
+	entries, err := os.ReadDir(pm.config.InstallPath)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.Type()&os.ModeSymlink == 0 {
+			continue
+		}
+		link := filepath.Join(pm.config.InstallPath, entry.Name())
+		if _, err := os.Stat(link); os.IsNotExist(err) {
+			pm.logger.Printf("Removing %s", link)
+			os.Remove(link)
+		}
+	}
+}
+
+// isPresent reports whether a dependency store path is already available,
+// either under InstallPath or in the host's own store (Config.StoreDir)
+func (pm *PackageManager) isPresent(info *NARInfo) bool {
+	for _, dir := range []string{pm.referencePath(info), filepath.Join(pm.config.StoreDir, path.Base(info.StorePath))} {
+		if _, err := os.Lstat(dir); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// formatSize renders a byte count for progress messages
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
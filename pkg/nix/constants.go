@@ -5,12 +5,18 @@ const (
 	// DefaultCacheURL is the official Nix binary cache
 	DefaultCacheURL = "https://cache.nixos.org"
 
+	// DefaultTrustedPublicKey is the signing key of cache.nixos.org
+	DefaultTrustedPublicKey = "cache.nixos.org-1:6NCHdD59X431o0gWypbMrAURkbJ16ZPMQFGspcDShjY="
+
 	// DefaultSearchURL is the package search endpoint
 	DefaultSearchURL = "https://search.nixos.org"
 
 	// DefaultInstallPath is where Nix typically stores packages
 	DefaultInstallPath = "/nix/store"
 
+	// DefaultIndexName prefixes the cached index files (nix_x86_64_linux.json)
+	DefaultIndexName = "nix"
+
 	// DefaultLocalCache is where Nix caches downloaded files
 	DefaultLocalCache = "~/.cache/nix"
 
@@ -20,6 +26,12 @@ const (
 	// CompressionBZip2 uses bzip2 compression
 	CompressionBZip2 = "bzip2"
 
+	// CompressionZstd uses zstd compression
+	CompressionZstd = "zstd"
+
+	// CompressionGzip uses gzip compression (Guix substitutes)
+	CompressionGzip = "gzip"
+
 	// CompressionNone uses no compression
 	CompressionNone = "none"
 )
@@ -0,0 +1,120 @@
+// pkg/nix/index.go
+package nix
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/arc-language/upkg/pkg/search"
+)
+
+// StringList is a JSON list of strings that also accepts a single string,
+// as nixpkgs meta fields hold either
+type StringList []string
+
+// UnmarshalJSON decodes a string or a list of strings
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		if single == "" {
+			*l = nil
+		} else {
+			*l = StringList{single}
+		}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// normalize fills the fields older index files lack from the ones they have
+func (p *Package) normalize(attribute string) {
+	if p.Attribute == "" {
+		p.Attribute = attribute
+	}
+	if p.PName == "" || p.Version == "" {
+		pname, version := ParseName(p.NameVersion)
+		if p.PName == "" {
+			p.PName = pname
+		}
+		if p.Version == "" {
+			p.Version = version
+		}
+	}
+	if len(p.Outputs) == 0 {
+		for output := range parseStorePath(p.StorePath) {
+			p.Outputs = append(p.Outputs, output)
+		}
+		sort.Slice(p.Outputs, func(i, j int) bool {
+			if (p.Outputs[i] == "out") != (p.Outputs[j] == "out") {
+				return p.Outputs[i] == "out"
+			}
+			return p.Outputs[i] < p.Outputs[j]
+		})
+	}
+}
+
+// ParseName splits a derivation name into name and version the way
+// builtins.parseDrvName does: the version starts after the first dash
+// that is not followed by a letter ("python3-3.12.4" -> "python3", "3.12.4")
+func ParseName(nameVersion string) (string, string) {
+	for i := 0; i+1 < len(nameVersion); i++ {
+		if nameVersion[i] != '-' {
+			continue
+		}
+		c := nameVersion[i+1]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return nameVersion[:i], nameVersion[i+1:]
+		}
+	}
+	return nameVersion, ""
+}
+
+// Search returns the packages of a platform's index matching query on
+// their attribute, name or description, ranked by search.Score; among
+// equal scores top-level attributes come first
+func (pm *PackageManager) Search(query string, platform Platform) ([]Package, error) {
+	index, err := pm.platformIndex(platform)
+	if err != nil {
+		return nil, err
+	}
+
+	words := search.Words(query)
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	type match struct {
+		pkg   Package
+		score int
+	}
+	var matches []match
+	for _, pkg := range index {
+		if score, ok := search.Score(words, []string{pkg.Attribute, pkg.PName}, pkg.Description); ok {
+			matches = append(matches, match{pkg, score})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.score != b.score {
+			return a.score < b.score
+		}
+		// Prefer top-level attributes over nested package sets
+		if len(a.pkg.Attribute) != len(b.pkg.Attribute) {
+			return len(a.pkg.Attribute) < len(b.pkg.Attribute)
+		}
+		return a.pkg.Attribute < b.pkg.Attribute
+	})
+
+	results := make([]Package, len(matches))
+	for i, m := range matches {
+		results[i] = m.pkg
+	}
+	return results, nil
+}
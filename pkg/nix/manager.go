@@ -2,29 +2,29 @@
 package nix
 
 import (
-	"bufio"
-	"compress/bzip2"
 	"context"
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
-
-	"github.com/ulikunitz/xz"
-	"zombiezen.com/go/nix/nar"
 )
 
 // PackageManager handles Nix package operations
 type PackageManager struct {
-	client *Client
-	config *Config
-	logger *log.Logger
-	index  map[string]Package // In-memory package index
+	client  *Client
+	config  *Config
+	logger  *log.Logger
+	index   map[string]Package              // In-memory package index for Config.Platform
+	indexes map[Platform]map[string]Package // Indexes of other platforms, loaded on demand
+	ordered []*Substituter                  // Substituters by priority, resolved on first use
+	keys    []*PublicKey                    // Parsed trusted-public-keys, loaded on first use
+	subKeys map[string][]*PublicKey         // Per-substituter keys, by substituter URL
 }
 
 // NewPackageManager creates a new Nix package manager
@@ -37,9 +37,28 @@ func NewPackageManager(cfg *Config) *PackageManager {
 	if cfg.CacheURL == "" {
 		cfg.CacheURL = DefaultCacheURL
 	}
+	if len(cfg.Substituters) == 0 {
+		cfg.Substituters = []Substituter{{URL: cfg.CacheURL}}
+	}
+	if cfg.Platform == "" {
+		platform, err := DetectPlatform()
+		if err != nil {
+			platform = PlatformX8664Linux
+		}
+		cfg.Platform = platform
+	}
+	if len(cfg.TrustedPublicKeys) == 0 {
+		cfg.TrustedPublicKeys = []string{DefaultTrustedPublicKey}
+	}
 	if cfg.InstallPath == "" {
 		cfg.InstallPath = DefaultInstallPath
 	}
+	if cfg.StoreDir == "" {
+		cfg.StoreDir = DefaultInstallPath
+	}
+	if cfg.IndexName == "" {
+		cfg.IndexName = DefaultIndexName
+	}
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 30 * time.Second
 	}
@@ -53,51 +72,100 @@ func NewPackageManager(cfg *Config) *PackageManager {
 			logger = log.New(io.Discard, "", 0)
 		}
 	}
+	if cfg.Progress == nil {
+		cfg.Progress = io.Discard
+	}
 
 	pm := &PackageManager{
-		client: NewClientWithTimeout(cfg.Timeout),
-		config: cfg,
-		logger: logger,
-		index:  make(map[string]Package),
+		client:  NewClientWithTimeout(cfg.Timeout),
+		config:  cfg,
+		logger:  logger,
+		index:   make(map[string]Package),
+		indexes: make(map[Platform]map[string]Package),
+		subKeys: make(map[string][]*PublicKey),
 	}
 
 	if cfg.Debug {
 		pm.logger.Printf("Initialized PackageManager")
-		pm.logger.Printf("  CacheURL: %s", cfg.CacheURL)
+		if cfg.Fetcher == nil {
+			for _, sub := range cfg.Substituters {
+				pm.logger.Printf("  Substituter: %s", sub.URL)
+			}
+		}
+		pm.logger.Printf("  Platform: %s", cfg.Platform)
 		pm.logger.Printf("  InstallPath: %s", cfg.InstallPath)
 		pm.logger.Printf("  CachePath: %s", cfg.CachePath)
 		pm.logger.Printf("  Timeout: %s", cfg.Timeout)
 	}
 
 	// Load the index from the cache file
-	if err := pm.loadIndex(); err != nil {
+	if index, err := pm.loadIndex(cfg.Platform); err != nil {
 		pm.logger.Printf("Warning: Failed to load Nix index: %v", err)
+	} else {
+		pm.index = index
 	}
+	pm.indexes[cfg.Platform] = pm.index
 
 	return pm
 }
 
-// loadIndex loads the JSON index file from the cache directory
-func (pm *PackageManager) loadIndex() error {
-	// The index is expected to be at: {CachePath}/index/nix_x86_64_linux.json
-	indexPath := filepath.Join(pm.config.CachePath, "index", "nix_x86_64_linux.json")
-	
+// IndexFile returns the name of a platform's index file in the index cache:
+// "nix_x86_64_linux.json" for x86_64-linux
+func IndexFile(platform Platform) string {
+	return indexFile(DefaultIndexName, platform)
+}
+
+// indexFile names the index of a platform for an index prefix
+func indexFile(name string, platform Platform) string {
+	return name + "_" + strings.ReplaceAll(string(platform), "-", "_") + ".json"
+}
+
+// loadIndex loads a platform's JSON index file from the cache directory
+func (pm *PackageManager) loadIndex(platform Platform) (map[string]Package, error) {
+	// The index is expected to be at: {CachePath}/index/{IndexName}_<arch>_<os>.json
+	indexPath := filepath.Join(pm.config.CachePath, "index", indexFile(pm.config.IndexName, platform))
+
 	if _, err := os.Stat(indexPath); os.IsNotExist(err) {
-		return fmt.Errorf("index file does not exist at %s (try running with -backend=auto first)", indexPath)
+		return nil, fmt.Errorf("no %s index at %s (try running with -backend=auto first)", platform, indexPath)
 	}
 
 	f, err := os.Open(indexPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer f.Close()
 
-	if err := json.NewDecoder(f).Decode(&pm.index); err != nil {
-		return fmt.Errorf("parsing index json: %w", err)
+	index := make(map[string]Package)
+	if err := json.NewDecoder(f).Decode(&index); err != nil {
+		return nil, fmt.Errorf("parsing index json: %w", err)
 	}
-	
-	pm.logger.Printf("Loaded %d packages from index", len(pm.index))
-	return nil
+	for attribute, pkg := range index {
+		pkg.normalize(attribute)
+		index[attribute] = pkg
+	}
+
+	pm.logger.Printf("Loaded %d %s packages from index", len(index), platform)
+	return index, nil
+}
+
+// platformIndex returns a platform's index, loading it on first use
+func (pm *PackageManager) platformIndex(platform Platform) (map[string]Package, error) {
+	if platform == "" {
+		platform = pm.config.Platform
+	}
+	if index, ok := pm.indexes[platform]; ok {
+		return index, nil
+	}
+	if strings.ContainsAny(string(platform), `/\`) {
+		return nil, fmt.Errorf("invalid platform %q", platform)
+	}
+
+	index, err := pm.loadIndex(platform)
+	if err != nil {
+		return nil, err
+	}
+	pm.indexes[platform] = index
+	return index, nil
 }
 
 // parseStorePath parses a StorePath field into a map of outputs
@@ -119,14 +187,14 @@ func parseStorePath(storePath string) map[string]string {
 			fullPath := kv[1]
 			
 			// Extract hash from "/nix/store/hash-name-version"
-			pathWithoutPrefix := strings.TrimPrefix(fullPath, "/nix/store/")
+			pathWithoutPrefix := path.Base(fullPath)
 			hashParts := strings.SplitN(pathWithoutPrefix, "-", 2)
 			if len(hashParts) > 0 {
 				outputs[outputName] = hashParts[0]
 			}
 		} else {
 			// Default output: "/nix/store/hash-name"
-			pathWithoutPrefix := strings.TrimPrefix(part, "/nix/store/")
+			pathWithoutPrefix := path.Base(part)
 			hashParts := strings.SplitN(pathWithoutPrefix, "-", 2)
 			if len(hashParts) > 0 {
 				outputs["out"] = hashParts[0]
@@ -139,11 +207,20 @@ func parseStorePath(storePath string) map[string]string {
 
 // LookupPackage finds a package by attribute name in the loaded index
 func (pm *PackageManager) LookupPackage(attribute string) (*Package, error) {
-	if len(pm.index) == 0 {
+	return pm.LookupPlatformPackage(attribute, pm.config.Platform)
+}
+
+// LookupPlatformPackage finds a package by attribute name in a platform's index
+func (pm *PackageManager) LookupPlatformPackage(attribute string, platform Platform) (*Package, error) {
+	index, err := pm.platformIndex(platform)
+	if err != nil {
+		return nil, err
+	}
+	if len(index) == 0 {
 		return nil, fmt.Errorf("package index is empty or not loaded")
 	}
 
-	pkg, ok := pm.index[attribute]
+	pkg, ok := index[attribute]
 	if !ok {
 		return nil, fmt.Errorf("package '%s' not found in registry", attribute)
 	}
@@ -155,6 +232,16 @@ func (pm *PackageManager) GetPackageRegistry() map[string]Package {
 	return pm.index
 }
 
+// PlatformRegistry exposes a platform's index for search operations
+func (pm *PackageManager) PlatformRegistry(platform Platform) (map[string]Package, error) {
+	return pm.platformIndex(platform)
+}
+
+// Platform returns the platform used when none is requested
+func (pm *PackageManager) Platform() Platform {
+	return pm.config.Platform
+}
+
 // Download downloads and installs a Nix package by attribute name
 func (pm *PackageManager) Download(ctx context.Context, attribute string, opts *DownloadOptions) error {
 	pm.logger.Printf("Starting download request for: %s", attribute)
@@ -172,8 +259,8 @@ func (pm *PackageManager) Download(ctx context.Context, attribute string, opts *
 	}
 	opts.VerifyHash = true
 
-	// 1. Lookup package in registry
-	pkg, err := pm.LookupPackage(attribute)
+	// 1. Lookup package in the platform's registry
+	pkg, err := pm.LookupPlatformPackage(attribute, opts.Platform)
 	if err != nil {
 		return fmt.Errorf("looking up package: %w", err)
 	}
@@ -205,48 +292,48 @@ func (pm *PackageManager) Download(ctx context.Context, attribute string, opts *
 
 	pm.logger.Printf("Downloading %d output(s): %v", len(outputsToDownload), getKeys(outputsToDownload))
 
-	// 4. Create base directory
+	// 4. Resolve the runtime closure. Requested outputs go to
+	// baseDir/<output>/ and are always (re)installed; the store paths they
+	// reference go to InstallPath/<hash-name>/ unless already present.
 	baseDir := filepath.Join(pm.config.InstallPath, pkg.NameVersion)
-
-	// 5. Download each output into its own subdirectory
+	outputDirs := make(map[string]string) // store hash -> output directory
+	roots := make([]string, 0, len(outputsToDownload))
 	for outputName, storeHash := range outputsToDownload {
-		pm.logger.Printf("--- Processing output: %s (%s) ---", outputName, storeHash)
+		outputDirs[storeHash] = filepath.Join(baseDir, outputName)
+		roots = append(roots, storeHash)
+	}
+	sort.Strings(roots)
 
-		// A. Get Metadata
-		narInfo, err := pm.GetNARInfo(ctx, storeHash)
-		if err != nil {
-			return fmt.Errorf("getting narinfo for %s: %w", outputName, err)
+	present := func(info *NARInfo) bool {
+		if _, ok := outputDirs[storeHash(info.StorePath)]; ok {
+			return false
 		}
+		return pm.isPresent(info)
+	}
+
+	closure, err := pm.resolveClosure(ctx, roots, present, !opts.OutputsOnly)
+	if err != nil {
+		return err
+	}
 
-		// B. Determine archive path
-		archiveName := fmt.Sprintf("%s-%s.nar.%s", pkg.NameVersion, outputName, narInfo.Compression)
-		narPath := filepath.Join(pm.config.InstallPath, archiveName)
+	// The closure size is reported even without Debug, before anything is fetched
+	fmt.Fprintf(pm.config.Progress, "%s: %d store path(s), %d to fetch (%s download, %s unpacked)\n",
+		pkg.NameVersion, len(closure.Paths), len(closure.Missing), formatSize(closure.FileSize), formatSize(closure.NarSize))
 
-		// C. Download
-		if err := pm.downloadNAR(ctx, narInfo, narPath); err != nil {
-			return fmt.Errorf("downloading %s: %w", outputName, err)
+	// 5. Download each missing store path
+	for _, narInfo := range closure.Missing {
+		dest, ok := outputDirs[storeHash(narInfo.StorePath)]
+		if !ok {
+			dest = pm.referencePath(narInfo)
 		}
+		pm.logger.Printf("--- Processing %s ---", path.Base(narInfo.StorePath))
 
-		// D. Verify
-		if opts.VerifyHash {
-			if err := pm.verifyFileHash(narPath, narInfo.FileHash); err != nil {
-				return fmt.Errorf("hash verification failed for %s: %w", outputName, err)
-			}
+		if err := pm.installStorePath(ctx, narInfo, dest, opts); err != nil {
+			return fmt.Errorf("installing %s: %w", path.Base(narInfo.StorePath), err)
 		}
-
-		// E. Extract into output-specific subdirectory
-		if opts.Extract {
-			// Extract each output to its own directory: baseDir/outputName/
-			outputDir := filepath.Join(baseDir, outputName)
-			pm.logger.Printf("Extracting %s to: %s", outputName, outputDir)
-			
-			if err := pm.extractNAR(narPath, outputDir, narInfo.Compression); err != nil {
-				return fmt.Errorf("extracting %s: %w", outputName, err)
-			}
-
-			// F. Cleanup
-			if !opts.KeepArchive {
-				os.Remove(narPath)
+		if ok && opts.Extract {
+			if err := pm.linkOutput(narInfo, dest); err != nil {
+				return fmt.Errorf("linking %s: %w", path.Base(narInfo.StorePath), err)
 			}
 		}
 	}
@@ -256,239 +343,48 @@ func (pm *PackageManager) Download(ctx context.Context, attribute string, opts *
 	for outputName := range outputsToDownload {
 		pm.logger.Printf("    - %s/%s/", baseDir, outputName)
 	}
-	
-	return nil
-}
-
-// GetNARInfo retrieves metadata for a store path
-func (pm *PackageManager) GetNARInfo(ctx context.Context, storeHash string) (*NARInfo, error) {
-	url := fmt.Sprintf("%s/%s.narinfo", pm.config.CacheURL, storeHash)
-	pm.logger.Printf("Fetching NAR info from: %s", url)
-
-	content, err := pm.client.GetString(ctx, url)
-	if err != nil {
-		pm.logger.Printf("✗ Failed to fetch NAR info: %v", err)
-		return nil, err
-	}
-
-	narInfo, err := parseNARInfo(content)
-	if err != nil {
-		pm.logger.Printf("✗ Failed to parse NAR info: %v", err)
-		return nil, err
-	}
-
-	return narInfo, nil
-}
-
-// downloadNAR downloads the NAR archive
-func (pm *PackageManager) downloadNAR(ctx context.Context, narInfo *NARInfo, destPath string) error {
-	url := fmt.Sprintf("%s/%s", pm.config.CacheURL, narInfo.URL)
-	pm.logger.Printf("Downloading NAR from: %s", url)
-
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return fmt.Errorf("creating directory: %w", err)
-	}
-
-	// Create output file
-	f, err := os.Create(destPath)
-	if err != nil {
-		return fmt.Errorf("creating file: %w", err)
-	}
-	defer f.Close()
-
-	// Download
-	if err := pm.client.Download(ctx, url, f); err != nil {
-		pm.logger.Printf("✗ Failed to download NAR: %v", err)
-		return fmt.Errorf("downloading: %w", err)
+	if deps := len(closure.Paths) - len(outputsToDownload); deps > 0 {
+		pm.logger.Printf("  Runtime dependencies: %d store path(s) under %s/", deps, pm.config.InstallPath)
 	}
 
-	pm.logger.Printf("✓ Downloaded %d bytes to %s", narInfo.FileSize, destPath)
 	return nil
 }
 
-// verifyFileHash verifies the SHA256 hash of a downloaded file
-func (pm *PackageManager) verifyFileHash(filePath, expectedHash string) error {
-	pm.logger.Printf("Computing SHA256 hash of: %s", filePath)
-
-	f, err := os.Open(filePath)
+// Remove deletes an installed package's outputs from InstallPath, or only
+// the given ones. Dependency store paths are shared between packages and
+// stay in place.
+func (pm *PackageManager) Remove(attribute string, outputs []string) error {
+	pkg, err := pm.LookupPackage(attribute)
 	if err != nil {
-		return fmt.Errorf("opening file: %w", err)
-	}
-	defer f.Close()
-
-	hasher := sha256.New()
-	if _, err := io.Copy(hasher, f); err != nil {
-		return fmt.Errorf("computing hash: %w", err)
-	}
-
-	actualHashBytes := hasher.Sum(nil)
-	actualHashBase32 := toNixBase32(actualHashBytes)
-
-	if actualHashBase32 != expectedHash {
-		return fmt.Errorf("hash mismatch: expected %s, got %s", expectedHash, actualHashBase32)
+		return fmt.Errorf("looking up package: %w", err)
 	}
 
-	pm.logger.Printf("  ✓ Hashes match!")
-	return nil
-}
-
-// extractNAR extracts a NAR archive
-func (pm *PackageManager) extractNAR(narPath, destPath, compression string) error {
-	pm.logger.Printf("Extracting NAR: %s -> %s (compression: %s)", narPath, destPath, compression)
-
-	// Create destination directory
-	if err := os.MkdirAll(destPath, 0755); err != nil {
-		return fmt.Errorf("creating destination directory: %w", err)
+	baseDir := filepath.Join(pm.config.InstallPath, pkg.NameVersion)
+	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
+		return fmt.Errorf("%s is not installed", attribute)
 	}
 
-	// Decompress first if needed
-	decompressedPath := narPath
-	if compression != "none" {
-		var err error
-		decompressedPath, err = pm.decompressFile(narPath, compression)
-		if err != nil {
-			return fmt.Errorf("decompressing: %w", err)
+	if len(outputs) == 0 {
+		pm.logger.Printf("Removing %s", baseDir)
+		if err := os.RemoveAll(baseDir); err != nil {
+			return err
 		}
-		defer os.Remove(decompressedPath)
-	}
-
-	// Extract the plain NAR
-	return pm.extractPlainNAR(decompressedPath, destPath)
-}
-
-// decompressFile decompresses a file and returns the path to the decompressed file
-func (pm *PackageManager) decompressFile(compressedPath, compression string) (string, error) {
-	pm.logger.Printf("Decompressing %s archive...", compression)
-
-	decompressedPath := compressedPath
-	switch compression {
-	case "xz":
-		decompressedPath = compressedPath[:len(compressedPath)-3]
-		return decompressedPath, pm.decompressXZ(compressedPath, decompressedPath)
-	case "bzip2":
-		decompressedPath = compressedPath[:len(compressedPath)-4]
-		return decompressedPath, pm.decompressBZip2(compressedPath, decompressedPath)
-	default:
-		return "", fmt.Errorf("unsupported compression: %s", compression)
-	}
-}
-
-// decompressXZ decompresses an xz file
-func (pm *PackageManager) decompressXZ(src, dst string) error {
-	inputFile, err := os.Open(src)
-	if err != nil {
-		return fmt.Errorf("opening source file: %w", err)
-	}
-	defer inputFile.Close()
-
-	xzReader, err := xz.NewReader(inputFile)
-	if err != nil {
-		return fmt.Errorf("creating xz reader: %w", err)
-	}
-
-	outFile, err := os.Create(dst)
-	if err != nil {
-		return fmt.Errorf("creating output file: %w", err)
-	}
-	defer outFile.Close()
-
-	if _, err := io.Copy(outFile, xzReader); err != nil {
-		return fmt.Errorf("decompressing data: %w", err)
-	}
-
-	return nil
-}
-
-// decompressBZip2 decompresses a bzip2 file
-func (pm *PackageManager) decompressBZip2(src, dst string) error {
-	inputFile, err := os.Open(src)
-	if err != nil {
-		return fmt.Errorf("opening source file: %w", err)
-	}
-	defer inputFile.Close()
-
-	bzReader := bzip2.NewReader(inputFile)
-
-	outFile, err := os.Create(dst)
-	if err != nil {
-		return fmt.Errorf("creating output file: %w", err)
-	}
-	defer outFile.Close()
-
-	if _, err := io.Copy(outFile, bzReader); err != nil {
-		return fmt.Errorf("decompressing data: %w", err)
+		pm.pruneOutputLinks()
+		return nil
 	}
 
-	return nil
-}
-
-// extractPlainNAR extracts an uncompressed NAR archive
-func (pm *PackageManager) extractPlainNAR(narPath, destPath string) error {
-	pm.logger.Printf("Extracting NAR archive...")
-
-	f, err := os.Open(narPath)
-	if err != nil {
-		return fmt.Errorf("opening NAR file: %w", err)
-	}
-	defer f.Close()
-
-	bufReader := bufio.NewReader(f)
-	narReader := nar.NewReader(bufReader)
-
-	fileCount := 0
-
-	for {
-		hdr, err := narReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("reading NAR entry: %w", err)
-		}
-
-		targetPath := filepath.Join(destPath, hdr.Path)
-
-		switch hdr.Mode.Type() {
-		case os.ModeDir:
-			if err := os.MkdirAll(targetPath, 0755); err != nil {
-				return fmt.Errorf("creating directory %s: %w", targetPath, err)
-			}
-		case os.ModeSymlink:
-			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-				return fmt.Errorf("creating parent directory: %w", err)
-			}
-			if err := os.Symlink(hdr.LinkTarget, targetPath); err != nil {
-				return fmt.Errorf("creating symlink: %w", err)
-			}
-		case 0: // Regular file
-			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-				return fmt.Errorf("creating parent directory: %w", err)
-			}
-
-			perm := os.FileMode(0644)
-			if hdr.Mode&0111 != 0 {
-				perm = 0755
-			}
-
-			outFile, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
-			if err != nil {
-				return fmt.Errorf("creating file %s: %w", targetPath, err)
-			}
-
-			written, err := io.Copy(outFile, narReader)
-			outFile.Close()
-			if err != nil {
-				return fmt.Errorf("writing file: %w", err)
-			}
-			if written != hdr.Size {
-				return fmt.Errorf("size mismatch")
-			}
-			fileCount++
+	for _, output := range outputs {
+		pm.logger.Printf("Removing %s/%s", baseDir, output)
+		if err := os.RemoveAll(filepath.Join(baseDir, output)); err != nil {
+			return err
 		}
 	}
 
-	pm.logger.Printf("✓ Extraction complete (%d files)", fileCount)
+	// Drop the package directory once its last output is gone
+	if entries, err := os.ReadDir(baseDir); err == nil && len(entries) == 0 {
+		os.Remove(baseDir)
+	}
+	pm.pruneOutputLinks()
 	return nil
 }
 
@@ -0,0 +1,251 @@
+// pkg/nix/nar.go
+package nix
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	"zombiezen.com/go/nix/nar"
+)
+
+// installStorePath downloads, verifies and extracts one store path into
+// dest in a single pass: the response is hashed for FileHash, decompressed,
+// hashed and counted for NarHash/NarSize and unpacked as it streams.
+// Extraction goes through a temporary directory that only replaces dest
+// once every check passed, so an interrupted or tampered download is never
+// mistaken for a present one.
+func (pm *PackageManager) installStorePath(ctx context.Context, narInfo *NARInfo, dest string, opts *DownloadOptions) error {
+	cacheURL := narInfo.CacheURL
+	if cacheURL == "" {
+		cacheURL = pm.config.CacheURL
+	}
+	url := fmt.Sprintf("%s/%s", cacheURL, narInfo.URL)
+	pm.logger.Printf("Downloading NAR from: %s", url)
+
+	resp, err := pm.client.Get(ctx, url)
+	if err != nil {
+		return fmt.Errorf("downloading: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Compressed stream: hashed for FileHash and optionally kept on disk
+	fileHasher := sha256.New()
+	var compressed io.Reader = io.TeeReader(resp.Body, fileHasher)
+
+	var archivePath string
+	if opts.KeepArchive {
+		archivePath = filepath.Join(pm.config.InstallPath, archiveName(narInfo))
+		if err := os.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+			return fmt.Errorf("creating directory: %w", err)
+		}
+		archive, err := os.Create(archivePath)
+		if err != nil {
+			return fmt.Errorf("creating archive: %w", err)
+		}
+		defer archive.Close()
+		compressed = io.TeeReader(compressed, archive)
+	}
+
+	// Uncompressed NAR stream: hashed and counted for NarHash and NarSize
+	decompressed, err := decompress(compressed, narInfo.Compression)
+	if err != nil {
+		return err
+	}
+	defer decompressed.Close()
+
+	narHasher := sha256.New()
+	counter := &countingWriter{}
+	narStream := io.TeeReader(decompressed, io.MultiWriter(narHasher, counter))
+
+	tmp := dest + ".partial"
+	os.RemoveAll(tmp)
+
+	fail := func(err error) error {
+		os.RemoveAll(tmp)
+		if archivePath != "" {
+			os.Remove(archivePath)
+		}
+		return err
+	}
+
+	if opts.Extract {
+		pm.logger.Printf("Extracting to: %s", dest)
+		if err := pm.unpackNAR(narStream, tmp); err != nil {
+			return fail(fmt.Errorf("extracting: %w", err))
+		}
+	}
+
+	// Drain whatever the unpacker and decompressor left, so both hashes
+	// cover the complete streams
+	if _, err := io.Copy(io.Discard, narStream); err != nil {
+		return fail(fmt.Errorf("reading NAR: %w", err))
+	}
+	if _, err := io.Copy(io.Discard, compressed); err != nil {
+		return fail(fmt.Errorf("downloading: %w", err))
+	}
+
+	if err := verifyNARStream(narInfo, fileHasher.Sum(nil), narHasher.Sum(nil), counter.n, opts.VerifyHash); err != nil {
+		return fail(err)
+	}
+	pm.logger.Printf("  ✓ NarHash and NarSize match (%s)", formatSize(counter.n))
+
+	if !opts.Extract {
+		return nil
+	}
+	if err := os.RemoveAll(dest); err != nil {
+		return fail(err)
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		return fail(err)
+	}
+	return nil
+}
+
+// verifyNARStream checks the sizes and hashes of a downloaded store path.
+// NarHash and NarSize are covered by the narinfo signature and always
+// checked; the unsigned FileHash only when checkFileHash is set.
+func verifyNARStream(narInfo *NARInfo, fileSum, narSum []byte, narSize int64, checkFileHash bool) error {
+	if narInfo.NarSize > 0 && narSize != narInfo.NarSize {
+		return fmt.Errorf("NAR size mismatch: expected %d, got %d", narInfo.NarSize, narSize)
+	}
+	if narInfo.NarHash == "" {
+		return fmt.Errorf("narinfo for %s has no NarHash", narInfo.StorePath)
+	}
+	if actual := toNixBase32(narSum); actual != hashBase32(narInfo.NarHash) {
+		return fmt.Errorf("NarHash mismatch: expected %s, got %s", narInfo.NarHash, actual)
+	}
+	if checkFileHash && narInfo.FileHash != "" {
+		if actual := toNixBase32(fileSum); actual != hashBase32(narInfo.FileHash) {
+			return fmt.Errorf("file hash mismatch: expected %s, got %s", narInfo.FileHash, actual)
+		}
+	}
+	return nil
+}
+
+// archiveName names a kept NAR after its store path, with the cache's
+// extension: "<hash>-hello-2.12.nar.xz"
+func archiveName(narInfo *NARInfo) string {
+	ext := ".nar"
+	if i := strings.Index(path.Base(narInfo.URL), ".nar"); i >= 0 {
+		ext = path.Base(narInfo.URL)[i:]
+	}
+	return path.Base(narInfo.StorePath) + ext
+}
+
+// decompress wraps a NAR download in the decoder for its Compression
+func decompress(r io.Reader, compression string) (io.ReadCloser, error) {
+	switch compression {
+	case CompressionXZ:
+		xzReader, err := xz.NewReader(bufio.NewReader(r))
+		if err != nil {
+			return nil, fmt.Errorf("creating xz reader: %w", err)
+		}
+		return io.NopCloser(xzReader), nil
+	case CompressionBZip2:
+		return io.NopCloser(bzip2.NewReader(bufio.NewReader(r))), nil
+	case CompressionGzip:
+		gzipReader, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("creating gzip reader: %w", err)
+		}
+		return gzipReader, nil
+	case CompressionZstd:
+		zstdReader, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("creating zstd reader: %w", err)
+		}
+		return zstdReader.IOReadCloser(), nil
+	case CompressionNone:
+		return io.NopCloser(r), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression: %s", compression)
+	}
+}
+
+// unpackNAR unpacks a NAR stream into destPath. The NAR's root becomes
+// destPath itself, whether it is a directory, a file or a symlink.
+func (pm *PackageManager) unpackNAR(r io.Reader, destPath string) error {
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return fmt.Errorf("creating parent directory: %w", err)
+	}
+
+	narReader := nar.NewReader(r)
+	fileCount := 0
+
+	for {
+		hdr, err := narReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading NAR entry: %w", err)
+		}
+
+		if hdr.Path != "" && !filepath.IsLocal(filepath.FromSlash(hdr.Path)) {
+			return fmt.Errorf("unsafe path in NAR: %s", hdr.Path)
+		}
+		targetPath := filepath.Join(destPath, filepath.FromSlash(hdr.Path))
+
+		switch hdr.Mode.Type() {
+		case os.ModeDir:
+			if err := os.MkdirAll(targetPath, 0755); err != nil {
+				return fmt.Errorf("creating directory %s: %w", targetPath, err)
+			}
+		case os.ModeSymlink:
+			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+				return fmt.Errorf("creating parent directory: %w", err)
+			}
+			if err := os.Symlink(hdr.LinkTarget, targetPath); err != nil {
+				return fmt.Errorf("creating symlink: %w", err)
+			}
+		case 0: // Regular file
+			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+				return fmt.Errorf("creating parent directory: %w", err)
+			}
+
+			perm := os.FileMode(0644)
+			if hdr.Mode&0111 != 0 {
+				perm = 0755
+			}
+
+			outFile, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+			if err != nil {
+				return fmt.Errorf("creating file %s: %w", targetPath, err)
+			}
+
+			written, err := io.Copy(outFile, narReader)
+			outFile.Close()
+			if err != nil {
+				return fmt.Errorf("writing file: %w", err)
+			}
+			if written != hdr.Size {
+				return fmt.Errorf("size mismatch for %s", hdr.Path)
+			}
+			fileCount++
+		}
+	}
+
+	pm.logger.Printf("✓ Extraction complete (%d files)", fileCount)
+	return nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -7,8 +7,8 @@ import (
 	"strings"
 )
 
-// parseNARInfo parses a .narinfo file
-func parseNARInfo(content string) (*NARInfo, error) {
+// ParseNARInfo parses a .narinfo file (Nix and Guix use the same format)
+func ParseNARInfo(content string) (*NARInfo, error) {
 	info := &NARInfo{}
 	lines := strings.Split(content, "\n")
 
@@ -50,13 +50,20 @@ func parseNARInfo(content string) (*NARInfo, error) {
 		case "Deriver":
 			info.Deriver = value
 		case "Sig":
-			info.Signature = value
+			if info.Signature == "" {
+				info.Signature = value
+			}
+			info.Signatures = append(info.Signatures, value)
 		}
 	}
 
 	if info.StorePath == "" {
 		return nil, fmt.Errorf("missing StorePath in narinfo")
 	}
+	if info.Compression == "" {
+		// Nix's default for narinfo without a Compression line
+		info.Compression = CompressionBZip2
+	}
 
 	return info, nil
 }
\ No newline at end of file
@@ -0,0 +1,140 @@
+// pkg/nix/substituter.go
+package nix
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultPriority is the priority of a substituter whose nix-cache-info
+// does not set one (cache.nixos.org advertises 40)
+const DefaultPriority = 50
+
+// Substituter is a binary cache store paths are fetched from, as in
+// nix.conf's substituters
+type Substituter struct {
+	URL        string   // Base URL holding <hash>.narinfo and nar/
+	Priority   int      // Lower is tried first; 0 reads it from <URL>/nix-cache-info
+	PublicKeys []string // "name:base64" keys trusted for this substituter only
+}
+
+// substituters returns the configured substituters in the order they are
+// tried: by priority, then as listed. Priorities left at 0 are read from
+// each cache's nix-cache-info once.
+func (pm *PackageManager) substituters(ctx context.Context) []*Substituter {
+	if pm.ordered != nil {
+		return pm.ordered
+	}
+
+	ordered := make([]*Substituter, 0, len(pm.config.Substituters))
+	for i := range pm.config.Substituters {
+		sub := pm.config.Substituters[i]
+		sub.URL = strings.TrimSuffix(sub.URL, "/")
+		if sub.Priority == 0 {
+			sub.Priority = pm.cachePriority(ctx, sub.URL)
+		}
+		ordered = append(ordered, &sub)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority < ordered[j].Priority
+	})
+
+	if pm.config.Debug {
+		for _, sub := range ordered {
+			pm.logger.Printf("  Substituter: %s (priority %d)", sub.URL, sub.Priority)
+		}
+	}
+
+	pm.ordered = ordered
+	return ordered
+}
+
+// cachePriority reads the Priority of a binary cache from its nix-cache-info
+func (pm *PackageManager) cachePriority(ctx context.Context, url string) int {
+	content, err := pm.client.GetString(ctx, url+"/nix-cache-info")
+	if err != nil {
+		return DefaultPriority
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) != "Priority" {
+			continue
+		}
+		if priority, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			return priority
+		}
+	}
+	return DefaultPriority
+}
+
+// NARInfoFetcher looks up the narinfo of a store hash in place of the
+// configured substituters, for stores with their own signature scheme (Guix)
+type NARInfoFetcher interface {
+	// FetchNARInfo returns the verified narinfo of a store hash, with
+	// CacheURL set to the server its NAR URL is relative to
+	FetchNARInfo(ctx context.Context, hash string) (*NARInfo, error)
+}
+
+// GetNARInfo retrieves metadata for a store path from the first
+// substituter that has it with a valid signature
+func (pm *PackageManager) GetNARInfo(ctx context.Context, hash string) (*NARInfo, error) {
+	if pm.config.Fetcher != nil {
+		narInfo, err := pm.config.Fetcher.FetchNARInfo(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if storeHash(narInfo.StorePath) != hash {
+			return nil, fmt.Errorf("narinfo for %s describes %s", hash, narInfo.StorePath)
+		}
+		return narInfo, nil
+	}
+
+	var lastErr error
+	for _, sub := range pm.substituters(ctx) {
+		narInfo, err := pm.getNARInfo(ctx, sub, hash)
+		if err == nil {
+			return narInfo, nil
+		}
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		return nil, fmt.Errorf("no substituters configured")
+	}
+	return nil, lastErr
+}
+
+// getNARInfo fetches and checks a store path's narinfo from one substituter
+func (pm *PackageManager) getNARInfo(ctx context.Context, sub *Substituter, hash string) (*NARInfo, error) {
+	url := fmt.Sprintf("%s/%s.narinfo", sub.URL, hash)
+	pm.logger.Printf("Fetching NAR info from: %s", url)
+
+	content, err := pm.client.GetString(ctx, url)
+	if err != nil {
+		pm.logger.Printf("✗ Failed to fetch NAR info: %v", err)
+		return nil, fmt.Errorf("%s: %w", sub.URL, err)
+	}
+
+	narInfo, err := ParseNARInfo(content)
+	if err != nil {
+		pm.logger.Printf("✗ Failed to parse NAR info: %v", err)
+		return nil, err
+	}
+	narInfo.CacheURL = sub.URL
+
+	// A cache must not answer for one store path with another's metadata
+	if storeHash(narInfo.StorePath) != hash {
+		return nil, fmt.Errorf("%s: narinfo for %s describes %s", sub.URL, hash, narInfo.StorePath)
+	}
+
+	if err := pm.verifyNARInfo(sub, narInfo); err != nil {
+		pm.logger.Printf("✗ %v", err)
+		return nil, fmt.Errorf("%s: %w", sub.URL, err)
+	}
+
+	return narInfo, nil
+}
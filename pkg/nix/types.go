@@ -2,25 +2,44 @@
 package nix
 
 import (
+	"io"
 	"log"
 	"time"
 )
 
-// Package represents an entry in the JSON index
+// Package represents an entry in the JSON index. Older index files only
+// carry Attribute, NameVersion and StorePath; PName, Version and Outputs are
+// then derived from those when the index is loaded.
 type Package struct {
 	Attribute   string `json:"Attribute"`
 	NameVersion string `json:"NameVersion"`
 	StorePath   string `json:"StorePath"`
+
+	PName       string     `json:"PName,omitempty"`       // Name without version (e.g. "python3")
+	Version     string     `json:"Version,omitempty"`     // e.g. "3.12.4"
+	Description string     `json:"Description,omitempty"` // meta.description
+	License     StringList `json:"License,omitempty"`     // SPDX ids or names from meta.license
+	Homepage    string     `json:"Homepage,omitempty"`    // meta.homepage
+	Outputs     []string   `json:"Outputs,omitempty"`     // Output names, default output first
+	Platforms   StringList `json:"Platforms,omitempty"`   // meta.platforms
 }
 
 // Config configures the package manager
 type Config struct {
-	CacheURL    string        // Default: https://cache.nixos.org
-	InstallPath string        // Default: /nix/store
-	CachePath   string        // Location of local cache/index files
-	Timeout     time.Duration
-	Debug       bool          // Enable debug logging
-	Logger      *log.Logger   // Custom logger (optional)
+	CacheURL          string         // Default: https://cache.nixos.org; used when Substituters is empty
+	Substituters      []Substituter  // Binary caches, tried by priority (default: CacheURL)
+	TrustedPublicKeys []string       // "name:base64" ed25519 keys trusted for every substituter (default: cache.nixos.org-1)
+	Platform          Platform       // Index used when a download names no platform (default: host)
+	AllowUnsigned     bool           // Skip narinfo signature checks, like require-sigs = false
+	InstallPath       string         // Default: /nix/store
+	StoreDir          string         // Host store checked for already present paths (default: /nix/store)
+	CachePath         string         // Location of local cache/index files
+	IndexName         string         // Index file prefix in CachePath/index (default: nix)
+	Fetcher           NARInfoFetcher // Replaces the substituters for narinfo lookups (optional)
+	Timeout           time.Duration
+	Debug             bool        // Enable debug logging
+	Logger            *log.Logger // Custom logger (optional)
+	Progress          io.Writer   // User-facing progress such as closure sizes (optional)
 }
 
 // NARInfo contains metadata about a Nix package
@@ -32,16 +51,20 @@ type NARInfo struct {
 	FileSize    int64
 	NarHash     string
 	NarSize     int64
+	CacheURL    string // Substituter the narinfo came from; the NAR URL is relative to it
 	References  []string
 	Deriver     string
-	Signature   string
+	Signature   string   // First Sig line
+	Signatures  []string // Every Sig line ("keyname:base64")
 }
 
 // DownloadOptions configures package download and extraction
 type DownloadOptions struct {
 	Outputs      []string // Which outputs to download (e.g., ["bin", "dev"]). Empty = all outputs
-	Compression  string   // xz, bzip2, none
+	Platform     Platform // Index to resolve the attribute in (default: Config.Platform)
+	Compression  string   // Ignored: each narinfo names its own (xz, bzip2, zstd, gzip, none)
 	Extract      bool     // Whether to extract the NAR archive (default: true)
 	KeepArchive  bool     // Whether to keep the .nar.xz file after extraction (default: false)
 	VerifyHash   bool     // Whether to verify file hash after download (default: true)
+	OutputsOnly  bool     // Skip the runtime closure (narinfo References) and fetch only the outputs
 }
\ No newline at end of file
@@ -0,0 +1,142 @@
+// pkg/osrelease/osrelease.go
+package osrelease
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Paths lists the os-release locations in lookup order, as described in os-release(5)
+var Paths = []string{
+	"/etc/os-release",
+	"/usr/lib/os-release",
+}
+
+// legacyFiles maps distribution-specific release files to an os-release ID,
+// for systems that predate /etc/os-release
+var legacyFiles = []struct {
+	path string
+	id   string
+}{
+	{"/etc/alpine-release", "alpine"},
+	{"/etc/fedora-release", "fedora"},
+	{"/etc/arch-release", "arch"},
+	{"/etc/SuSE-release", "opensuse"},
+	{"/etc/debian_version", "debian"},
+}
+
+// Info holds the fields of /etc/os-release that upkg cares about
+type Info struct {
+	ID              string            // Distribution ID (ubuntu, fedora, alpine, ...)
+	IDLike          []string          // Parent distributions from ID_LIKE (debian, rhel, ...)
+	Name            string            // NAME
+	PrettyName      string            // PRETTY_NAME
+	VersionID       string            // VERSION_ID (24.04, 42, 3.19.1, ...)
+	VersionCodename string            // VERSION_CODENAME (noble, bookworm, ...)
+	Fields          map[string]string // Every key/value pair from the file
+}
+
+// Load reads the os-release file of the running system
+func Load() (*Info, error) {
+	for _, path := range Paths {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		info, err := Parse(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		return info, nil
+	}
+
+	for _, legacy := range legacyFiles {
+		if _, err := os.Stat(legacy.path); err == nil {
+			return &Info{ID: legacy.id, Fields: map[string]string{"ID": legacy.id}}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no os-release file found")
+}
+
+// Parse parses os-release content (shell-style KEY=value assignments)
+func Parse(r io.Reader) (*Info, error) {
+	info := &Info{Fields: make(map[string]string)}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		info.Fields[strings.TrimSpace(key)] = unquote(strings.TrimSpace(value))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	info.ID = strings.ToLower(info.Fields["ID"])
+	info.IDLike = strings.Fields(strings.ToLower(info.Fields["ID_LIKE"]))
+	info.Name = info.Fields["NAME"]
+	info.PrettyName = info.Fields["PRETTY_NAME"]
+	info.VersionID = info.Fields["VERSION_ID"]
+	info.VersionCodename = info.Fields["VERSION_CODENAME"]
+
+	return info, nil
+}
+
+// Get returns an arbitrary field (e.g. UBUNTU_CODENAME)
+func (i *Info) Get(key string) string {
+	return i.Fields[key]
+}
+
+// Is reports whether the distribution is one of ids, either directly or through ID_LIKE
+func (i *Info) Is(ids ...string) bool {
+	for _, id := range ids {
+		if i.ID == id {
+			return true
+		}
+		for _, like := range i.IDLike {
+			if like == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unquote strips the quoting and backslash escapes os-release(5) allows around values
+func unquote(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' || first == '\'') && first == last {
+			value = value[1 : len(value)-1]
+		}
+	}
+
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+
+	var b strings.Builder
+	escaped := false
+	for _, r := range value {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -5,22 +5,54 @@ const (
 	// In a real scenario, this should be configurable or parsed from /etc/pacman.d/mirrorlist
 	DefaultMirror = "https://geo.mirror.pkgbuild.com"
 
+	// DefaultARMMirror is the Arch Linux ARM mirror redirector
+	DefaultARMMirror = "http://mirror.archlinuxarm.org"
+
+	// ArchLayout and ARMLayout are appended to a mirror URL without
+	// $repo/$arch placeholders, as in pacman's mirrorlist Server lines
+	ArchLayout = "$repo/os/$arch"
+	ARMLayout  = "$arch/$repo"
+
 	// DefaultInstallPath is where packages will be extracted
 	DefaultInstallPath = "/opt/upkg"
 
 	// DefaultArch is the primary architecture for Arch Linux
 	DefaultArch = "x86_64"
+
+	// DefaultKeyringDir holds the keyrings installed by the *-keyring packages
+	DefaultKeyringDir = "/usr/share/pacman/keyrings"
+
+	// DefaultKeyringName is the Arch Linux keyring (archlinux.gpg, archlinux-keyring)
+	DefaultKeyringName = "archlinux"
+
+	// ARMKeyringName is the Arch Linux ARM keyring (archlinuxarm.gpg, archlinuxarm-keyring)
+	ARMKeyringName = "archlinuxarm"
+)
+
+// Architectures
+const (
+	ArchX86_64  = "x86_64"  // Arch Linux
+	ArchAarch64 = "aarch64" // Arch Linux ARM, 64-bit
+	ArchArmv7h  = "armv7h"  // Arch Linux ARM, ARMv7 hard float
 )
 
 // Repository names
 const (
 	RepoCore     = "core"     // Critical system packages
 	RepoExtra    = "extra"    // General application packages
-	RepoMultilib = "multilib" // 32-bit compatibility libraries
+	RepoMultilib = "multilib" // 32-bit compatibility libraries (x86_64 only)
+	RepoAlarm    = "alarm"    // Arch Linux ARM specific packages
 )
 
 // DefaultRepos lists the standard repositories enabled by default
 var DefaultRepos = []string{
 	RepoCore,
 	RepoExtra,
-}
\ No newline at end of file
+}
+
+// DefaultARMRepos lists the repositories enabled by default on Arch Linux ARM
+var DefaultARMRepos = []string{
+	RepoCore,
+	RepoExtra,
+	RepoAlarm,
+}
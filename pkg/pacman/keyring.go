@@ -0,0 +1,369 @@
+// pkg/pacman/keyring.go
+package pacman
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Keyring holds the OpenPGP keys database and package signatures are
+// checked against
+type Keyring struct {
+	entities openpgp.EntityList // Every key, minus revoked ones
+	trusted  openpgp.EntityList // Keys the keyring's trusted list vouches for
+}
+
+// Len returns the number of keys in the keyring
+func (k *Keyring) Len() int {
+	return len(k.entities)
+}
+
+// add adds keys to the keyring, trusted or not
+func (k *Keyring) add(entities, trusted openpgp.EntityList) {
+	k.entities = append(k.entities, entities...)
+	k.trusted = append(k.trusted, trusted...)
+}
+
+// Verify checks a detached signature (binary, as pacman ships them, or
+// armored) over signed and returns the signer. With TrustedOnly the signer
+// must be a trusted key; with TrustAll any key in the keyring will do.
+func (k *Keyring) Verify(signed io.Reader, sig []byte, trust SigTrust) (string, error) {
+	keys := k.trusted
+	if trust == TrustAll {
+		keys = k.entities
+	}
+
+	var signer *openpgp.Entity
+	var err error
+	if bytes.HasPrefix(bytes.TrimSpace(sig), []byte("-----BEGIN PGP")) {
+		signer, err = openpgp.CheckArmoredDetachedSignature(keys, signed, bytes.NewReader(sig), nil)
+	} else {
+		signer, err = openpgp.CheckDetachedSignature(keys, signed, bytes.NewReader(sig), nil)
+	}
+	if errors.Is(err, pgperrors.ErrUnknownIssuer) {
+		if trust == TrustedOnly {
+			return "", fmt.Errorf("signed with an unknown or untrusted key")
+		}
+		return "", fmt.Errorf("signed with an unknown key")
+	}
+	if err != nil {
+		return "", fmt.Errorf("bad signature: %w", err)
+	}
+
+	if id := signer.PrimaryIdentity(); id != nil {
+		return id.Name, nil
+	}
+	return strings.ToUpper(hex.EncodeToString(signer.PrimaryKey.Fingerprint)), nil
+}
+
+// keyring loads the trusted keys on first use: the configured keyring files
+// minus the fingerprints in their "-revoked" lists, trusted as their
+// "-trusted" lists say, plus Config.GPGKeys. When no keyring is installed
+// (any host but Arch itself), the keyring package is bootstrapped once and
+// pinned in the cache.
+func (pm *PackageManager) keyring(ctx context.Context, arch string) (*Keyring, error) {
+	if pm.keys != nil {
+		return pm.keys, nil
+	}
+
+	keyring := &Keyring{}
+	for _, file := range pm.config.Keyrings {
+		entities, err := readKeyringFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("loading keyring %s: %w", file, err)
+		}
+
+		// An installed keyring is trusted as its own trusted list says; a
+		// keyring file without one was configured by hand and is trusted whole
+		trusted := entities
+		if list, err := os.ReadFile(strings.TrimSuffix(file, ".gpg") + "-trusted"); err == nil {
+			if trusted, err = vouched(entities, list); err != nil {
+				return nil, fmt.Errorf("loading keyring %s: %w", file, err)
+			}
+		}
+		keyring.add(entities, trusted)
+	}
+
+	if len(keyring.entities) == 0 {
+		entities, trusted, err := pm.bootstrapKeyring(ctx, arch)
+		if err != nil {
+			return nil, fmt.Errorf("no %s keyring installed and bootstrapping it failed: %w", pm.config.KeyringName, err)
+		}
+		keyring.add(entities, trusted)
+	}
+
+	for _, source := range pm.config.GPGKeys {
+		data, err := pm.readKey(ctx, source)
+		if err != nil {
+			return nil, fmt.Errorf("loading key %s: %w", source, err)
+		}
+		entities, err := parseKeys(data)
+		if err != nil {
+			return nil, fmt.Errorf("loading key %s: %w", source, err)
+		}
+		keyring.add(entities, entities)
+	}
+
+	pm.logger.Printf("  Loaded %d keys, %d trusted", keyring.Len(), len(keyring.trusted))
+	pm.keys = keyring
+	return keyring, nil
+}
+
+// readKeyringFile reads a keyring and drops the keys listed in the
+// "<name>-revoked" file next to it
+func readKeyringFile(file string) (openpgp.EntityList, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	entities, err := parseKeys(data)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked := make(map[string]bool)
+	if list, err := os.ReadFile(strings.TrimSuffix(file, ".gpg") + "-revoked"); err == nil {
+		for _, line := range strings.Fields(string(list)) {
+			revoked[strings.ToUpper(line)] = true
+		}
+	}
+
+	var kept openpgp.EntityList
+	for _, entity := range entities {
+		if !revoked[strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint))] {
+			kept = append(kept, entity)
+		}
+	}
+	return kept, nil
+}
+
+// parseKeys parses public keys, binary (as in keyring files) or armored
+func parseKeys(data []byte) (openpgp.EntityList, error) {
+	if bytes.Contains(data, []byte("-----BEGIN PGP")) {
+		return openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	}
+	return openpgp.ReadKeyRing(bytes.NewReader(data))
+}
+
+// readKey reads a key from a path or URL; remote keys are pinned in the
+// cache on first fetch
+func (pm *PackageManager) readKey(ctx context.Context, source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "https://") && !strings.HasPrefix(source, "http://") {
+		return os.ReadFile(strings.TrimPrefix(source, "file://"))
+	}
+
+	sum := sha256.Sum256([]byte(source))
+	pinned := filepath.Join(pm.config.CachePath, "keys", hex.EncodeToString(sum[:8])+".asc")
+	if data, err := os.ReadFile(pinned); err == nil {
+		return data, nil
+	}
+
+	body, err := pm.client.Get(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseKeys(data); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(pinned), 0755); err == nil {
+		os.WriteFile(pinned, data, 0644)
+	}
+	return data, nil
+}
+
+// bootstrapKeyring returns the pinned copy of the distribution keyring,
+// fetching it first if needed: the <name>-keyring package is located in the
+// core database (or Config.KeyringDB), checked against its SHA256SUM and its
+// keyring files are kept in the cache. Nothing downloaded is trusted on its
+// own: the keys are trusted as the <name>-trusted list embedded in upkg
+// says, and the package itself must be signed by one of them. Without an
+// embedded list, or when the keyring holds none of its master keys, this
+// fails.
+func (pm *PackageManager) bootstrapKeyring(ctx context.Context, arch string) (openpgp.EntityList, openpgp.EntityList, error) {
+	name := pm.config.KeyringName
+	list, ok := embeddedTrustedList(name)
+	if !ok {
+		return nil, nil, fmt.Errorf("no %s-trusted master key list is embedded (see pkg/pacman/keyrings/README.md)", name)
+	}
+
+	dir := filepath.Join(pm.config.CachePath, "keyring")
+	file := filepath.Join(dir, name+".gpg")
+	if entities, err := readKeyringFile(file); err == nil && len(entities) > 0 {
+		if trusted, err := vouched(entities, list); err == nil {
+			return entities, trusted, nil
+		}
+		pm.logger.Printf("  ⚠️ Pinned %s keyring is not vouched for by the embedded master keys, fetching it again", name)
+	}
+
+	pkgName := name + "-keyring"
+	pm.logger.Printf("  No %s keyring installed; bootstrapping it from the %s package", name, pkgName)
+
+	dbURL := pm.config.KeyringDB
+	if dbURL == "" {
+		dbURL = fmt.Sprintf("%s/%s.db", pm.repoURL(RepoCore, arch), RepoCore)
+	}
+	repoDir, dbFile := path.Split(dbURL)
+	repo := strings.TrimSuffix(dbFile, ".db")
+
+	body, err := pm.client.Get(ctx, dbURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	pkgs, err := ParseDatabase(body, repo)
+	body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var keyringPkg *PackageInfo
+	for _, p := range pkgs {
+		if p.Name == pkgName {
+			keyringPkg = p
+		}
+	}
+	if keyringPkg == nil || keyringPkg.Filename == "" {
+		return nil, nil, fmt.Errorf("%s not found in %s", pkgName, repo)
+	}
+
+	archive := filepath.Join(pm.config.CachePath, "downloads", keyringPkg.Filename)
+	if err := pm.downloadFile(ctx, repoDir+keyringPkg.Filename, archive); err != nil {
+		return nil, nil, err
+	}
+	defer os.Remove(archive)
+	if err := pm.verifyHash(archive, keyringPkg.SHA256Sum); err != nil {
+		return nil, nil, err
+	}
+
+	// Extract next to the pinned copy and only replace it once verified
+	if err := os.MkdirAll(pm.config.CachePath, 0755); err != nil {
+		return nil, nil, err
+	}
+	staging, err := os.MkdirTemp(pm.config.CachePath, "keyring-")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(staging)
+	if err := extractKeyringFiles(archive, staging); err != nil {
+		return nil, nil, err
+	}
+	entities, err := readKeyringFile(filepath.Join(staging, name+".gpg"))
+	if err != nil {
+		return nil, nil, err
+	}
+	trusted, err := vouched(entities, list)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", keyringPkg.Filename, err)
+	}
+
+	// The package must be signed by a key the master keys vouch for
+	sig, err := pm.keyringSignature(ctx, keyringPkg, repoDir+keyringPkg.Filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, err := (&Keyring{entities: entities, trusted: trusted}).Verify(f, sig, TrustedOnly)
+	f.Close()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", keyringPkg.Filename, err)
+	}
+	pm.logger.Printf("  %s signed by %s", keyringPkg.Filename, signer)
+
+	os.RemoveAll(dir)
+	if err := os.Rename(staging, dir); err != nil {
+		return nil, nil, err
+	}
+	return entities, trusted, nil
+}
+
+// keyringSignature returns the keyring package's signature: its %PGPSIG%
+// or the .sig next to it on the mirror
+func (pm *PackageManager) keyringSignature(ctx context.Context, pkg *PackageInfo, url string) ([]byte, error) {
+	if pkg.PGPSignature != "" {
+		sig, err := base64.StdEncoding.DecodeString(pkg.PGPSignature)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s %%PGPSIG%%: %w", pkg.Name, err)
+		}
+		return sig, nil
+	}
+	sig, err := pm.fetchSignature(ctx, url+".sig")
+	if err != nil {
+		return nil, fmt.Errorf("%s is not signed: %w", pkg.Name, err)
+	}
+	return sig, nil
+}
+
+// extractKeyringFiles copies usr/share/pacman/keyrings/* out of a keyring package
+func extractKeyringFiles(archive, dir string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zr, err := zstd.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return fmt.Errorf("zstd init: %w", err)
+	}
+	defer zr.Close()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	found := false
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg || path.Dir(hdr.Name) != "usr/share/pacman/keyrings" {
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(tr, 64<<20))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, path.Base(hdr.Name)), data, 0644); err != nil {
+			return err
+		}
+		found = true
+	}
+
+	if !found {
+		return fmt.Errorf("no keyring files in %s", filepath.Base(archive))
+	}
+	return nil
+}
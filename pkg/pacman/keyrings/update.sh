@@ -0,0 +1,33 @@
+#!/bin/sh
+# Fetches the <name>-trusted master key lists into this directory. Run
+# through `go generate ./pkg/pacman` with network access, check the
+# fingerprints against the published master keys and commit the result.
+set -eu
+
+dir=$(dirname "$0")
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+
+# save checks a fetched list is in FINGERPRINT:LEVEL: format and installs it
+save() {
+	name=$1
+	if ! grep -Eq '^[0-9A-F]{40}:[0-9]+:$' "$tmp/$name-trusted"; then
+		echo "$name-trusted: no FINGERPRINT:LEVEL: lines" >&2
+		exit 1
+	fi
+	mv "$tmp/$name-trusted" "$dir/$name-trusted"
+	echo "$name-trusted"
+}
+
+# Arch Linux builds the list into its keyring package
+curl -fsSL -o "$tmp/archlinux-keyring.pkg.tar.zst" https://archlinux.org/packages/core/any/archlinux-keyring/download/
+zstd -dc "$tmp/archlinux-keyring.pkg.tar.zst" | tar -xOf - usr/share/pacman/keyrings/archlinux-trusted >"$tmp/archlinux-trusted"
+save archlinux
+
+# Arch Linux ARM keeps it in its keyring repository
+curl -fsSL -o "$tmp/archlinuxarm-trusted" https://raw.githubusercontent.com/archlinuxarm/archlinuxarm-keyring/master/archlinuxarm-trusted
+save archlinuxarm
+
+# So does MSYS2
+curl -fsSL -o "$tmp/msys2-trusted" https://raw.githubusercontent.com/msys2/MSYS2-keyring/master/msys2-trusted
+save msys2
@@ -3,6 +3,7 @@ package pacman
 
 import (
 	"archive/tar"
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
@@ -22,11 +23,40 @@ func NewPackageManager(cfg *Config) *PackageManager {
 		cfg = &Config{}
 	}
 
+	if cfg.Architecture == "" {
+		detected, err := DetectArchitecture()
+		if err != nil {
+			detected = DefaultArch
+		}
+		cfg.Architecture = detected
+	}
+	cfg.Architecture = NormalizeArch(cfg.Architecture)
 	if cfg.MirrorURL == "" {
 		cfg.MirrorURL = DefaultMirror
+		if IsARM(cfg.Architecture) {
+			cfg.MirrorURL = DefaultARMMirror
+		}
 	}
 	if len(cfg.Repos) == 0 {
 		cfg.Repos = DefaultRepos
+		if IsARM(cfg.Architecture) {
+			cfg.Repos = DefaultARMRepos
+		}
+	}
+	if cfg.Multilib && !containsString(cfg.Repos, RepoMultilib) {
+		cfg.Repos = append(append([]string(nil), cfg.Repos...), RepoMultilib)
+	}
+	if cfg.SigLevel == "" {
+		cfg.SigLevel = DefaultSigLevel
+	}
+	if cfg.KeyringName == "" {
+		cfg.KeyringName = DefaultKeyringName
+		if IsARM(cfg.Architecture) {
+			cfg.KeyringName = ARMKeyringName
+		}
+	}
+	if len(cfg.Keyrings) == 0 {
+		cfg.Keyrings = []string{filepath.Join(DefaultKeyringDir, cfg.KeyringName+".gpg")}
 	}
 	if cfg.InstallPath == "" {
 		cfg.InstallPath = DefaultInstallPath
@@ -63,8 +93,9 @@ func NewPackageManager(cfg *Config) *PackageManager {
 // Download performs the package download and installation
 func (pm *PackageManager) Download(ctx context.Context, opts *DownloadOptions) error {
 	if opts.Architecture == "" {
-		opts.Architecture = DefaultArch
+		opts.Architecture = pm.config.Architecture
 	}
+	opts.Architecture = NormalizeArch(opts.Architecture)
 
 	pm.logger.Printf("Starting operation for package: %s", opts.Package)
 
@@ -121,14 +152,10 @@ func (pm *PackageManager) installRecursive(ctx context.Context, pkgName string,
 
 	// FIX: Use the repository architecture (opts.Architecture) for the URL path,
 	// NOT the package architecture (pkg.Architecture).
-	// 'any' packages live inside the 'x86_64' directory on the mirror.
+	// 'any' packages live inside the 'x86_64' (or 'aarch64', ...) directory on the mirror.
 	repoArch := opts.Architecture
-	if repoArch == "" {
-		repoArch = "x86_64"
-	}
 
-	downloadURL := fmt.Sprintf("%s/%s/os/%s/%s", 
-		pm.config.MirrorURL, pkg.Repository, repoArch, filename)
+	downloadURL := fmt.Sprintf("%s/%s", pm.repoURL(pkg.Repository, repoArch), filename)
 	
 	destPath := filepath.Join(pm.config.CachePath, "downloads", filename)
 
@@ -140,9 +167,14 @@ func (pm *PackageManager) installRecursive(ctx context.Context, pkgName string,
 	// 4. Verify
 	if opts.VerifyHash && pkg.SHA256Sum != "" {
 		if err := pm.verifyHash(destPath, pkg.SHA256Sum); err != nil {
+			os.Remove(destPath)
 			return fmt.Errorf("hash verification failed for %s: %w", pkg.Name, err)
 		}
 	}
+	if err := pm.verifyPackage(ctx, pkg, destPath, downloadURL, repoArch); err != nil {
+		os.Remove(destPath)
+		return fmt.Errorf("signature verification failed for %s: %w", pkg.Name, err)
+	}
 
 	// 5. Extract
 	if opts.Extract {
@@ -161,7 +193,7 @@ func (pm *PackageManager) installRecursive(ctx context.Context, pkgName string,
 
 // updateDB downloads and indexes repositories
 func (pm *PackageManager) updateDB(ctx context.Context, arch string) error {
-	if len(pm.cache.packages) > 0 && time.Since(pm.cache.lastUpdate) < pm.cache.cacheDuration {
+	if len(pm.cache.packages) > 0 && pm.cache.arch == arch && time.Since(pm.cache.lastUpdate) < pm.cache.cacheDuration {
 		return nil
 	}
 
@@ -171,9 +203,16 @@ func (pm *PackageManager) updateDB(ctx context.Context, arch string) error {
 	pm.cache.packages = make(map[string]*PackageInfo)
 	pm.cache.providers = make(map[string][]*PackageInfo)
 
+	var lastErr error
 	for _, repo := range pm.config.Repos {
-		// DB URL: https://mirror/repo/os/arch/repo.db
-		url := fmt.Sprintf("%s/%s/os/%s/%s.db", pm.config.MirrorURL, repo, arch, repo)
+		// Multilib only exists for x86_64
+		if repo == RepoMultilib && arch != ArchX86_64 {
+			pm.logger.Printf("  Skipping %s: not available for %s", repo, arch)
+			continue
+		}
+
+		// DB URL: https://mirror/repo/os/arch/repo.db (Arch Linux ARM: http://mirror/arch/repo/repo.db)
+		url := fmt.Sprintf("%s/%s.db", pm.repoURL(repo, arch), repo)
 		
 		pm.logger.Printf("  Fetching %s.db", repo)
 		body, err := pm.client.Get(ctx, url)
@@ -181,9 +220,22 @@ func (pm *PackageManager) updateDB(ctx context.Context, arch string) error {
 			pm.logger.Printf("    ⚠️ Failed to fetch %s: %v", repo, err)
 			continue
 		}
-
-		pkgs, err := ParseDatabase(body, repo)
+		data, err := io.ReadAll(body)
 		body.Close()
+		if err != nil {
+			pm.logger.Printf("    ⚠️ Failed to fetch %s: %v", repo, err)
+			continue
+		}
+
+		// An unverifiable database is skipped: its checksums and %PGPSIG%
+		// entries are what packages are checked against
+		if err := pm.verifyDatabase(ctx, repo, url, arch, data); err != nil {
+			pm.logger.Printf("    ⚠️ Skipping %s: %v", repo, err)
+			lastErr = err
+			continue
+		}
+
+		pkgs, err := ParseDatabase(bytes.NewReader(data), repo)
 		if err != nil {
 			pm.logger.Printf("    ⚠️ Failed to parse %s: %v", repo, err)
 			continue
@@ -206,6 +258,11 @@ func (pm *PackageManager) updateDB(ctx context.Context, arch string) error {
 		pm.logger.Printf("    Indexed %d packages from %s", len(pkgs), repo)
 	}
 
+	if len(pm.cache.packages) == 0 && lastErr != nil {
+		return fmt.Errorf("no usable database: %w", lastErr)
+	}
+
+	pm.cache.arch = arch
 	pm.cache.lastUpdate = time.Now()
 	return nil
 }
@@ -221,17 +278,52 @@ func (pm *PackageManager) resolvePackage(name string) (*PackageInfo, error) {
 
 	// 2. Check providers
 	if providers, ok := pm.cache.providers[cleanName]; ok && len(providers) > 0 {
-		// Heuristic: Prefer core over extra, but here we just take the first one
-		return providers[0], nil
+		return pickProvider(name, providers), nil
 	}
 
 	return nil, fmt.Errorf("package %s not found", name)
 }
 
+// pickProvider chooses among packages providing a dependency. With multilib
+// enabled both "libfoo.so=4-64" and "libfoo.so=4-32" clean down to the same
+// name, so an exact versioned provide wins, and lib32- packages only satisfy
+// requests that ask for them.
+func pickProvider(dep string, providers []*PackageInfo) *PackageInfo {
+	for _, p := range providers {
+		if containsString(p.Provides, dep) {
+			return p
+		}
+	}
+
+	wantLib32 := strings.HasPrefix(dep, "lib32-")
+	for _, p := range providers {
+		if strings.HasPrefix(p.Name, "lib32-") == wantLib32 {
+			return p
+		}
+	}
+
+	return providers[0]
+}
+
 func (pm *PackageManager) findPackage(name, version string) (*PackageInfo, error) {
 	return pm.resolvePackage(name)
 }
 
+// repoURL returns a repository's directory on the mirror
+func (pm *PackageManager) repoURL(repo, arch string) string {
+	return strings.NewReplacer("$repo", repo, "$arch", arch).Replace(MirrorTemplate(pm.config.MirrorURL, arch))
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 // Helper to remove version constraints (e.g., "glibc>=2.35" -> "glibc")
 func cleanDepName(dep string) string {
 	if idx := strings.IndexAny(dep, "><="); idx != -1 {
@@ -329,14 +421,14 @@ func (pm *PackageManager) extractZstdPackage(src, dest string) error {
 }
 
 func (pm *PackageManager) GetPackageInfo(ctx context.Context, name string) (*PackageInfo, error) {
-	if err := pm.updateDB(ctx, DefaultArch); err != nil {
+	if err := pm.updateDB(ctx, pm.config.Architecture); err != nil {
 		return nil, err
 	}
 	return pm.findPackage(name, "")
 }
 
 func (pm *PackageManager) SearchPackages(ctx context.Context, query string) ([]*PackageInfo, error) {
-	if err := pm.updateDB(ctx, DefaultArch); err != nil {
+	if err := pm.updateDB(ctx, pm.config.Architecture); err != nil {
 		return nil, err
 	}
 	var results []*PackageInfo
@@ -3,24 +3,45 @@ package pacman
 import (
 	"archive/tar"
 	"bufio"
+	"bytes"
 	"compress/gzip"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
+
+	"github.com/klauspost/compress/zstd"
 )
 
-// ParseDatabase parses a Pacman sync database (.db file, which is a tar.gz)
+// zstdMagic starts every zstd frame
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// ParseDatabase parses a Pacman sync database (.db file, a tar.gz; MSYS2
+// and some custom repositories compress it with zstd instead)
 func ParseDatabase(r io.Reader, repoName string) ([]*PackageInfo, error) {
-	// 1. Decompress Gzip
-	gzReader, err := gzip.NewReader(r)
-	if err != nil {
-		return nil, fmt.Errorf("creating gzip reader: %w", err)
+	// 1. Decompress Gzip or Zstd, by magic
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+
+	var dbReader io.Reader
+	if bytes.Equal(magic, zstdMagic) {
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("creating zstd reader: %w", err)
+		}
+		defer zr.Close()
+		dbReader = zr
+	} else {
+		gzReader, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("creating gzip reader: %w", err)
+		}
+		defer gzReader.Close()
+		dbReader = gzReader
 	}
-	defer gzReader.Close()
 
 	// 2. Read Tar
-	tarReader := tar.NewReader(gzReader)
+	tarReader := tar.NewReader(dbReader)
 	var packages []*PackageInfo
 
 	for {
@@ -104,6 +125,8 @@ func parseDescFile(r io.Reader) (*PackageInfo, error) {
 			pkg.MD5Sum = line
 		case "%SHA256SUM%":
 			pkg.SHA256Sum = line
+		case "%PGPSIG%":
+			pkg.PGPSignature = line
 		case "%FILENAME%":
 			pkg.Filename = line
 		case "%LICENSE%":
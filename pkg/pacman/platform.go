@@ -3,6 +3,7 @@ package pacman
 import (
 	"fmt"
 	"runtime"
+	"strings"
 )
 
 // DetectArchitecture checks the system architecture
@@ -18,10 +19,52 @@ func DetectArchitecture() (string, error) {
 
 	switch goarch {
 	case "amd64":
-		return "x86_64", nil
+		return ArchX86_64, nil
 	case "arm64":
-		return "aarch64", nil // Arch Linux ARM uses aarch64
+		return ArchAarch64, nil // Arch Linux ARM uses aarch64
+	case "arm":
+		return ArchArmv7h, nil
 	default:
 		return "", fmt.Errorf("unsupported architecture for pacman: %s", goarch)
 	}
-}
\ No newline at end of file
+}
+
+// NormalizeArch maps Go architecture names (amd64, arm64, arm) to the
+// pacman ones and passes pacman names through
+func NormalizeArch(arch string) string {
+	switch arch {
+	case "amd64":
+		return ArchX86_64
+	case "arm64":
+		return ArchAarch64
+	case "arm":
+		return ArchArmv7h
+	}
+	return arch
+}
+
+// IsARM reports whether an architecture is served by Arch Linux ARM
+func IsARM(arch string) bool {
+	return arch == ArchAarch64 || strings.HasPrefix(arch, "armv")
+}
+
+// MirrorTemplate returns a mirror URL with $repo and $arch placeholders.
+// URLs that already contain $repo are used as given; bare base URLs get
+// the Arch Linux or Arch Linux ARM layout for the architecture.
+func MirrorTemplate(mirror, arch string) string {
+	if mirror == "" {
+		mirror = DefaultMirror
+		if IsARM(arch) {
+			mirror = DefaultARMMirror
+		}
+	}
+	if strings.Contains(mirror, "$repo") {
+		return mirror
+	}
+
+	layout := ArchLayout
+	if IsARM(arch) {
+		layout = ARMLayout
+	}
+	return strings.TrimSuffix(mirror, "/") + "/" + layout
+}
@@ -0,0 +1,110 @@
+// pkg/pacman/siglevel.go
+package pacman
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SigCheck is how strictly one kind of signature is checked
+type SigCheck int
+
+const (
+	SigRequired SigCheck = iota // A valid signature must be present
+	SigOptional                 // Checked when present; a bad signature still fails
+	SigNever                    // Not checked
+)
+
+// SigTrust is which keys of the keyring a signature may come from
+type SigTrust int
+
+const (
+	TrustedOnly SigTrust = iota // Only keys the keyring's trusted list vouches for
+	TrustAll                    // Any key in the keyring
+)
+
+// SigLevel is a trust policy like pacman.conf's SigLevel, with separate
+// checks for packages and databases
+type SigLevel struct {
+	Package       SigCheck
+	Database      SigCheck
+	PackageTrust  SigTrust
+	DatabaseTrust SigTrust
+}
+
+// DefaultSigLevel is pacman's default, "Required DatabaseOptional"
+const DefaultSigLevel = "Required DatabaseOptional"
+
+// ParseSigLevel parses a pacman SigLevel value such as "Required
+// DatabaseOptional" or "PackageRequired DatabaseNever". Options apply in
+// order, so later ones override earlier ones. TrustedOnly (the default)
+// accepts signatures from keys the keyring's trusted list vouches for only,
+// TrustAll from any key in the keyring.
+func ParseSigLevel(s string) (SigLevel, error) {
+	level := SigLevel{Package: SigRequired, Database: SigOptional}
+
+	for _, option := range strings.Fields(s) {
+		target := "Both"
+		for _, prefix := range []string{"Package", "Database"} {
+			if strings.HasPrefix(option, prefix) {
+				target = prefix
+				option = strings.TrimPrefix(option, prefix)
+				break
+			}
+		}
+
+		var check SigCheck
+		switch option {
+		case "Required":
+			check = SigRequired
+		case "Optional":
+			check = SigOptional
+		case "Never":
+			check = SigNever
+		case "TrustedOnly", "TrustAll":
+			trust := TrustedOnly
+			if option == "TrustAll" {
+				trust = TrustAll
+			}
+			if target != "Database" {
+				level.PackageTrust = trust
+			}
+			if target != "Package" {
+				level.DatabaseTrust = trust
+			}
+			continue
+		default:
+			return level, fmt.Errorf("invalid SigLevel option %q", option)
+		}
+
+		if target != "Database" {
+			level.Package = check
+		}
+		if target != "Package" {
+			level.Database = check
+		}
+	}
+
+	return level, nil
+}
+
+// sigLevel returns the trust policy of a repository: its entry in
+// Config.RepoSigLevels, else Config.SigLevel
+func (pm *PackageManager) sigLevel(repo string) SigLevel {
+	if pm.config.NoGPGCheck {
+		return SigLevel{Package: SigNever, Database: SigNever}
+	}
+
+	value := pm.config.SigLevel
+	if repoValue, ok := pm.config.RepoSigLevels[repo]; ok {
+		value = repoValue
+	}
+
+	level, err := ParseSigLevel(value)
+	if err != nil {
+		// Fall back to the strictest policy rather than silently trusting
+		pm.logger.Printf("  ⚠️ %s: %v, requiring signatures", repo, err)
+		return SigLevel{Package: SigRequired, Database: SigRequired}
+	}
+	return level
+}
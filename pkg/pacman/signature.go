@@ -0,0 +1,101 @@
+// pkg/pacman/signature.go
+package pacman
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+)
+
+// verifyDatabase checks a sync database against its detached .db.sig,
+// according to the repository's SigLevel
+func (pm *PackageManager) verifyDatabase(ctx context.Context, repo, url, arch string, data []byte) error {
+	check := pm.sigLevel(repo).Database
+	if check == SigNever {
+		return nil
+	}
+
+	sig, err := pm.fetchSignature(ctx, url+".sig")
+	if err != nil {
+		if check == SigOptional {
+			if pm.config.Debug {
+				pm.logger.Printf("    %s.db is not signed", repo)
+			}
+			return nil
+		}
+		return fmt.Errorf("%s.db: missing signature: %w", repo, err)
+	}
+
+	keyring, err := pm.keyring(ctx, arch)
+	if err != nil {
+		return err
+	}
+	signer, err := keyring.Verify(bytes.NewReader(data), sig, pm.sigLevel(repo).DatabaseTrust)
+	if err != nil {
+		return fmt.Errorf("%s.db: %w", repo, err)
+	}
+
+	if pm.config.Debug {
+		pm.logger.Printf("    %s.db signed by %s", repo, signer)
+	}
+	return nil
+}
+
+// verifyPackage checks a downloaded package against its signature: the
+// %PGPSIG% from the database when present, otherwise the .sig file next to
+// the package on the mirror
+func (pm *PackageManager) verifyPackage(ctx context.Context, pkg *PackageInfo, pkgPath, url, arch string) error {
+	check := pm.sigLevel(pkg.Repository).Package
+	if check == SigNever {
+		return nil
+	}
+
+	var sig []byte
+	var err error
+	if pkg.PGPSignature != "" {
+		sig, err = base64.StdEncoding.DecodeString(pkg.PGPSignature)
+		if err != nil {
+			return fmt.Errorf("decoding %%PGPSIG%%: %w", err)
+		}
+	} else if sig, err = pm.fetchSignature(ctx, url+".sig"); err != nil {
+		if check == SigOptional {
+			return nil
+		}
+		return fmt.Errorf("missing signature: %w", err)
+	}
+
+	keyring, err := pm.keyring(ctx, arch)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(pkgPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	signer, err := keyring.Verify(f, sig, pm.sigLevel(pkg.Repository).PackageTrust)
+	if err != nil {
+		return err
+	}
+
+	if pm.config.Debug {
+		pm.logger.Printf("  Signature OK: %s (%s)", pkg.Name, signer)
+	}
+	return nil
+}
+
+// fetchSignature downloads a detached signature
+func (pm *PackageManager) fetchSignature(ctx context.Context, url string) ([]byte, error) {
+	body, err := pm.client.Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return io.ReadAll(io.LimitReader(body, 64*1024))
+}
@@ -0,0 +1,140 @@
+// pkg/pacman/trust.go
+package pacman
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+//go:generate sh keyrings/update.sh
+
+// trustedLists holds the master key lists of the keyrings upkg bootstraps
+//
+//go:embed keyrings
+var trustedLists embed.FS
+
+// Owner trust levels of a -trusted list, as gpg --import-ownertrust reads them
+const (
+	trustMarginal = 4
+	trustFull     = 5
+)
+
+// certificationsNeeded is how much owner trust has to certify a key for it
+// to be valid, gpg's defaults: one fully trusted or three marginally
+// trusted certifications
+const certificationsNeeded = 3
+
+// embeddedTrustedList returns the embedded <name>-trusted list
+func embeddedTrustedList(name string) ([]byte, bool) {
+	data, err := trustedLists.ReadFile(path.Join("keyrings", name+"-trusted"))
+	return data, err == nil
+}
+
+// parseTrustedList parses a -trusted list ("FINGERPRINT:LEVEL:" lines) into
+// owner trust levels by upper-case fingerprint
+func parseTrustedList(list []byte) map[string]int {
+	levels := make(map[string]int)
+	scanner := bufio.NewScanner(bytes.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ":")
+		if len(fields) < 2 {
+			continue
+		}
+		level, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		levels[strings.ToUpper(fields[0])] = level
+	}
+	return levels
+}
+
+// vouched returns the keys of a keyring that its trusted list vouches for,
+// as pacman-key --populate sets them up: the listed keys themselves, and
+// every other key whose user IDs they certify with enough owner trust (one
+// fully or three marginally trusted certifications). It fails when the
+// keyring holds none of the listed keys.
+func vouched(entities openpgp.EntityList, list []byte) (openpgp.EntityList, error) {
+	levels := parseTrustedList(list)
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("trusted key list is empty")
+	}
+
+	introducers := make(map[uint64]*openpgp.Entity)
+	var trusted openpgp.EntityList
+	for _, entity := range entities {
+		if _, ok := levels[fingerprint(entity)]; ok {
+			introducers[entity.PrimaryKey.KeyId] = entity
+			trusted = append(trusted, entity)
+		}
+	}
+	if len(trusted) == 0 {
+		return nil, fmt.Errorf("keyring holds none of the %d trusted master keys", len(levels))
+	}
+
+	for _, entity := range entities {
+		if _, ok := levels[fingerprint(entity)]; ok {
+			continue
+		}
+
+		score := 0
+		for _, introducer := range certifiers(entity, introducers) {
+			if levels[fingerprint(introducer)] >= trustFull {
+				score += certificationsNeeded
+			} else if levels[fingerprint(introducer)] == trustMarginal {
+				score++
+			}
+		}
+		if score >= certificationsNeeded {
+			trusted = append(trusted, entity)
+		}
+	}
+	return trusted, nil
+}
+
+// certifiers returns the introducers with a valid certification of one of
+// the entity's user IDs
+func certifiers(entity *openpgp.Entity, introducers map[uint64]*openpgp.Entity) []*openpgp.Entity {
+	seen := make(map[uint64]bool)
+	var found []*openpgp.Entity
+	for _, identity := range entity.Identities {
+		for _, sig := range identity.Signatures {
+			if sig.IssuerKeyId == nil || seen[*sig.IssuerKeyId] {
+				continue
+			}
+			switch sig.SigType {
+			case packet.SigTypeGenericCert, packet.SigTypePersonaCert, packet.SigTypeCasualCert, packet.SigTypePositiveCert:
+			default:
+				continue
+			}
+			introducer, ok := introducers[*sig.IssuerKeyId]
+			if !ok || introducer == entity {
+				continue
+			}
+			if introducer.PrimaryKey.VerifyUserIdSignature(identity.Name, entity.PrimaryKey, sig) != nil {
+				continue
+			}
+			seen[*sig.IssuerKeyId] = true
+			found = append(found, introducer)
+		}
+	}
+	return found
+}
+
+// fingerprint returns an entity's primary key fingerprint in upper-case hex
+func fingerprint(entity *openpgp.Entity) string {
+	return strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint))
+}
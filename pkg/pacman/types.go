@@ -8,13 +8,22 @@ import (
 
 // Config configures the Pacman package manager
 type Config struct {
-	MirrorURL   string        // Mirror URL (e.g., https://geo.mirror.pkgbuild.com)
-	Repos       []string      // List of repositories to sync (core, extra)
-	InstallPath string        // Where to install packages
-	CachePath   string        // Where to cache downloaded files
-	Timeout     time.Duration // Network timeout
-	Debug       bool          // Enable debug logging
-	Logger      *log.Logger   // Custom logger
+	MirrorURL     string            // Mirror URL, optionally with $repo/$arch (default: per architecture)
+	Architecture  string            // Target architecture: x86_64, aarch64, armv7h (default: detected)
+	Repos         []string          // List of repositories to sync (default: core, extra; plus alarm on ARM)
+	Multilib      bool              // Also sync multilib for 32-bit libraries (x86_64 only)
+	SigLevel      string            // Trust policy as in pacman.conf (default: DefaultSigLevel)
+	RepoSigLevels map[string]string // Per-repository SigLevel overrides (key: repository name)
+	Keyrings      []string          // Keyring files (default: /usr/share/pacman/keyrings/<KeyringName>.gpg)
+	KeyringName   string            // Distribution keyring, bootstrapped when none is installed (default: archlinux or archlinuxarm)
+	KeyringDB     string            // Database URL holding the <KeyringName>-keyring package (default: the core repository)
+	GPGKeys       []string          // Extra trusted keys (paths or URLs)
+	NoGPGCheck    bool              // Skip all signature checks, like SigLevel = Never
+	InstallPath   string            // Where to install packages
+	CachePath     string            // Where to cache downloaded files
+	Timeout       time.Duration     // Network timeout
+	Debug         bool              // Enable debug logging
+	Logger        *log.Logger       // Custom logger
 }
 
 // PackageManager handles Pacman package operations
@@ -23,6 +32,7 @@ type PackageManager struct {
 	config *Config
 	logger *log.Logger
 	cache  *PackageCache
+	keys   *Keyring // Trusted keys, loaded on first use
 }
 
 // PackageInfo contains metadata from the 'desc' file in the sync db
@@ -41,7 +51,7 @@ type PackageInfo struct {
 	Filename       string // The .pkg.tar.zst filename
 	MD5Sum         string
 	SHA256Sum      string
-	PGPSignature   string
+	PGPSignature   string // Base64 detached signature (PGPSIG)
 	License        []string
 	Replaces       []string
 	Groups         []string
@@ -58,7 +68,7 @@ type PackageInfo struct {
 type DownloadOptions struct {
 	Package      string // Required
 	Version      string // Optional
-	Architecture string // Optional (defaults to x86_64)
+	Architecture string // Optional (defaults to Config.Architecture)
 	Extract      bool
 	KeepArchive  bool
 	VerifyHash   bool
@@ -68,6 +78,7 @@ type DownloadOptions struct {
 type PackageCache struct {
 	packages      map[string]*PackageInfo   // key: package_name
 	providers     map[string][]*PackageInfo // key: virtual_name -> list of providers
+	arch          string                    // Architecture the index was built for
 	lastUpdate    time.Time
 	cacheDuration time.Duration
 }
\ No newline at end of file
@@ -0,0 +1,655 @@
+// pkg/patchelf/elf.go
+package patchelf
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotELF is returned by Open for files that are not ELF objects
+var ErrNotELF = errors.New("not an ELF file")
+
+// File is an ELF executable or shared library opened for patching. Changes
+// are staged with SetRunPath and SetInterpreter and written by Save.
+type File struct {
+	path  string
+	mode  os.FileMode
+	data  []byte
+	class elf.Class
+	order binary.ByteOrder
+
+	phoff     uint64
+	progs     []prog
+	shoff     uint64
+	shentsize uint64
+	sections  map[string]int // Section name -> section header index
+	dyns      []dyn          // Entries of PT_DYNAMIC, including trailing DT_NULLs
+
+	runPath    []string
+	setRunPath bool
+	interp     string
+	setInterp  bool
+}
+
+// prog is a program header
+type prog struct {
+	typ    elf.ProgType
+	flags  elf.ProgFlag
+	off    uint64
+	vaddr  uint64
+	paddr  uint64
+	filesz uint64
+	memsz  uint64
+	align  uint64
+}
+
+// dyn is a dynamic section entry
+type dyn struct {
+	tag elf.DynTag
+	val uint64
+}
+
+// Open reads an ELF file into memory
+func Open(path string) (*File, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	f.path = path
+	f.mode = info.Mode().Perm()
+	return f, nil
+}
+
+// parse decodes the headers and dynamic section of an in-memory ELF file
+func parse(data []byte) (*File, error) {
+	if !bytes.HasPrefix(data, []byte(elf.ELFMAG)) {
+		return nil, ErrNotELF
+	}
+	ef, err := elf.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotELF, err)
+	}
+	defer ef.Close()
+
+	f := &File{
+		data:     data,
+		class:    ef.Class,
+		order:    ef.ByteOrder,
+		sections: make(map[string]int),
+	}
+
+	if f.is64() {
+		f.phoff = f.order.Uint64(data[32:])
+		f.shoff = f.order.Uint64(data[40:])
+		f.shentsize = uint64(f.order.Uint16(data[58:]))
+	} else {
+		f.phoff = uint64(f.order.Uint32(data[28:]))
+		f.shoff = uint64(f.order.Uint32(data[32:]))
+		f.shentsize = uint64(f.order.Uint16(data[46:]))
+	}
+
+	for _, p := range ef.Progs {
+		f.progs = append(f.progs, prog{
+			typ:    p.Type,
+			flags:  p.Flags,
+			off:    p.Off,
+			vaddr:  p.Vaddr,
+			paddr:  p.Paddr,
+			filesz: p.Filesz,
+			memsz:  p.Memsz,
+			align:  p.Align,
+		})
+	}
+	for i, s := range ef.Sections {
+		if _, ok := f.sections[s.Name]; !ok && s.Name != "" {
+			f.sections[s.Name] = i
+		}
+	}
+
+	if p := f.findProg(elf.PT_DYNAMIC); p != nil {
+		size := f.dynSize()
+		end := p.off + p.filesz
+		if end > uint64(len(data)) {
+			return nil, fmt.Errorf("PT_DYNAMIC extends past end of file")
+		}
+		for off := p.off; off+size <= end; off += size {
+			f.dyns = append(f.dyns, f.readDyn(data[off:]))
+		}
+	}
+
+	return f, nil
+}
+
+func (f *File) is64() bool {
+	return f.class == elf.ELFCLASS64
+}
+
+// findProg returns the first program header of a type
+func (f *File) findProg(typ elf.ProgType) *prog {
+	for i := range f.progs {
+		if f.progs[i].typ == typ {
+			return &f.progs[i]
+		}
+	}
+	return nil
+}
+
+// IsDynamic reports whether the file is dynamically linked
+func (f *File) IsDynamic() bool {
+	return len(f.dyns) > 0
+}
+
+// Interpreter returns PT_INTERP, the dynamic loader of an executable, or ""
+func (f *File) Interpreter() string {
+	p := f.findProg(elf.PT_INTERP)
+	if p == nil {
+		return ""
+	}
+	return f.cstring(p.off, p.filesz)
+}
+
+// RunPath returns the library search path, from DT_RUNPATH or else DT_RPATH
+func (f *File) RunPath() []string {
+	i := f.runPathIndex()
+	if i < 0 {
+		return nil
+	}
+	s := f.dynString(f.dyns[i].val)
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ":")
+}
+
+// Needed returns the DT_NEEDED shared libraries
+func (f *File) Needed() []string {
+	var needed []string
+	for _, d := range f.dyns {
+		if d.tag == elf.DT_NEEDED {
+			needed = append(needed, f.dynString(d.val))
+		}
+	}
+	return needed
+}
+
+// SetRunPath stages a new DT_RUNPATH. A DT_RPATH is turned into DT_RUNPATH.
+func (f *File) SetRunPath(paths []string) {
+	f.runPath = paths
+	f.setRunPath = true
+}
+
+// SetInterpreter stages a new PT_INTERP; only executables have one
+func (f *File) SetInterpreter(path string) {
+	f.interp = path
+	f.setInterp = true
+}
+
+// Save applies the staged changes and replaces the file on disk, keeping
+// its permissions. The new contents are written next to it and renamed
+// into place, so running copies of the binary are not disturbed.
+func (f *File) Save() error {
+	if err := f.patch(); err != nil {
+		return fmt.Errorf("%s: %w", f.path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), "."+filepath.Base(f.path)+".patchelf-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(f.data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(f.mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// Bytes applies the staged changes and returns the patched file contents
+func (f *File) Bytes() ([]byte, error) {
+	if err := f.patch(); err != nil {
+		return nil, err
+	}
+	return f.data, nil
+}
+
+// patch applies the staged changes to f.data. Strings that fit where the
+// old ones were are overwritten in place. Otherwise the string table, the
+// interpreter and, if an entry has to be added, the dynamic section are
+// copied into a new PT_LOAD segment appended to the file, as patchelf does.
+// The program header for it takes the place of a PT_NULL entry; without one
+// the header table is moved into the new segment and grows by an entry.
+func (f *File) patch() error {
+	var seg segment
+	dynDirty := false
+
+	if f.setRunPath && f.IsDynamic() {
+		value := strings.Join(f.runPath, ":")
+		i := f.runPathIndex()
+
+		if i >= 0 && f.dynString(f.dyns[i].val) == value && f.dyns[i].tag == elf.DT_RUNPATH {
+			// Unchanged
+		} else if i >= 0 && len(value) <= len(f.dynString(f.dyns[i].val)) {
+			off, ok := f.dynStrOffset(f.dyns[i].val)
+			if !ok {
+				return fmt.Errorf("DT_STRTAB is not mapped by any PT_LOAD")
+			}
+			old := len(f.dynString(f.dyns[i].val))
+			copy(f.data[off:], value)
+			clear(f.data[off+uint64(len(value)) : off+uint64(old)])
+			f.dyns[i].tag = elf.DT_RUNPATH
+			dynDirty = true
+		} else {
+			strtab, strsz, ok := f.strtab()
+			if !ok {
+				return fmt.Errorf("DT_STRTAB is not mapped by any PT_LOAD")
+			}
+			if i >= 0 {
+				// Blank the old string so no stale path is left behind
+				off, _ := f.dynStrOffset(f.dyns[i].val)
+				clear(f.data[off : off+uint64(len(f.dynString(f.dyns[i].val)))])
+			}
+			seg.dynstr = append(append([]byte{}, f.data[strtab:strtab+strsz]...), value...)
+			seg.dynstr = append(seg.dynstr, 0)
+
+			entry := dyn{tag: elf.DT_RUNPATH, val: strsz}
+			if i >= 0 {
+				f.dyns[i] = entry
+			} else if j := f.spareNull(); j >= 0 {
+				f.dyns[j] = entry
+			} else {
+				// No room: grow the dynamic section and move it too
+				first := f.firstNull()
+				f.dyns = append(f.dyns[:first], append([]dyn{entry}, f.dyns[first:]...)...)
+				seg.dynamic = true
+			}
+			dynDirty = true
+		}
+	}
+
+	if f.setInterp {
+		p := f.findProg(elf.PT_INTERP)
+		if p == nil {
+			return fmt.Errorf("no PT_INTERP to set (static executable or shared library)")
+		}
+		if f.Interpreter() != f.interp {
+			if uint64(len(f.interp))+1 <= p.filesz {
+				copy(f.data[p.off:], f.interp)
+				clear(f.data[p.off+uint64(len(f.interp)) : p.off+p.filesz])
+			} else {
+				clear(f.data[p.off : p.off+p.filesz])
+				seg.interp = append([]byte(f.interp), 0)
+			}
+		}
+	}
+
+	if seg.empty() {
+		if dynDirty {
+			f.writeDyns(f.findProg(elf.PT_DYNAMIC).off)
+		}
+		return nil
+	}
+	return f.appendSegment(&seg, dynDirty)
+}
+
+// segment collects the contents of a new PT_LOAD segment
+type segment struct {
+	dynstr  []byte // New string table (old one plus appended strings)
+	interp  []byte // New interpreter path, NUL-terminated
+	dynamic bool   // Whether the dynamic section moves as well
+}
+
+func (s *segment) empty() bool {
+	return s.dynstr == nil && s.interp == nil && !s.dynamic
+}
+
+// appendSegment lays out seg at the end of the file, maps it with a new
+// PT_LOAD and points the dynamic entries, program and section headers at it
+func (f *File) appendSegment(seg *segment, dynDirty bool) error {
+	slot := -1
+	for i, p := range f.progs {
+		if p.typ == elf.PT_NULL {
+			slot = i
+			break
+		}
+	}
+	movePhdrs := slot < 0
+
+	// The segment starts on a fresh page above every existing one, with a
+	// file offset congruent to its address as the loaders require
+	align, end := uint64(0x1000), uint64(0)
+	var first *prog
+	for i, p := range f.progs {
+		if p.typ != elf.PT_LOAD {
+			continue
+		}
+		if first == nil {
+			first = &f.progs[i]
+		}
+		align = max(align, p.align)
+		end = max(end, p.vaddr+p.memsz)
+	}
+	if first == nil {
+		return fmt.Errorf("no PT_LOAD segment")
+	}
+	off := alignUp(uint64(len(f.data)), 8)
+	vaddr := alignUp(end, align) + off%align
+
+	if movePhdrs {
+		// Kernels before 5.18 derive AT_PHDR from e_phoff through the first
+		// PT_LOAD, so the moved table has to sit at the same distance
+		// between file offset and address
+		if first.vaddr < first.off {
+			return fmt.Errorf("no PT_NULL program header to reuse and the header table cannot be moved")
+		}
+		base := first.vaddr - first.off
+		off = max(off, alignUp(end, align)-base)
+		vaddr = off + base
+	}
+
+	var blob []byte
+	place := func(b []byte) (uint64, uint64) {
+		blob = append(blob, make([]byte, alignUp(uint64(len(blob)), 8)-uint64(len(blob)))...)
+		pos := uint64(len(blob))
+		blob = append(blob, b...)
+		return off + pos, vaddr + pos
+	}
+
+	if movePhdrs {
+		size := uint64(len(f.progs)+1) * f.phentSize()
+		phOff, phAddr := place(make([]byte, size))
+		f.phoff = phOff
+		if p := f.findProg(elf.PT_PHDR); p != nil {
+			p.off, p.vaddr, p.paddr = phOff, phAddr, phAddr
+			p.filesz, p.memsz = size, size
+		}
+	}
+
+	if seg.dynstr != nil {
+		strOff, strAddr := place(seg.dynstr)
+		f.setDyn(elf.DT_STRTAB, strAddr)
+		f.setDyn(elf.DT_STRSZ, uint64(len(seg.dynstr)))
+		f.setSection(".dynstr", strOff, strAddr, uint64(len(seg.dynstr)))
+	}
+
+	if seg.interp != nil {
+		interpOff, interpAddr := place(seg.interp)
+		p := f.findProg(elf.PT_INTERP)
+		p.off, p.vaddr, p.paddr = interpOff, interpAddr, interpAddr
+		p.filesz, p.memsz = uint64(len(seg.interp)), uint64(len(seg.interp))
+		f.setSection(".interp", interpOff, interpAddr, uint64(len(seg.interp)))
+	}
+
+	flags := elf.PF_R
+	if seg.dynamic {
+		size := uint64(len(f.dyns)) * f.dynSize()
+		dynOff, dynAddr := place(make([]byte, size))
+		p := f.findProg(elf.PT_DYNAMIC)
+		p.off, p.vaddr, p.paddr = dynOff, dynAddr, dynAddr
+		p.filesz, p.memsz = size, size
+		f.setSection(".dynamic", dynOff, dynAddr, size)
+		flags |= elf.PF_W // The loader writes DT_DEBUG
+	}
+
+	f.data = append(f.data, make([]byte, off-uint64(len(f.data)))...)
+	f.data = append(f.data, blob...)
+	if seg.dynamic || dynDirty {
+		f.writeDyns(f.findProg(elf.PT_DYNAMIC).off)
+	}
+
+	// Keep PT_LOAD entries sorted by address: drop the reused slot, if
+	// any, and insert the new segment after the last PT_LOAD
+	load := prog{
+		typ:    elf.PT_LOAD,
+		flags:  flags,
+		off:    off,
+		vaddr:  vaddr,
+		paddr:  vaddr,
+		filesz: uint64(len(blob)),
+		memsz:  uint64(len(blob)),
+		align:  align,
+	}
+	progs := append([]prog{}, f.progs...)
+	if !movePhdrs {
+		progs = append(progs[:slot], progs[slot+1:]...)
+	}
+	last := -1
+	for i, p := range progs {
+		if p.typ == elf.PT_LOAD {
+			last = i
+		}
+	}
+	progs = append(progs[:last+1], append([]prog{load}, progs[last+1:]...)...)
+	f.progs = progs
+	f.writeProgs()
+	return nil
+}
+
+// runPathIndex returns the index of DT_RUNPATH, else DT_RPATH, or -1
+func (f *File) runPathIndex() int {
+	rpath := -1
+	for i, d := range f.dyns {
+		switch d.tag {
+		case elf.DT_RUNPATH:
+			return i
+		case elf.DT_RPATH:
+			if rpath < 0 {
+				rpath = i
+			}
+		}
+	}
+	return rpath
+}
+
+// firstNull returns the index of the DT_NULL terminating the dynamic section
+func (f *File) firstNull() int {
+	for i, d := range f.dyns {
+		if d.tag == elf.DT_NULL {
+			return i
+		}
+	}
+	return len(f.dyns)
+}
+
+// spareNull returns a DT_NULL that can be used for a new entry while still
+// leaving a terminator, or -1
+func (f *File) spareNull() int {
+	first := f.firstNull()
+	if first+1 < len(f.dyns) {
+		return first
+	}
+	return -1
+}
+
+// dynVal returns the value of the first dynamic entry with a tag
+func (f *File) dynVal(tag elf.DynTag) (uint64, bool) {
+	for _, d := range f.dyns {
+		if d.tag == tag {
+			return d.val, true
+		}
+	}
+	return 0, false
+}
+
+// setDyn sets the value of the first dynamic entry with a tag
+func (f *File) setDyn(tag elf.DynTag, val uint64) {
+	for i := range f.dyns {
+		if f.dyns[i].tag == tag {
+			f.dyns[i].val = val
+			return
+		}
+	}
+}
+
+// strtab returns the file offset and size of the dynamic string table
+func (f *File) strtab() (uint64, uint64, bool) {
+	addr, ok := f.dynVal(elf.DT_STRTAB)
+	if !ok {
+		return 0, 0, false
+	}
+	size, _ := f.dynVal(elf.DT_STRSZ)
+	off, ok := f.vaddrToOffset(addr)
+	if !ok || off+size > uint64(len(f.data)) {
+		return 0, 0, false
+	}
+	return off, size, true
+}
+
+// dynStrOffset returns the file offset of a string in the dynamic string table
+func (f *File) dynStrOffset(index uint64) (uint64, bool) {
+	off, size, ok := f.strtab()
+	if !ok || index >= size {
+		return 0, false
+	}
+	return off + index, true
+}
+
+// dynString reads a string from the dynamic string table
+func (f *File) dynString(index uint64) string {
+	off, size, ok := f.strtab()
+	if !ok || index >= size {
+		return ""
+	}
+	return f.cstring(off+index, size-index)
+}
+
+// cstring reads a NUL-terminated string of at most max bytes
+func (f *File) cstring(off, max uint64) string {
+	if off >= uint64(len(f.data)) {
+		return ""
+	}
+	b := f.data[off:min(off+max, uint64(len(f.data)))]
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// vaddrToOffset maps a virtual address to its file offset
+func (f *File) vaddrToOffset(addr uint64) (uint64, bool) {
+	for _, p := range f.progs {
+		if p.typ == elf.PT_LOAD && addr >= p.vaddr && addr < p.vaddr+p.filesz {
+			return p.off + addr - p.vaddr, true
+		}
+	}
+	return 0, false
+}
+
+func (f *File) dynSize() uint64 {
+	if f.is64() {
+		return 16
+	}
+	return 8
+}
+
+func (f *File) readDyn(b []byte) dyn {
+	if f.is64() {
+		return dyn{tag: elf.DynTag(int64(f.order.Uint64(b))), val: f.order.Uint64(b[8:])}
+	}
+	return dyn{tag: elf.DynTag(int32(f.order.Uint32(b))), val: uint64(f.order.Uint32(b[4:]))}
+}
+
+// writeDyns encodes the dynamic entries at off
+func (f *File) writeDyns(off uint64) {
+	size := f.dynSize()
+	for i, d := range f.dyns {
+		b := f.data[off+uint64(i)*size:]
+		if f.is64() {
+			f.order.PutUint64(b, uint64(int64(d.tag)))
+			f.order.PutUint64(b[8:], d.val)
+		} else {
+			f.order.PutUint32(b, uint32(int32(d.tag)))
+			f.order.PutUint32(b[4:], uint32(d.val))
+		}
+	}
+}
+
+func (f *File) phentSize() uint64 {
+	if f.is64() {
+		return 56
+	}
+	return 32
+}
+
+// writeProgs encodes the program header table and its position and entry
+// count in the ELF header
+func (f *File) writeProgs() {
+	if f.is64() {
+		f.order.PutUint64(f.data[32:], f.phoff)
+		f.order.PutUint16(f.data[56:], uint16(len(f.progs)))
+	} else {
+		f.order.PutUint32(f.data[28:], uint32(f.phoff))
+		f.order.PutUint16(f.data[44:], uint16(len(f.progs)))
+	}
+	for i, p := range f.progs {
+		if f.is64() {
+			b := f.data[f.phoff+uint64(i)*56:]
+			f.order.PutUint32(b, uint32(p.typ))
+			f.order.PutUint32(b[4:], uint32(p.flags))
+			f.order.PutUint64(b[8:], p.off)
+			f.order.PutUint64(b[16:], p.vaddr)
+			f.order.PutUint64(b[24:], p.paddr)
+			f.order.PutUint64(b[32:], p.filesz)
+			f.order.PutUint64(b[40:], p.memsz)
+			f.order.PutUint64(b[48:], p.align)
+		} else {
+			b := f.data[f.phoff+uint64(i)*32:]
+			f.order.PutUint32(b, uint32(p.typ))
+			f.order.PutUint32(b[4:], uint32(p.off))
+			f.order.PutUint32(b[8:], uint32(p.vaddr))
+			f.order.PutUint32(b[12:], uint32(p.paddr))
+			f.order.PutUint32(b[16:], uint32(p.filesz))
+			f.order.PutUint32(b[20:], uint32(p.memsz))
+			f.order.PutUint32(b[24:], uint32(p.flags))
+			f.order.PutUint32(b[28:], uint32(p.align))
+		}
+	}
+}
+
+// setSection points a section header at moved contents, so tools reading
+// sections (readelf, strip) agree with the program headers
+func (f *File) setSection(name string, off, addr, size uint64) {
+	i, ok := f.sections[name]
+	if !ok || f.shoff == 0 {
+		return
+	}
+	b := f.data[f.shoff+uint64(i)*f.shentsize:]
+	if f.is64() {
+		f.order.PutUint64(b[16:], addr)
+		f.order.PutUint64(b[24:], off)
+		f.order.PutUint64(b[32:], size)
+	} else {
+		f.order.PutUint32(b[12:], uint32(addr))
+		f.order.PutUint32(b[16:], uint32(off))
+		f.order.PutUint32(b[20:], uint32(size))
+	}
+}
+
+func alignUp(v, align uint64) uint64 {
+	if align == 0 {
+		return v
+	}
+	return (v + align - 1) / align * align
+}
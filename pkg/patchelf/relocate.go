@@ -0,0 +1,233 @@
+// pkg/patchelf/relocate.go
+package patchelf
+
+import (
+	"bytes"
+	"debug/elf"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Options configures Relocate
+type Options struct {
+	// Root is the directory whose ELF files are relocated
+	Root string
+
+	// PrefixMap maps absolute paths baked into binaries to the directories
+	// they were installed to (e.g. "/nix/store" -> Root). "/" maps every
+	// absolute path, which suits packages built for an FHS layout.
+	PrefixMap map[string]string
+
+	// LibraryDirs are appended to every RUNPATH and searched for the
+	// dynamic loader
+	LibraryDirs []string
+
+	// SetInterpreter also points PT_INTERP at the loader of the same name
+	// found in Root
+	SetInterpreter bool
+
+	// Skip lists directories below Root that are not walked
+	Skip []string
+}
+
+// Report describes what Relocate did
+type Report struct {
+	Scanned int              // ELF files examined
+	Patched []string         // Files rewritten
+	Failed  map[string]error // Files that could not be patched
+}
+
+// Relocate rewrites the RUNPATH of every dynamically linked ELF file below
+// opts.Root so libraries resolve inside the tree: entries are mapped through
+// PrefixMap and expressed relative to $ORIGIN, so the tree can be moved
+// without patching again. Failures on single files are collected in the
+// report rather than aborting the walk.
+func Relocate(opts *Options) (*Report, error) {
+	report := &Report{Failed: make(map[string]error)}
+
+	err := filepath.WalkDir(opts.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			for _, skip := range opts.Skip {
+				if path == filepath.Join(opts.Root, skip) {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !isELF(path) {
+			return nil
+		}
+
+		report.Scanned++
+		patched, err := RelocateFile(path, opts)
+		if err != nil {
+			report.Failed[path] = err
+		} else if patched {
+			report.Patched = append(report.Patched, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// RelocateFile relocates a single ELF file and reports whether it changed
+func RelocateFile(path string, opts *Options) (bool, error) {
+	f, err := Open(path)
+	if err != nil {
+		return false, err
+	}
+	// Without DT_NEEDED there is nothing to look up; this also keeps the
+	// dynamic loader itself untouched
+	if !f.IsDynamic() || len(f.Needed()) == 0 {
+		return false, nil
+	}
+
+	changed := false
+	runPath := relocateRunPath(f.RunPath(), filepath.Dir(path), opts)
+	if !slices.Equal(runPath, f.RunPath()) || f.hasRPath() {
+		f.SetRunPath(runPath)
+		changed = true
+	}
+
+	if opts.SetInterpreter {
+		if interp := f.Interpreter(); interp != "" {
+			if loader := findLoader(interp, opts); loader != "" && loader != interp {
+				f.SetInterpreter(loader)
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return false, nil
+	}
+	return true, f.Save()
+}
+
+// hasRPath reports whether the search path is a DT_RPATH
+func (f *File) hasRPath() bool {
+	i := f.runPathIndex()
+	return i >= 0 && f.dyns[i].tag == elf.DT_RPATH
+}
+
+// relocateRunPath maps a RUNPATH into the tree. Entries that resolve inside
+// it come first in their original order, then LibraryDirs, then entries that
+// could not be mapped, so host paths are only a fallback.
+func relocateRunPath(old []string, origin string, opts *Options) []string {
+	var mapped, unmapped []string
+	seen := make(map[string]bool)
+	add := func(list *[]string, entry string) {
+		if entry != "" && !seen[entry] {
+			seen[entry] = true
+			*list = append(*list, entry)
+		}
+	}
+
+	for _, entry := range old {
+		if strings.HasPrefix(entry, "$ORIGIN") || strings.HasPrefix(entry, "${ORIGIN}") {
+			add(&mapped, entry)
+			continue
+		}
+		if dir := mapPath(entry, opts); dir != "" && isDir(dir) && within(opts.Root, dir) {
+			add(&mapped, originPath(origin, dir))
+			continue
+		}
+		add(&unmapped, entry)
+	}
+	for _, dir := range opts.LibraryDirs {
+		if isDir(dir) {
+			add(&mapped, originPath(origin, dir))
+		}
+	}
+
+	return append(mapped, unmapped...)
+}
+
+// mapPath maps an absolute path through the longest matching prefix
+func mapPath(path string, opts *Options) string {
+	if !filepath.IsAbs(path) {
+		return ""
+	}
+
+	prefixes := make([]string, 0, len(opts.PrefixMap))
+	for prefix := range opts.PrefixMap {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+
+	for _, prefix := range prefixes {
+		if prefix == "/" || path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return filepath.Join(opts.PrefixMap[prefix], strings.TrimPrefix(path, prefix))
+		}
+	}
+	return ""
+}
+
+// findLoader returns the dynamic loader in the tree replacing interp: the
+// mapped path if it exists, else a file of the same name in LibraryDirs
+func findLoader(interp string, opts *Options) string {
+	if path := mapPath(interp, opts); path != "" && isFile(path) && within(opts.Root, path) {
+		return path
+	}
+	name := filepath.Base(interp)
+	for _, dir := range opts.LibraryDirs {
+		if path := filepath.Join(dir, name); isFile(path) {
+			return path
+		}
+	}
+	return ""
+}
+
+// originPath expresses dir relative to $ORIGIN, the directory of the binary
+func originPath(origin, dir string) string {
+	rel, err := filepath.Rel(origin, dir)
+	if err != nil {
+		return dir
+	}
+	if rel == "." {
+		return "$ORIGIN"
+	}
+	return "$ORIGIN/" + filepath.ToSlash(rel)
+}
+
+// within reports whether path is inside root
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// isELF checks the magic number without reading the whole file
+func isELF(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(elf.ELFMAG))
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return false
+	}
+	return bytes.Equal(magic, []byte(elf.ELFMAG))
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
@@ -28,6 +28,12 @@ func New(cacheDir string) *Registry {
 	}
 }
 
+// fallbacks lists backends whose package names can stand in when an entry
+// has no key for the requested backend (EL packages are named like Fedora's)
+var fallbacks = map[string][]string{
+	"el": {"dnf"},
+}
+
 // Resolve takes a canonical package name and a backend,
 // returns the backend-specific package name.
 // e.g. Resolve("sqlite3", "apt") -> "libsqlite3-dev"
@@ -37,12 +43,16 @@ func (r *Registry) Resolve(name string, backend string) (string, error) {
 		return "", err
 	}
 
-	pkgName, ok := entry.Backends[backend]
-	if !ok {
-		return "", fmt.Errorf("registry: package '%s' has no entry for backend '%s'", name, backend)
+	if pkgName, ok := entry.Backends[backend]; ok {
+		return pkgName, nil
+	}
+	for _, fallback := range fallbacks[backend] {
+		if pkgName, ok := entry.Backends[fallback]; ok {
+			return pkgName, nil
+		}
 	}
 
-	return pkgName, nil
+	return "", fmt.Errorf("registry: package '%s' has no entry for backend '%s'", name, backend)
 }
 
 // Load reads and parses deps/<name>/index.toml.
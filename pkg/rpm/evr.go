@@ -0,0 +1,90 @@
+// pkg/rpm/evr.go
+package rpm
+
+import (
+	"strings"
+
+	"github.com/sassoftware/go-rpmutils"
+)
+
+// EVR is an RPM epoch:version-release
+type EVR struct {
+	Epoch   string
+	Version string
+	Release string
+}
+
+// ParseEVR splits "[epoch:]version[-release]"
+func ParseEVR(s string) EVR {
+	var evr EVR
+	if epoch, rest, ok := strings.Cut(s, ":"); ok {
+		evr.Epoch = epoch
+		s = rest
+	}
+	if i := strings.LastIndex(s, "-"); i >= 0 {
+		evr.Version, evr.Release = s[:i], s[i+1:]
+	} else {
+		evr.Version = s
+	}
+	return evr
+}
+
+// String formats the EVR the way rpm prints it, omitting a zero epoch
+func (e EVR) String() string {
+	s := e.Version
+	if e.Release != "" {
+		s += "-" + e.Release
+	}
+	if e.Epoch != "" && e.Epoch != "0" {
+		s = e.Epoch + ":" + s
+	}
+	return s
+}
+
+// Compare orders two EVRs like rpm: a missing epoch is 0, and the release is
+// only compared when both sides have one
+func (e EVR) Compare(other EVR) int {
+	if res := rpmutils.Vercmp(epochOrZero(e.Epoch), epochOrZero(other.Epoch)); res != 0 {
+		return res
+	}
+	if res := rpmutils.Vercmp(e.Version, other.Version); res != 0 {
+		return res
+	}
+	if e.Release == "" || other.Release == "" {
+		return 0
+	}
+	return rpmutils.Vercmp(e.Release, other.Release)
+}
+
+// Satisfies reports whether a capability provided at EVR provided meets a
+// requirement with the given flags (EQ, LT, LE, GT, GE) and EVR. Unversioned
+// requirements and unversioned provides always match, as in rpm.
+func Satisfies(provided EVR, flags string, required EVR) bool {
+	if flags == "" || required.Version == "" || provided.Version == "" {
+		return true
+	}
+
+	res := provided.Compare(required)
+	switch flags {
+	case "EQ":
+		return res == 0
+	case "LT":
+		return res < 0
+	case "LE":
+		return res <= 0
+	case "GT":
+		return res > 0
+	case "GE":
+		return res >= 0
+	default:
+		return true
+	}
+}
+
+// epochOrZero treats a missing epoch as 0
+func epochOrZero(epoch string) string {
+	if epoch == "" {
+		return "0"
+	}
+	return epoch
+}
@@ -0,0 +1,315 @@
+// pkg/rpm/extract.go
+package rpm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sassoftware/go-rpmutils"
+)
+
+// File types in an RPM file mode (the S_IFMT bits)
+const (
+	modeTypeMask = 0170000
+	modeDir      = 0040000
+	modeRegular  = 0100000
+	modeSymlink  = 0120000
+)
+
+// Extract unpacks an RPM's payload into dest and returns the paths it
+// produced, relative to dest. The lead and headers are parsed properly and
+// the payload is decompressed according to its PAYLOADCOMPRESSOR tag, so
+// nothing is guessed from magic bytes. Per-file modes and mtimes from the
+// header are applied (setuid/setgid are dropped; the tree belongs to the
+// user running upkg), symlinks and hardlinks are recreated, directories get
+// their final mode once their contents are written, and %ghost entries are
+// skipped as rpm does. Ownership is applied only when running as root.
+func Extract(rpmPath, dest string) ([]string, error) {
+	f, err := os.Open(rpmPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pkg, err := rpmutils.ReadRpm(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading rpm header: %w", err)
+	}
+
+	payload, err := pkg.PayloadReaderExtended()
+	if err != nil {
+		return nil, fmt.Errorf("opening rpm payload: %w", err)
+	}
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return nil, fmt.Errorf("creating install directory: %w", err)
+	}
+	root, err := filepath.Abs(dest)
+	if err != nil {
+		return nil, err
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &extractor{
+		root:     root,
+		realRoot: realRoot,
+		dirs:     make(map[string]rpmutils.FileInfo),
+		pending:  make(map[uint64][]string),
+		chown:    os.Geteuid() == 0,
+	}
+
+	for {
+		fi, err := payload.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading rpm payload: %w", err)
+		}
+
+		if err := x.extractFile(fi, payload); err != nil {
+			return nil, fmt.Errorf("extracting %s: %w", fi.Name(), err)
+		}
+	}
+
+	if err := x.finishDirs(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(x.files)
+	return x.files, nil
+}
+
+// extractor carries the state of one payload extraction
+type extractor struct {
+	root     string                       // Absolute destination directory
+	realRoot string                       // root with symlinks resolved
+	files    []string                     // Produced paths, relative to root
+	dirs     map[string]rpmutils.FileInfo // Directories whose mode is applied last
+	pending  map[uint64][]string          // Hardlinks waiting for the member carrying the contents
+	chown    bool                         // Apply ownership (running as root)
+}
+
+// extractFile writes one payload member
+func (x *extractor) extractFile(fi rpmutils.FileInfo, payload rpmutils.PayloadReader) error {
+	// %ghost files are owned by the package but never shipped
+	if fi.Flags()&rpmutils.RPMFILE_GHOST != 0 {
+		return nil
+	}
+
+	rel := strings.TrimPrefix(filepath.Clean("/"+fi.Name()), "/")
+	if rel == "" {
+		return nil
+	}
+	target := filepath.Join(x.root, rel)
+	if !strings.HasPrefix(target, x.root+string(filepath.Separator)) {
+		return fmt.Errorf("path escapes install directory")
+	}
+
+	if err := x.makeDirs(filepath.Dir(target)); err != nil {
+		return err
+	}
+
+	switch fi.Mode() & modeTypeMask {
+	case modeDir:
+		// Stay writable until every member below it has been extracted
+		if err := x.makeDirs(target); err != nil {
+			return err
+		}
+		x.dirs[target] = fi
+
+	case modeSymlink:
+		if err := removeExisting(target); err != nil {
+			return err
+		}
+		if err := os.Symlink(fi.Linkname(), target); err != nil {
+			return err
+		}
+		x.applyOwner(target, fi, true)
+
+	case modeRegular:
+		key := uint64(fi.Device())<<32 | uint64(fi.Inode())
+		if payload.IsLink() {
+			// Contents come with a later member of the same inode
+			x.pending[key] = append(x.pending[key], target)
+			x.files = append(x.files, rel)
+			return nil
+		}
+
+		if err := x.writeFile(target, fi, payload); err != nil {
+			return err
+		}
+		for _, link := range x.pending[key] {
+			if err := removeExisting(link); err != nil {
+				return err
+			}
+			if err := os.Link(target, link); err != nil {
+				return fmt.Errorf("hardlinking %s: %w", link, err)
+			}
+		}
+		delete(x.pending, key)
+
+	default:
+		// Devices, FIFOs and sockets cannot be created unprivileged
+		return nil
+	}
+
+	x.files = append(x.files, rel)
+	return nil
+}
+
+// makeDirs creates the directories from root down to dir. Symlinks met on
+// the way (lib -> usr/lib) are followed only when they resolve inside root,
+// so a payload cannot ship a link to a directory outside it and then
+// write members through that link.
+func (x *extractor) makeDirs(dir string) error {
+	rel, err := filepath.Rel(x.root, dir)
+	if err != nil || rel == "." {
+		return err
+	}
+
+	current := x.root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+
+		info, err := os.Lstat(current)
+		if errors.Is(err, fs.ErrNotExist) {
+			if err := os.Mkdir(current, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			resolved, err := filepath.EvalSymlinks(current)
+			if err != nil {
+				return err
+			}
+			if resolved != x.realRoot && !strings.HasPrefix(resolved, x.realRoot+string(filepath.Separator)) {
+				return fmt.Errorf("%s links outside the install directory", strings.TrimPrefix(current, x.root))
+			}
+			if info, err = os.Stat(resolved); err != nil {
+				return err
+			}
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", strings.TrimPrefix(current, x.root))
+		}
+	}
+	return nil
+}
+
+// writeFile writes a regular file's contents, then its mode, owner and mtime
+func (x *extractor) writeFile(target string, fi rpmutils.FileInfo, r io.Reader) error {
+	// Replace rather than truncate, so read-only files from an earlier
+	// install (and symlinks pointing elsewhere) are never written through
+	if err := removeExisting(target); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	x.applyOwner(target, fi, false)
+	if err := os.Chmod(target, fileMode(fi)); err != nil {
+		return err
+	}
+	mtime := time.Unix(int64(fi.Mtime()), 0)
+	return os.Chtimes(target, mtime, mtime)
+}
+
+// finishDirs applies directory modes and mtimes, deepest first
+func (x *extractor) finishDirs() error {
+	dirs := make([]string, 0, len(x.dirs))
+	for dir := range x.dirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+
+	for _, dir := range dirs {
+		fi := x.dirs[dir]
+		x.applyOwner(dir, fi, false)
+		// Keep directories traversable and writable for the owner so later
+		// installs into the same prefix never hit permission errors
+		if err := os.Chmod(dir, fileMode(fi)|0700); err != nil {
+			return err
+		}
+		mtime := time.Unix(int64(fi.Mtime()), 0)
+		os.Chtimes(dir, mtime, mtime)
+	}
+
+	return nil
+}
+
+// applyOwner sets the file's owner and group by name when running as root;
+// unknown names keep the current owner
+func (x *extractor) applyOwner(target string, fi rpmutils.FileInfo, symlink bool) {
+	if !x.chown {
+		return
+	}
+
+	uid, gid := -1, -1
+	if u, err := user.Lookup(fi.UserName()); err == nil {
+		uid, _ = strconv.Atoi(u.Uid)
+	}
+	if g, err := user.LookupGroup(fi.GroupName()); err == nil {
+		gid, _ = strconv.Atoi(g.Gid)
+	}
+	if uid == -1 && gid == -1 {
+		return
+	}
+
+	if symlink {
+		os.Lchown(target, uid, gid)
+	} else {
+		os.Chown(target, uid, gid)
+	}
+}
+
+// fileMode converts an RPM mode to permission bits, dropping setuid and setgid
+func fileMode(fi rpmutils.FileInfo) os.FileMode {
+	mode := os.FileMode(fi.Mode() & 0777)
+	if fi.Mode()&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+// removeExisting removes whatever is at path, unless it is a directory
+func removeExisting(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,177 @@
+// pkg/rpm/keyring.go
+package rpm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// Fetcher retrieves a remote key; backends pass their own HTTP client's Get
+type Fetcher func(ctx context.Context, url string) (io.ReadCloser, error)
+
+// Keyring holds the OpenPGP public keys RPM signatures are checked against
+type Keyring struct {
+	entities openpgp.EntityList
+	sources  []string // Sources at least one key was loaded from
+}
+
+// LoadKeyring reads armored or binary public keys from local paths, file://
+// URLs and http(s) URLs; a local directory contributes every *.asc file in it.
+// Remote keys are cached in cacheDir on first fetch and read from there
+// afterwards, so a key is pinned once it has been trusted.
+// Missing local files are skipped (distro key paths only exist on that distro);
+// an error is returned only when no source yields a key.
+func LoadKeyring(ctx context.Context, sources []string, cacheDir string, fetch Fetcher) (*Keyring, error) {
+	keyring := &Keyring{}
+	var errs []error
+
+	for _, source := range expandDirs(sources) {
+		data, err := readKey(ctx, source, cacheDir, fetch)
+		if errors.Is(err, os.ErrNotExist) && !isRemote(source) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source, err))
+			continue
+		}
+
+		entities, err := parseKeys(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source, err))
+			continue
+		}
+		keyring.entities = append(keyring.entities, entities...)
+		keyring.sources = append(keyring.sources, source)
+	}
+
+	if len(keyring.entities) == 0 {
+		if len(errs) == 0 {
+			return nil, fmt.Errorf("no keys found in %s", strings.Join(sources, ", "))
+		}
+		return nil, fmt.Errorf("loading keys: %w", errors.Join(errs...))
+	}
+
+	return keyring, nil
+}
+
+// Len returns the number of keys in the keyring
+func (k *Keyring) Len() int {
+	return len(k.entities)
+}
+
+// Sources returns the paths and URLs keys were loaded from
+func (k *Keyring) Sources() []string {
+	return k.sources
+}
+
+// expandDirs replaces local directories with the key files they contain
+func expandDirs(sources []string) []string {
+	var expanded []string
+	for _, source := range sources {
+		path := strings.TrimPrefix(source, "file://")
+		if info, err := os.Stat(path); err == nil && info.IsDir() && !isRemote(source) {
+			files, _ := filepath.Glob(filepath.Join(path, "*.asc"))
+			expanded = append(expanded, files...)
+			continue
+		}
+		expanded = append(expanded, source)
+	}
+	return expanded
+}
+
+// readKey returns the raw key material of a source, using the cached copy of
+// remote keys when there is one
+func readKey(ctx context.Context, source, cacheDir string, fetch Fetcher) ([]byte, error) {
+	if !isRemote(source) {
+		return os.ReadFile(strings.TrimPrefix(source, "file://"))
+	}
+
+	sum := sha256.Sum256([]byte(source))
+	cached := filepath.Join(cacheDir, hex.EncodeToString(sum[:8])+".asc")
+	if data, err := os.ReadFile(cached); err == nil {
+		return data, nil
+	}
+
+	if fetch == nil {
+		return nil, fmt.Errorf("no fetcher for remote key")
+	}
+	body, err := fetch(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	// Only pin material that actually parses as a key
+	if _, err := parseKeys(data); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err == nil {
+		os.WriteFile(cached, data, 0644)
+	}
+
+	return data, nil
+}
+
+// parseKeys parses one or more public keys, armored or binary
+func parseKeys(data []byte) (openpgp.EntityList, error) {
+	if bytes.Contains(data, []byte("-----BEGIN PGP")) {
+		var all openpgp.EntityList
+		// Key files often concatenate several armored blocks
+		for _, block := range splitArmored(data) {
+			entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(block))
+			if err != nil {
+				return nil, fmt.Errorf("parsing armored key: %w", err)
+			}
+			all = append(all, entities...)
+		}
+		return all, nil
+	}
+
+	entities, err := openpgp.ReadKeyRing(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing key: %w", err)
+	}
+	return entities, nil
+}
+
+// splitArmored splits concatenated ASCII-armored blocks
+func splitArmored(data []byte) [][]byte {
+	const end = "-----END PGP PUBLIC KEY BLOCK-----"
+
+	var blocks [][]byte
+	for {
+		start := bytes.Index(data, []byte("-----BEGIN PGP"))
+		if start < 0 {
+			break
+		}
+		data = data[start:]
+		stop := bytes.Index(data, []byte(end))
+		if stop < 0 {
+			blocks = append(blocks, data)
+			break
+		}
+		blocks = append(blocks, data[:stop+len(end)])
+		data = data[stop+len(end):]
+	}
+	return blocks
+}
+
+// isRemote reports whether a key source must be downloaded
+func isRemote(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
@@ -0,0 +1,63 @@
+// pkg/rpm/verify.go
+package rpm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/sassoftware/go-rpmutils"
+)
+
+// ErrUnsigned is returned when signatures are required but the package has none
+var ErrUnsigned = errors.New("package is not signed")
+
+// Result describes what was verified in a package
+type Result struct {
+	Signers []string // Identity (or key ID) behind each valid signature
+}
+
+// Verify checks an RPM's header digest and payload digest and, when keyring
+// is not nil, its PGP signatures. With a keyring, every signature must be
+// made by a key in it and at least one signature must cover the package.
+func Verify(path string, keyring *Keyring) (*Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var known openpgp.EntityList
+	if keyring != nil {
+		// A non-nil list makes rpmutils check signatures, even if it is empty
+		known = append(openpgp.EntityList{}, keyring.entities...)
+	}
+
+	_, sigs, err := rpmutils.Verify(f, known)
+	if err != nil {
+		var missing rpmutils.KeyNotFoundError
+		if errors.As(err, &missing) {
+			return nil, fmt.Errorf("signed with untrusted key: %w", err)
+		}
+		return nil, err
+	}
+
+	result := &Result{}
+	if keyring == nil {
+		return result, nil
+	}
+
+	if len(sigs) == 0 {
+		return nil, ErrUnsigned
+	}
+	for _, sig := range sigs {
+		signer := sig.PrimaryName
+		if signer == "" {
+			signer = fmt.Sprintf("%016x", sig.KeyId)
+		}
+		result.Signers = append(result.Signers, signer)
+	}
+
+	return result, nil
+}
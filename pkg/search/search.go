@@ -0,0 +1,48 @@
+// pkg/search/search.go
+package search
+
+import "strings"
+
+// Words splits a search query into lower-cased words
+func Words(query string) []string {
+	return strings.Fields(strings.ToLower(query))
+}
+
+// Score ranks a package against query words; lower is better. Each word
+// scores by its best match: an exact name first, then a name prefix, then
+// a substring of a name, then the description only. Every word has to
+// match, or ok is false. names (e.g. attribute and pname, or a formula and
+// its aliases) and description are compared case-insensitively.
+func Score(words, names []string, description string) (score int, ok bool) {
+	lower := make([]string, len(names))
+	for i, name := range names {
+		lower[i] = strings.ToLower(name)
+	}
+	description = strings.ToLower(description)
+
+	anyName := func(match func(name, word string) bool, word string) bool {
+		for _, name := range lower {
+			if match(name, word) {
+				return true
+			}
+		}
+		return false
+	}
+	equal := func(name, word string) bool { return name == word }
+
+	for _, word := range words {
+		switch {
+		case anyName(equal, word):
+			// Exact matches rank first
+		case anyName(strings.HasPrefix, word):
+			score += 1
+		case anyName(strings.Contains, word):
+			score += 2
+		case strings.Contains(description, word):
+			score += 4
+		default:
+			return 0, false
+		}
+	}
+	return score, true
+}
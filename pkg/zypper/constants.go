@@ -18,10 +18,37 @@ const (
 const (
 	RepoOSS      = "repo/oss"      // Open Source Software (Main)
 	RepoNonOSS   = "repo/non-oss"  // Proprietary Software
-	RepoUpdate   = "repo/update"   // Updates
 )
 
-// DefaultRepos lists the standard repositories enabled by default
+// Update repositories live outside the distribution tree, under
+// update/tumbleweed or update/leap/<version>; see RepoURL
+const (
+	RepoUpdate          = "update"           // Maintenance updates for repo/oss
+	RepoUpdateNonOSS    = "update/non-oss"   // Maintenance updates for repo/non-oss
+	RepoUpdateSLE       = "update/sle"       // Leap: updates to packages taken from SLE
+	RepoUpdateBackports = "update/backports" // Leap: updates to Package Hub backports
+)
+
+// DefaultRepos lists the standard repositories enabled by default on Tumbleweed
 var DefaultRepos = []string{
 	RepoOSS,
+	RepoUpdate,
+}
+
+// DefaultLeapRepos lists the standard repositories enabled by default on Leap,
+// which builds most of its binaries from SLE and backports
+var DefaultLeapRepos = []string{
+	RepoOSS,
+	RepoUpdate,
+	RepoUpdateSLE,
+	RepoUpdateBackports,
+}
+
+// DefaultGPGKeys are always trusted: the keys an
+// openSUSE host ships for rpm, then the openSUSE Project signing key published
+// by the build service (fetched once and pinned in the cache). Leap also ships
+// SLE binaries signed by SUSE's own key; off openSUSE, add it to Config.GPGKeys.
+var DefaultGPGKeys = []string{
+	"/usr/lib/rpm/gnupg/keys",
+	"https://build.opensuse.org/projects/openSUSE:Factory/signing_keys/download?kind=gpg",
 }
\ No newline at end of file
@@ -0,0 +1,175 @@
+// pkg/zypper/filelists.go
+package zypper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FindFileOwners returns the packages that ship path. A bare file name
+// (e.g. "libssl.so.3") matches that name in any directory.
+func (pm *PackageManager) FindFileOwners(ctx context.Context, filePath, arch string) ([]*PackageInfo, error) {
+	if arch == "" {
+		arch = pm.config.Architecture
+	}
+	if err := pm.updateDB(ctx, arch); err != nil {
+		return nil, err
+	}
+
+	owners, err := pm.lookupFile(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(owners) == 0 {
+		return nil, fmt.Errorf("no package ships %s", filePath)
+	}
+	return owners, nil
+}
+
+// findFileOwner picks the package that satisfies a file requirement,
+// preferring the target architecture over noarch
+func (pm *PackageManager) findFileOwner(ctx context.Context, filePath, arch string) (*PackageInfo, error) {
+	owners, err := pm.lookupFile(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(owners) == 0 {
+		return nil, fmt.Errorf("no package ships %s", filePath)
+	}
+
+	for _, p := range owners {
+		if p.Architecture == arch {
+			return p, nil
+		}
+	}
+	return owners[0], nil
+}
+
+// lookupFile resolves a path against the file index. primary.xml only
+// lists a subset of the files (binaries and /etc); the first time a path is
+// missing from it, or a bare file name is looked up, every repository's full
+// filelists are downloaded and added to the index, once per sync.
+func (pm *PackageManager) lookupFile(ctx context.Context, filePath string) ([]*PackageInfo, error) {
+	bare := !strings.Contains(filePath, "/")
+	if !bare {
+		if owners, ok := pm.cache.files[filePath]; ok {
+			return owners, nil
+		}
+	}
+
+	if !pm.cache.filesIndexed {
+		pm.indexFilelists(ctx)
+	}
+
+	if bare {
+		return pm.cache.baseNames[filePath], nil
+	}
+	return pm.cache.files[filePath], nil
+}
+
+// indexFilelists adds the files of every repository's filelists to the file
+// index. Filelists identify packages by pkgid, the checksum primary.xml
+// lists, so only the builds that won the index are recorded.
+func (pm *PackageManager) indexFilelists(ctx context.Context) {
+	pm.cache.filesIndexed = true
+
+	byID := make(map[string]*PackageInfo, len(pm.cache.packages))
+	for _, p := range pm.cache.packages {
+		byID[p.Checksum] = p
+	}
+
+	for _, source := range pm.cache.filelists {
+		if err := pm.fetchFilelists(ctx, source); err != nil {
+			pm.logger.Printf("  ⚠️ Skipping filelists for %s: %v", source.repo, err)
+			continue
+		}
+
+		pm.logger.Printf("  Indexing %s filelists", source.repo)
+
+		f, err := os.Open(source.path)
+		if err != nil {
+			pm.logger.Printf("  ⚠️ Failed to read filelists for %s: %v", source.repo, err)
+			continue
+		}
+		err = ParseFilelists(f, source.path, func(pkgID, name string, files []string) {
+			p, ok := byID[pkgID]
+			if !ok {
+				return
+			}
+			for _, file := range files {
+				pm.addFile(file, p)
+			}
+		})
+		f.Close()
+		if err != nil {
+			pm.logger.Printf("  ⚠️ Failed to read filelists for %s: %v", source.repo, err)
+		}
+	}
+
+	pm.logger.Printf("  ✓ Indexed %d files", len(pm.cache.files))
+}
+
+// addFile records p as an owner of file, by path and by base name
+func (pm *PackageManager) addFile(file string, p *PackageInfo) {
+	pm.cache.files[file] = appendOwner(pm.cache.files[file], p)
+	base := path.Base(file)
+	pm.cache.baseNames[base] = appendOwner(pm.cache.baseNames[base], p)
+}
+
+// appendOwner adds p to owners unless primary.xml already listed it
+func appendOwner(owners []*PackageInfo, p *PackageInfo) []*PackageInfo {
+	for _, owner := range owners {
+		if owner == p {
+			return owners
+		}
+	}
+	return append(owners, p)
+}
+
+// fetchFilelists downloads a repository's filelists into the cache directory
+func (pm *PackageManager) fetchFilelists(ctx context.Context, source *filelistSource) error {
+	if source.path != "" {
+		return nil
+	}
+
+	dest, err := pm.fetchRepodata(ctx, source.repo, source.url, source.checksum, source.checksumType)
+	if err != nil {
+		return err
+	}
+	source.path = dest
+	return nil
+}
+
+// fetchRepodata downloads a repodata file (primary, filelists) into the
+// cache directory and checks it against the checksum repomd.xml lists for
+// it, so a verified repomd.xml covers the metadata too. A cached copy that
+// still matches is reused; one that no longer does is downloaded again.
+func (pm *PackageManager) fetchRepodata(ctx context.Context, repo, url, checksum, checksumType string) (string, error) {
+	if checksumType != "sha256" && checksumType != "sha512" {
+		return "", fmt.Errorf("unsupported checksum type %q for %s", checksumType, path.Base(url))
+	}
+
+	name := strings.ReplaceAll(repo, "/", "_") + "-" + path.Base(url)
+	dest := filepath.Join(pm.config.CachePath, "repodata", name)
+	if _, err := os.Stat(dest); err == nil {
+		if pm.verifyHash(dest, checksum, checksumType) == nil {
+			return dest, nil
+		}
+		os.Remove(dest)
+	}
+
+	pm.logger.Printf("  Downloading %s %s", repo, url)
+	if err := pm.downloadFile(ctx, url, dest); err != nil {
+		os.Remove(dest)
+		return "", err
+	}
+	if err := pm.verifyHash(dest, checksum, checksumType); err != nil {
+		os.Remove(dest)
+		return "", fmt.Errorf("verifying %s: %w", path.Base(url), err)
+	}
+	return dest, nil
+}
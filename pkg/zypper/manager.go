@@ -2,7 +2,6 @@
 package zypper
 
 import (
-	"compress/gzip"
 	"context"
 	"crypto/sha256"
 	"crypto/sha512"
@@ -15,9 +14,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/cavaliergopher/cpio"
-	"github.com/klauspost/compress/zstd"
-	"github.com/ulikunitz/xz"
+	"github.com/arc-language/upkg/pkg/rpm"
 )
 
 func NewPackageManager(cfg *Config) *PackageManager {
@@ -31,8 +28,11 @@ func NewPackageManager(cfg *Config) *PackageManager {
 	if cfg.Distribution == "" {
 		cfg.Distribution = DefaultDistribution
 	}
+	if cfg.Architecture == "" {
+		cfg.Architecture = DefaultArch
+	}
 	if len(cfg.Repos) == 0 {
-		cfg.Repos = DefaultRepos
+		cfg.Repos = DefaultReposFor(cfg.Distribution)
 	}
 	if cfg.InstallPath == "" {
 		cfg.InstallPath = DefaultInstallPath
@@ -60,6 +60,7 @@ func NewPackageManager(cfg *Config) *PackageManager {
 		logger: logger,
 		cache: &PackageCache{
 			packages:      make(map[string]*PackageInfo),
+			providers:     make(map[string][]*PackageInfo),
 			cacheDuration: 30 * time.Minute,
 		},
 	}
@@ -68,7 +69,7 @@ func NewPackageManager(cfg *Config) *PackageManager {
 // Download downloads and installs a package and its dependencies
 func (pm *PackageManager) Download(ctx context.Context, opts *DownloadOptions) error {
 	if opts.Architecture == "" {
-		opts.Architecture = DefaultArch
+		opts.Architecture = pm.config.Architecture
 	}
 
 	pm.logger.Printf("Starting operation for package: %s", opts.Package)
@@ -81,50 +82,60 @@ func (pm *PackageManager) Download(ctx context.Context, opts *DownloadOptions) e
 	// Track installed packages to avoid loops
 	visited := make(map[string]bool)
 
-	return pm.installRecursive(ctx, opts, visited)
+	// An explicit version pins the request: "1.2" matches any release of 1.2
+	request := Dependency{Name: opts.Package}
+	if opts.Version != "" {
+		request.Flags = "EQ"
+		request.Version = opts.Version
+		request.Rel = rpm.ParseEVR(opts.Version).Release
+	}
+
+	return pm.installRecursive(ctx, request, opts, visited)
 }
 
 // installRecursive handles the actual download, dependency resolution, and extraction
-func (pm *PackageManager) installRecursive(ctx context.Context, opts *DownloadOptions, visited map[string]bool) error {
+func (pm *PackageManager) installRecursive(ctx context.Context, req Dependency, opts *DownloadOptions, visited map[string]bool) error {
 	// Skip if already visited/installed in this transaction
-	if visited[opts.Package] {
+	if visited[req.Name] {
 		return nil
 	}
-	visited[opts.Package] = true
+	visited[req.Name] = true
 
-	// 1. Find Package
-	pkg, err := pm.findPackage(opts.Package, opts.Version)
+	// 1. Find Package: a package name, a capability (libssl.so.3()(64bit))
+	// or a file requirement like /bin/sh, honoring any version constraint
+	pkg, err := pm.resolveDependency(ctx, req, opts.Architecture)
 	if err != nil {
 		// If we can't find a dependency, we log a warning but don't fail hard,
 		// as it might be a virtual package or capability provided by the system.
-		pm.logger.Printf("  ⚠️ Warning: Could not find package/dependency '%s': %v", opts.Package, err)
+		pm.logger.Printf("  ⚠️ Warning: Could not find package/dependency '%s': %v", req, err)
 		return nil
 	}
-	
+	if pkg.Name != req.Name {
+		if visited[pkg.Name] {
+			return nil
+		}
+		visited[pkg.Name] = true
+	}
+
 	pm.logger.Printf("Processing: %s %s", pkg.Name, pkg.Version)
 
 	// 2. Resolve Dependencies Recursive Loop
 	if len(pkg.Dependencies) > 0 {
 		pm.logger.Printf("  Resolving dependencies for %s...", pkg.Name)
 		for _, dep := range pkg.Dependencies {
-			pm.logger.Printf("    -> Dependency: %s", dep.Name)
-			
-			// Create options for the dependency
-			depOpts := *opts // Shallow copy
-			depOpts.Package = dep.Name
-			depOpts.Version = "" // Always use latest available for dependencies for now
-			
+			pm.logger.Printf("    -> Dependency: %s", dep)
+
 			// Recurse
-			if err := pm.installRecursive(ctx, &depOpts, visited); err != nil {
+			if err := pm.installRecursive(ctx, dep, opts, visited); err != nil {
 				pm.logger.Printf("    ⚠️ Warning: Failed to install dependency %s: %v", dep.Name, err)
 			}
 		}
 	}
 
 	// 3. Download
-	// URL Construction: Mirror / Distribution / RepoPath / LocationFromXML
-	repoBaseURL := fmt.Sprintf("%s/%s/%s", pm.config.MirrorURL, pm.config.Distribution, pkg.Repository)
-	
+	// URL Construction: repository base URL (see RepoURL) / LocationFromXML
+	repoBaseURL := RepoURL(pm.config.MirrorURL, pm.config.Distribution, pkg.Repository)
+
 	// Ensure no double slashes if Repo is handled differently in caching
 	downloadURL := fmt.Sprintf("%s/%s", repoBaseURL, pkg.Location)
 
@@ -135,12 +146,17 @@ func (pm *PackageManager) installRecursive(ctx context.Context, opts *DownloadOp
 		return fmt.Errorf("downloading %s: %w", pkg.Name, err)
 	}
 
-	// 4. Verify
+	// 4. Verify the repodata checksum, then the RPM's own digests and signature
 	if opts.VerifyHash && pkg.Checksum != "" {
 		if err := pm.verifyHash(destPath, pkg.Checksum, pkg.ChecksumType); err != nil {
+			os.Remove(destPath)
 			return err
 		}
 	}
+	if err := pm.verifyPackage(ctx, pkg, destPath, opts.VerifyHash); err != nil {
+		os.Remove(destPath)
+		return err
+	}
 
 	// 5. Extract
 	if opts.Extract {
@@ -158,16 +174,21 @@ func (pm *PackageManager) installRecursive(ctx context.Context, opts *DownloadOp
 }
 
 func (pm *PackageManager) updateDB(ctx context.Context, arch string) error {
-	if len(pm.cache.packages) > 0 && time.Since(pm.cache.lastUpdate) < pm.cache.cacheDuration {
+	if len(pm.cache.packages) > 0 && pm.cache.arch == arch && time.Since(pm.cache.lastUpdate) < pm.cache.cacheDuration {
 		return nil
 	}
 
 	pm.logger.Printf("Syncing databases...")
 	pm.cache.packages = make(map[string]*PackageInfo)
+	pm.cache.providers = make(map[string][]*PackageInfo)
+	pm.cache.files = make(map[string][]*PackageInfo)
+	pm.cache.baseNames = make(map[string][]*PackageInfo)
+	pm.cache.filelists = nil
+	pm.cache.filesIndexed = false
 
 	for _, repoPath := range pm.config.Repos {
 		// 1. Get repomd.xml
-		baseURL := fmt.Sprintf("%s/%s/%s", pm.config.MirrorURL, pm.config.Distribution, repoPath)
+		baseURL := RepoURL(pm.config.MirrorURL, pm.config.Distribution, repoPath)
 		repomdURL := fmt.Sprintf("%s/repodata/repomd.xml", baseURL)
 
 		pm.logger.Printf("  Fetching repomd: %s", repomdURL)
@@ -178,22 +199,48 @@ func (pm *PackageManager) updateDB(ctx context.Context, arch string) error {
 			continue
 		}
 		
-		primaryLoc, err := ParseRepomd(repomdBody)
+		entries, err := ParseRepomdData(repomdBody)
 		repomdBody.Close()
 		if err != nil {
 			pm.logger.Printf("    ⚠️ Failed to parse repomd for %s: %v", repoPath, err)
 			continue
 		}
 
+		primary, ok := entries["primary"]
+		if !ok {
+			pm.logger.Printf("    ⚠️ No primary metadata in repomd for %s", repoPath)
+			continue
+		}
+		primaryLoc := primary.Location.Href
+
+		// Full file lists are only fetched when a path isn't covered by primary.xml
+		filelists, ok := entries["filelists"]
+		if !ok {
+			filelists, ok = entries["filelists-ext"]
+		}
+		if ok {
+			pm.cache.filelists = append(pm.cache.filelists, &filelistSource{
+				repo:         repoPath,
+				url:          fmt.Sprintf("%s/%s", baseURL, filelists.Location.Href),
+				checksum:     filelists.Checksum.Value,
+				checksumType: filelists.Checksum.Type,
+			})
+		}
+
 		// 2. Get Primary XML
 		primaryURL := fmt.Sprintf("%s/%s", baseURL, primaryLoc)
 		pm.logger.Printf("    Fetching primary: %s", primaryURL)
 
-		primaryBody, err := pm.client.Get(ctx, primaryURL)
+		primaryPath, err := pm.fetchRepodata(ctx, repoPath, primaryURL, primary.Checksum.Value, primary.Checksum.Type)
 		if err != nil {
 			pm.logger.Printf("    ⚠️ Failed to fetch primary XML: %v", err)
 			continue
 		}
+		primaryBody, err := os.Open(primaryPath)
+		if err != nil {
+			pm.logger.Printf("    ⚠️ Failed to open primary XML: %v", err)
+			continue
+		}
 
 		// Pass primaryLoc (filename) so parser knows to use zstd or gzip
 		pkgs, err := ParsePrimary(primaryBody, primaryLoc, repoPath)
@@ -203,26 +250,41 @@ func (pm *PackageManager) updateDB(ctx context.Context, arch string) error {
 			continue
 		}
 
-		// 3. Filter by architecture and add to cache
+		// 3. Filter by architecture and add to cache. Every build is a
+		// provider candidate; by name, the highest version wins, so update
+		// repositories supersede the release.
 		count := 0
 		for _, p := range pkgs {
 			// Basic arch filtering
-			if p.Architecture == "noarch" || p.Architecture == arch {
-				// Simple Last-Write-Wins for versioning in this map
+			if p.Architecture != "noarch" && p.Architecture != arch {
+				continue
+			}
+			pm.indexProvides(p)
+			if existing, ok := pm.cache.packages[p.Name]; !ok || p.evr().Compare(existing.evr()) > 0 {
 				pm.cache.packages[p.Name] = p
-				count++
 			}
+			count++
 		}
 		pm.logger.Printf("    Indexed %d packages from %s", count, repoPath)
 	}
 
+	// primary.xml lists the files most requirements point at (/usr/bin, /etc)
+	for _, p := range pm.cache.packages {
+		for _, file := range p.Files {
+			pm.addFile(file, p)
+		}
+	}
+
+	pm.logger.Printf("  ✓ Indexed %d packages, %d unique provides", len(pm.cache.packages), len(pm.cache.providers))
+
+	pm.cache.arch = arch
 	pm.cache.lastUpdate = time.Now()
 	return nil
 }
 
 func (pm *PackageManager) findPackage(name, version string) (*PackageInfo, error) {
 	if pkg, ok := pm.cache.packages[name]; ok {
-		if version == "" || pkg.Version == version {
+		if version == "" || rpm.Satisfies(pkg.evr(), "EQ", rpm.ParseEVR(version)) {
 			return pkg, nil
 		}
 	}
@@ -277,117 +339,17 @@ func (pm *PackageManager) verifyHash(path, expected, hashType string) error {
 	return nil
 }
 
-// extractRPM extracts an RPM package.
+// extractRPM extracts an RPM package and logs the files it produced
 func (pm *PackageManager) extractRPM(rpmPath, dest string) error {
-	f, err := os.Open(rpmPath)
+	files, err := rpm.Extract(rpmPath, dest)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-
-	// 1. Find the start of the compressed CPIO archive.
-	// We look for GZIP (1f 8b), Zstd (28 b5 2f fd), or XZ (fd 37 7a 58 5a 00)
-	// Scanning the first 1MB should be enough to skip RPM headers.
-	scanSize := 1024 * 1024 // 1MB buffer
-	buf := make([]byte, scanSize) 
-	n, err := f.Read(buf)
-	if err != nil && err != io.EOF {
-		return err
-	}
-
-	var offset int64 = -1
-	var format string
 
-	for i := 0; i < n-6; i++ {
-		// Check for GZIP
-		if buf[i] == 0x1f && buf[i+1] == 0x8b {
-			offset = int64(i)
-			format = "gzip"
-			break
-		}
-		// Check for ZSTD
-		if buf[i] == 0x28 && buf[i+1] == 0xb5 && buf[i+2] == 0x2f && buf[i+3] == 0xfd {
-			offset = int64(i)
-			format = "zstd"
-			break
-		}
-		// Check for XZ
-		if buf[i] == 0xfd && buf[i+1] == 0x37 && buf[i+2] == 0x7a && buf[i+3] == 0x58 && buf[i+4] == 0x5a && buf[i+5] == 0x00 {
-			offset = int64(i)
-			format = "xz"
-			break
-		}
-	}
-
-	if offset == -1 {
-		return fmt.Errorf("could not find compressed archive within RPM (scanned %d bytes)", n)
-	}
-
-	f.Seek(offset, 0)
-
-	// 2. Decompress
-	var reader io.Reader
-	if format == "gzip" {
-		gz, err := gzip.NewReader(f)
-		if err != nil {
-			return err
-		}
-		defer gz.Close()
-		reader = gz
-	} else if format == "zstd" {
-		zs, err := zstd.NewReader(f)
-		if err != nil {
-			return err
-		}
-		defer zs.Close()
-		reader = zs
-	} else if format == "xz" {
-		x, err := xz.NewReader(f)
-		if err != nil {
-			return err
-		}
-		reader = x
-	}
-
-	// 3. Extract CPIO
-	cpioReader := cpio.NewReader(reader)
-	
-	for {
-		header, err := cpioReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("reading cpio: %w", err)
-		}
-
-		// Skip metadata/directories if needed, or create them
-		target := filepath.Join(dest, header.Name)
-		
-		if header.Mode.IsDir() {
-			os.MkdirAll(target, 0755)
-			continue
-		}
-
-		// Ensure parent exists
-		os.MkdirAll(filepath.Dir(target), 0755)
-
-		if header.Mode.IsRegular() {
-			perm := os.FileMode(header.Mode & 0777)
-			outFile, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
-			if err != nil {
-				return err
-			}
-			if _, err := io.Copy(outFile, cpioReader); err != nil {
-				outFile.Close()
-				return err
-			}
-			outFile.Close()
-		} else if (header.Mode & 0170000) == 0120000 { // Symlink
-			if header.Linkname != "" {
-				os.Remove(target)
-				os.Symlink(header.Linkname, target)
-			}
+	pm.logger.Printf("    Extracted %d files", len(files))
+	if pm.config.Debug {
+		for _, file := range files {
+			pm.logger.Printf("      %s", file)
 		}
 	}
 
@@ -395,14 +357,14 @@ func (pm *PackageManager) extractRPM(rpmPath, dest string) error {
 }
 
 func (pm *PackageManager) GetPackageInfo(ctx context.Context, name string) (*PackageInfo, error) {
-	if err := pm.updateDB(ctx, DefaultArch); err != nil {
+	if err := pm.updateDB(ctx, pm.config.Architecture); err != nil {
 		return nil, err
 	}
 	return pm.findPackage(name, "")
 }
 
 func (pm *PackageManager) SearchPackages(ctx context.Context, query string) ([]*PackageInfo, error) {
-	if err := pm.updateDB(ctx, DefaultArch); err != nil {
+	if err := pm.updateDB(ctx, pm.config.Architecture); err != nil {
 		return nil, err
 	}
 	var results []*PackageInfo
@@ -416,7 +378,7 @@ func (pm *PackageManager) SearchPackages(ctx context.Context, query string) ([]*
 
 // GetDependencies returns the list of dependencies for a package
 func (pm *PackageManager) GetDependencies(ctx context.Context, name string) ([]Dependency, error) {
-	if err := pm.updateDB(ctx, DefaultArch); err != nil {
+	if err := pm.updateDB(ctx, pm.config.Architecture); err != nil {
 		return nil, err
 	}
 
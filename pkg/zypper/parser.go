@@ -9,48 +9,74 @@ import (
 	"strings"
 
 	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
 )
 
 // ParseRepomd finds the location of the 'primary' metadata file from repomd.xml
 func ParseRepomd(r io.Reader) (string, error) {
-	var repo Repomd
-	if err := xml.NewDecoder(r).Decode(&repo); err != nil {
+	entries, err := ParseRepomdData(r)
+	if err != nil {
 		return "", err
 	}
 
-	for _, data := range repo.Data {
-		if data.Type == "primary" {
-			return data.Location.Href, nil
-		}
+	if primary, ok := entries["primary"]; ok {
+		return primary.Location.Href, nil
 	}
 
 	return "", fmt.Errorf("primary metadata not found in repomd.xml")
 }
 
-// ParsePrimary parses the primary package metadata
-// filename is used to determine compression type (gz or zst)
-func ParsePrimary(r io.Reader, filename string, repoName string) ([]*PackageInfo, error) {
-	var xmlReader io.Reader
+// ParseRepomdData maps every metadata type in repomd.xml (primary,
+// filelists, filelists-ext, ...) to its entry: location and checksum
+func ParseRepomdData(r io.Reader) (map[string]RepomdData, error) {
+	var repo Repomd
+	if err := xml.NewDecoder(r).Decode(&repo); err != nil {
+		return nil, err
+	}
 
-	// Handle compression based on file extension
-	if strings.HasSuffix(filename, ".zst") {
+	entries := make(map[string]RepomdData, len(repo.Data))
+	for _, data := range repo.Data {
+		data.Checksum.Value = strings.TrimSpace(data.Checksum.Value)
+		entries[data.Type] = data
+	}
+	return entries, nil
+}
+
+// decompress wraps r according to the metadata filename's extension (gz, zst, xz)
+func decompress(r io.Reader, filename string) (io.ReadCloser, error) {
+	switch {
+	case strings.HasSuffix(filename, ".zst"):
 		decoder, err := zstd.NewReader(r)
 		if err != nil {
 			return nil, fmt.Errorf("zstd reader: %w", err)
 		}
-		defer decoder.Close()
-		xmlReader = decoder
-	} else if strings.HasSuffix(filename, ".gz") {
+		return decoder.IOReadCloser(), nil
+	case strings.HasSuffix(filename, ".gz"):
 		gzReader, err := gzip.NewReader(r)
 		if err != nil {
 			return nil, fmt.Errorf("gzip reader: %w", err)
 		}
-		defer gzReader.Close()
-		xmlReader = gzReader
-	} else {
+		return gzReader, nil
+	case strings.HasSuffix(filename, ".xz"):
+		xzReader, err := xz.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("xz reader: %w", err)
+		}
+		return io.NopCloser(xzReader), nil
+	default:
 		// Assume uncompressed
-		xmlReader = r
+		return io.NopCloser(r), nil
 	}
+}
+
+// ParsePrimary parses the primary package metadata
+// filename is used to determine compression type (gz, zst or xz)
+func ParsePrimary(r io.Reader, filename string, repoName string) ([]*PackageInfo, error) {
+	xmlReader, err := decompress(r, filename)
+	if err != nil {
+		return nil, err
+	}
+	defer xmlReader.Close()
 
 	// XML Stream
 	decoder := xml.NewDecoder(xmlReader)
@@ -81,49 +107,50 @@ func ParsePrimary(r io.Reader, filename string, repoName string) ([]*PackageInfo
 					fullVersion += "-" + p.Version.Rel
 				}
 
-				// Parse dependencies from rpm:requires
+				// Parse dependencies from rpm:requires. Sonames and other
+				// capabilities are kept; they resolve through the provides index.
 				var deps []Dependency
 				for _, entry := range p.Format.Requires.Entries {
 					// --- Dependency Filtering Logic ---
-					
-					// 1. Skip library dependencies (.so)
-					if strings.Contains(entry.Name, ".so") {
-						continue
-					}
-					// 2. Skip absolute paths (usually file dependencies like /bin/sh)
-					if strings.HasPrefix(entry.Name, "/") {
-						continue
-					}
-					// 3. Skip rpmlib internal dependencies (e.g., rpmlib(PayloadIsZstd))
+
+					// 1. Skip rpmlib internal dependencies (e.g., rpmlib(PayloadIsZstd))
 					if strings.HasPrefix(entry.Name, "rpmlib(") {
 						continue
 					}
-					// 4. Skip config entries (e.g., config(package))
+					// 2. Skip config entries (e.g., config(package))
 					if strings.HasPrefix(entry.Name, "config(") {
 						continue
 					}
-
-					dep := Dependency{
-						Name:  entry.Name,
-						Flags: entry.Flags,
-						Epoch: entry.Epoch,
+					// 3. Skip rich (boolean) dependencies such as "(foo if bar)"
+					if strings.HasPrefix(entry.Name, "(") {
+						continue
 					}
 
-					// Construct version string if present
-					if entry.Ver != "" {
-						dep.Version = entry.Ver
-						if entry.Rel != "" {
-							dep.Version += "-" + entry.Rel
-							dep.Rel = entry.Rel
-						}
+					deps = append(deps, entryDependency(entry))
+				}
+
+				var provides []Dependency
+				for _, entry := range p.Format.Provides.Entries {
+					provides = append(provides, entryDependency(entry))
+				}
+
+				// Keep the primary file subset so file requirements (/bin/sh) resolve
+				var files []string
+				for _, file := range p.Format.Files {
+					if file.Type != "dir" && file.Path != "" {
+						files = append(files, file.Path)
 					}
+				}
 
-					deps = append(deps, dep)
+				epoch := p.Version.Epoch
+				if epoch == "0" {
+					epoch = ""
 				}
 
 				info := &PackageInfo{
 					Name:          p.Name,
 					Version:       fullVersion,
+					Epoch:         epoch,
 					Architecture:  p.Arch,
 					Summary:       p.Summary,
 					Description:   p.Description,
@@ -137,6 +164,8 @@ func ParsePrimary(r io.Reader, filename string, repoName string) ([]*PackageInfo
 					ChecksumType:  p.Checksum.Type,
 					Repository:    repoName,
 					Dependencies:  deps,
+					Provides:      provides,
+					Files:         files,
 				}
 				packages = append(packages, info)
 			}
@@ -144,4 +173,64 @@ func ParsePrimary(r io.Reader, filename string, repoName string) ([]*PackageInfo
 	}
 
 	return packages, nil
+}
+
+// entryDependency converts an rpm:entry into a Dependency, joining ver and rel
+func entryDependency(entry RpmEntry) Dependency {
+	dep := Dependency{
+		Name:  entry.Name,
+		Flags: entry.Flags,
+		Epoch: entry.Epoch,
+	}
+
+	// Construct version string if present
+	if entry.Ver != "" {
+		dep.Version = entry.Ver
+		if entry.Rel != "" {
+			dep.Version += "-" + entry.Rel
+			dep.Rel = entry.Rel
+		}
+	}
+
+	return dep
+}
+
+// ParseFilelists streams filelists.xml (or filelists-ext.xml), calling fn
+// with each package's pkgid, name and non-directory files.
+// filename is used to determine compression type.
+func ParseFilelists(r io.Reader, filename string, fn func(pkgID, name string, files []string)) error {
+	xmlReader, err := decompress(r, filename)
+	if err != nil {
+		return err
+	}
+	defer xmlReader.Close()
+
+	decoder := xml.NewDecoder(xmlReader)
+	for {
+		t, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("decoding filelists: %w", err)
+		}
+
+		se, ok := t.(xml.StartElement)
+		if !ok || se.Name.Local != "package" {
+			continue
+		}
+
+		var p FilelistPackage
+		if err := decoder.DecodeElement(&p, &se); err != nil {
+			return fmt.Errorf("decoding filelists package: %w", err)
+		}
+
+		files := make([]string, 0, len(p.Files))
+		for _, file := range p.Files {
+			if file.Type != "dir" {
+				files = append(files, file.Path)
+			}
+		}
+		fn(p.PkgID, p.Name, files)
+	}
 }
\ No newline at end of file
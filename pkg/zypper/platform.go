@@ -3,6 +3,7 @@ package zypper
 import (
 	"fmt"
 	"runtime"
+	"strings"
 )
 
 // DetectArchitecture checks the system architecture
@@ -28,4 +29,57 @@ func DetectArchitecture() (string, error) {
 	default:
 		return "", fmt.Errorf("unsupported architecture for zypper: %s", goarch)
 	}
+}
+
+// DistributionFor converts an openSUSE release into its path on the mirror.
+// "tumbleweed" stays as is, a Leap/SLE version such as "15.6" becomes
+// "distribution/leap/15.6" (as does "leap/15.6"), and any other value
+// containing a slash is treated as a full path.
+func DistributionFor(release string) string {
+	release = strings.TrimSpace(release)
+	switch {
+	case release == "":
+		return DefaultDistribution
+	case strings.HasPrefix(release, "leap"):
+		return "distribution/leap/" + strings.TrimLeft(strings.TrimPrefix(release, "leap"), "-/ ")
+	case release == DefaultDistribution || strings.Contains(release, "/"):
+		return release
+	default:
+		return "distribution/leap/" + release
+	}
+}
+
+// DefaultReposFor returns the repositories enabled by default for a distribution path
+func DefaultReposFor(distribution string) []string {
+	if strings.Contains(distribution, "leap") {
+		return DefaultLeapRepos
+	}
+	return DefaultRepos
+}
+
+// RepoURL returns the base URL of a repository. Paths such as repo/oss live
+// under the distribution ("tumbleweed/repo/oss"); update repositories do not:
+// "update" is update/tumbleweed on Tumbleweed and update/leap/15.6/oss on Leap,
+// "update/sle" is update/leap/15.6/sle, and so on.
+func RepoURL(mirror, distribution, repo string) string {
+	mirror = strings.TrimSuffix(mirror, "/")
+
+	sub, isUpdate := strings.CutPrefix(repo, RepoUpdate)
+	if !isUpdate || (sub != "" && sub[0] != '/') {
+		return fmt.Sprintf("%s/%s/%s", mirror, distribution, repo)
+	}
+	sub = strings.TrimPrefix(sub, "/")
+
+	if version, ok := strings.CutPrefix(distribution, "distribution/leap/"); ok {
+		if sub == "" {
+			sub = "oss"
+		}
+		return fmt.Sprintf("%s/update/leap/%s/%s", mirror, version, sub)
+	}
+
+	// Tumbleweed publishes a single update tree per repository
+	if sub == "" || sub == "oss" {
+		return fmt.Sprintf("%s/update/%s", mirror, distribution)
+	}
+	return fmt.Sprintf("%s/update/%s-%s", mirror, distribution, sub)
 }
\ No newline at end of file
@@ -0,0 +1,115 @@
+// pkg/zypper/resolve.go
+package zypper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/arc-language/upkg/pkg/rpm"
+)
+
+// String formats a dependency the way zypper prints it ("libfoo >= 1.2-3")
+func (d Dependency) String() string {
+	op := map[string]string{"EQ": "=", "LT": "<", "LE": "<=", "GT": ">", "GE": ">="}[d.Flags]
+	if op == "" || d.Version == "" {
+		return d.Name
+	}
+	return fmt.Sprintf("%s %s %s", d.Name, op, d.evr())
+}
+
+// evr returns the version constraint of a dependency or provide
+func (d Dependency) evr() rpm.EVR {
+	return rpm.EVR{
+		Epoch:   d.Epoch,
+		Version: strings.TrimSuffix(d.Version, "-"+d.Rel),
+		Release: d.Rel,
+	}
+}
+
+// evr returns the package's epoch:version-release
+func (p *PackageInfo) evr() rpm.EVR {
+	evr := rpm.ParseEVR(p.Version)
+	evr.Epoch = p.Epoch
+	return evr
+}
+
+// indexProvides adds a package to the provides index under its own name
+// and every capability it provides (sonames, pkgconfig(...), perl(...), ...)
+func (pm *PackageManager) indexProvides(p *PackageInfo) {
+	pm.cache.providers[p.Name] = append(pm.cache.providers[p.Name], p)
+	for _, provide := range p.Provides {
+		if provide.Name == p.Name {
+			continue
+		}
+		pm.cache.providers[provide.Name] = append(pm.cache.providers[provide.Name], p)
+	}
+}
+
+// resolveDependency picks the package satisfying a requirement. Candidates
+// come from the provides index and must meet the version constraint; ties
+// are broken by betterCandidate.
+func (pm *PackageManager) resolveDependency(ctx context.Context, req Dependency, arch string) (*PackageInfo, error) {
+	if strings.HasPrefix(req.Name, "/") {
+		return pm.findFileOwner(ctx, req.Name, arch)
+	}
+
+	var best *PackageInfo
+	for _, candidate := range pm.cache.providers[req.Name] {
+		if !satisfies(candidate, req) {
+			continue
+		}
+		if best == nil || pm.betterCandidate(candidate, best, req.Name, arch) {
+			best = candidate
+		}
+	}
+
+	if best == nil {
+		if len(pm.cache.providers[req.Name]) > 0 {
+			return nil, fmt.Errorf("no provider of %s matches the required version", req)
+		}
+		return nil, fmt.Errorf("nothing provides %s", req.Name)
+	}
+
+	return best, nil
+}
+
+// satisfies reports whether a package meets a requirement, either by name or
+// through one of its provides
+func satisfies(p *PackageInfo, req Dependency) bool {
+	if p.Name == req.Name && rpm.Satisfies(p.evr(), req.Flags, req.evr()) {
+		return true
+	}
+	for _, provide := range p.Provides {
+		if provide.Name != req.Name {
+			continue
+		}
+		// An unversioned provide of the package's own name carries its version
+		evr := provide.evr()
+		if provide.Name == p.Name && evr.Version == "" {
+			evr = p.evr()
+		}
+		if rpm.Satisfies(evr, req.Flags, req.evr()) {
+			return true
+		}
+	}
+	return false
+}
+
+// betterCandidate reports whether a should be preferred over b: the package
+// named like the requirement first, then the newest build of a package over
+// superseded ones, then the target architecture over noarch, then the higher
+// version
+func (pm *PackageManager) betterCandidate(a, b *PackageInfo, name, arch string) bool {
+	if (a.Name == name) != (b.Name == name) {
+		return a.Name == name
+	}
+	aCurrent, bCurrent := pm.cache.packages[a.Name] == a, pm.cache.packages[b.Name] == b
+	if aCurrent != bCurrent {
+		return aCurrent
+	}
+	if (a.Architecture == arch) != (b.Architecture == arch) {
+		return a.Architecture == arch
+	}
+	return a.evr().Compare(b.evr()) > 0
+}
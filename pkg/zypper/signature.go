@@ -0,0 +1,58 @@
+// pkg/zypper/signature.go
+package zypper
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/arc-language/upkg/pkg/rpm"
+)
+
+// gpgcheck reports whether packages from a repository must carry a valid signature
+func (pm *PackageManager) gpgcheck(repoPath string) bool {
+	if pm.config.NoGPGCheck {
+		return false
+	}
+	if check, ok := pm.config.GPGCheck[repoPath]; ok {
+		return check
+	}
+	return true
+}
+
+// verifyPackage checks a downloaded RPM against its own header: header and
+// payload digests when digests is set, and the PGP signature when the
+// package's repository has gpgcheck enabled
+func (pm *PackageManager) verifyPackage(ctx context.Context, pkg *PackageInfo, rpmPath string, digests bool) error {
+	if !pm.gpgcheck(pkg.Repository) {
+		if !digests {
+			return nil
+		}
+		if _, err := rpm.Verify(rpmPath, nil); err != nil {
+			return fmt.Errorf("verifying %s digests: %w", filepath.Base(rpmPath), err)
+		}
+		return nil
+	}
+
+	if pm.keyring == nil {
+		sources := append(append([]string{}, DefaultGPGKeys...), pm.config.GPGKeys...)
+		keyring, err := rpm.LoadKeyring(ctx, sources, filepath.Join(pm.config.CachePath, "keys"), pm.client.Get)
+		if err != nil {
+			return fmt.Errorf("loading trusted keys: %w", err)
+		}
+		pm.logger.Printf("  Loaded %d keys from %s", keyring.Len(), strings.Join(keyring.Sources(), ", "))
+		pm.keyring = keyring
+	}
+
+	result, err := rpm.Verify(rpmPath, pm.keyring)
+	if err != nil {
+		return fmt.Errorf("verifying %s signature: %w", filepath.Base(rpmPath), err)
+	}
+
+	if pm.config.Debug {
+		pm.logger.Printf("    Signature OK: %s", strings.Join(result.Signers, ", "))
+	}
+
+	return nil
+}
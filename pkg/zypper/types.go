@@ -4,18 +4,24 @@ import (
 	"encoding/xml"
 	"log"
 	"time"
+
+	"github.com/arc-language/upkg/pkg/rpm"
 )
 
 // Config configures the Zypper package manager
 type Config struct {
-	MirrorURL    string        // Base Mirror URL
-	Distribution string        // Distribution (tumbleweed, distribution/leap/15.5)
-	Repos        []string      // List of repository paths
-	InstallPath  string        // Where to install packages
-	CachePath    string        // Where to cache downloaded files
-	Timeout      time.Duration // Network timeout
-	Debug        bool          // Enable debug logging
-	Logger       *log.Logger   // Custom logger
+	MirrorURL    string          // Base Mirror URL
+	Distribution string          // Distribution (tumbleweed, distribution/leap/15.5)
+	Architecture string          // Target architecture (default: x86_64)
+	Repos        []string        // List of repository paths
+	GPGKeys      []string        // Extra trusted keys (paths or URLs), added to DefaultGPGKeys
+	GPGCheck     map[string]bool // Per-repository signature policy (key: repository path; default: true)
+	NoGPGCheck   bool            // Skip RPM signature checks everywhere; header and payload digests are still verified
+	InstallPath  string          // Where to install packages
+	CachePath    string          // Where to cache downloaded files
+	Timeout      time.Duration   // Network timeout
+	Debug        bool            // Enable debug logging
+	Logger       *log.Logger     // Custom logger
 }
 
 // PackageManager handles Zypper package operations
@@ -24,12 +30,15 @@ type PackageManager struct {
 	config *Config
 	logger *log.Logger
 	cache  *PackageCache
+
+	keyring *rpm.Keyring // Trusted keys, loaded on first use
 }
 
 // PackageInfo contains metadata from the primary.xml
 type PackageInfo struct {
 	Name          string
-	Version       string
+	Version       string // version-release
+	Epoch         string // Epoch, empty when 0
 	Architecture  string
 	Summary       string
 	Description   string
@@ -43,6 +52,8 @@ type PackageInfo struct {
 	ChecksumType  string // sha256, sha1, etc.
 	Repository    string // Origin repository
 	Dependencies  []Dependency // Package dependencies (added for sub-dep support)
+	Provides      []Dependency // Capabilities this package provides (sonames, pkgconfig(...), ...)
+	Files         []string     // Files listed in primary.xml (binaries and /etc); the rest live in filelists
 }
 
 // Dependency represents a package dependency
@@ -66,11 +77,26 @@ type DownloadOptions struct {
 
 // PackageCache caches package index information
 type PackageCache struct {
-	packages      map[string]*PackageInfo // key: package_name
+	packages      map[string]*PackageInfo   // key: package_name (highest version across repositories)
+	providers     map[string][]*PackageInfo // key: capability -> every package providing it
+	files         map[string][]*PackageInfo // key: file path -> owning packages
+	baseNames     map[string][]*PackageInfo // key: file base name -> owning packages
+	filelists     []*filelistSource         // filelists of each repository, fetched on first use
+	filesIndexed  bool                      // filelists have been added to files and baseNames
+	arch          string                    // Architecture the index was built for
 	lastUpdate    time.Time
 	cacheDuration time.Duration
 }
 
+// filelistSource locates a repository's filelists metadata and its local copy
+type filelistSource struct {
+	repo         string // Repository path (repo/oss)
+	url          string // Remote filelists (or filelists-ext) location
+	checksum     string // Checksum of the compressed file, from repomd.xml
+	checksumType string // sha256 or sha512
+	path         string // Local cached copy, set once downloaded and verified
+}
+
 // --- XML Structures ---
 
 // Repomd represents repodata/repomd.xml
@@ -143,6 +169,21 @@ type Format struct {
 	License  string       `xml:"license"`
 	Requires RpmRequires  `xml:"requires"`
 	Provides RpmProvides  `xml:"provides"`
+	Files    []FileEntry  `xml:"file"`
+}
+
+// FileEntry is a <file> element of primary.xml or filelists.xml
+type FileEntry struct {
+	Type string `xml:"type,attr"` // "dir", "ghost" or empty for regular files
+	Path string `xml:",chardata"`
+}
+
+// FilelistPackage is a <package> element of filelists.xml
+type FilelistPackage struct {
+	PkgID string      `xml:"pkgid,attr"`
+	Name  string      `xml:"name,attr"`
+	Arch  string      `xml:"arch,attr"`
+	Files []FileEntry `xml:"file"`
 }
 
 // RpmRequires contains the list of package dependencies
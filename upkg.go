@@ -4,13 +4,14 @@ package upkg
 import (
 	"context"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"runtime"
-	"strings"
 
 	"github.com/arc-language/upkg/pkg/backend"
 	"github.com/arc-language/upkg/pkg/choco"
+	"github.com/arc-language/upkg/pkg/env"
 	"github.com/arc-language/upkg/pkg/index"
 	"github.com/arc-language/upkg/pkg/registry"
 )
@@ -23,6 +24,7 @@ type (
 	DownloadOptions = backend.DownloadOptions
 	Config          = backend.Config
 	NixConfig       = backend.NixConfig
+	NixSubstituter  = backend.NixSubstituter
 	BrewConfig      = backend.BrewConfig
 	// RegistryEntry is the metadata for a package from the deps/ registry.
 	// Re-exported so external tools like a compiler can access it.
@@ -32,13 +34,16 @@ type (
 // Re-export backend constants
 const (
 	BackendNix    = backend.BackendNix
+	BackendGuix   = backend.BackendGuix
 	BackendBrew   = backend.BackendBrew
 	BackendDpkg   = backend.BackendDpkg
 	BackendApt    = backend.BackendApt
 	BackendApk    = backend.BackendApk
 	BackendDnf    = backend.BackendDnf
+	BackendEL     = backend.BackendEL
 	BackendChoco  = backend.BackendChoco
 	BackendPacman = backend.BackendPacman
+	BackendMSYS2  = backend.BackendMSYS2
 	BackendZypper = backend.BackendZypper
 	BackendWinget = backend.BackendWinget
 	BackendAuto   = backend.BackendAuto
@@ -75,7 +80,11 @@ func NewManager(backendType backend.BackendType, config *backend.Config) (*Manag
 	// Sync if deps folder doesn't exist yet
 	depsDir := filepath.Join(config.CachePath, "deps")
 	if _, err := os.Stat(depsDir); os.IsNotExist(err) {
-		if err := index.Sync(config.CachePath); err != nil {
+		var brewAPIURL string
+		if config.Brew != nil {
+			brewAPIURL = config.Brew.APIURL
+		}
+		if err := index.Sync(config.CachePath, brewAPIURL); err != nil {
 			return nil, fmt.Errorf("failed to sync package index: %w", err)
 		}
 	}
@@ -84,34 +93,13 @@ func NewManager(backendType backend.BackendType, config *backend.Config) (*Manag
 	var reg *registry.Registry
 	var err error
 
-	switch backendType {
-	case backend.BackendNix:
-		b, err = backend.NewNixBackend(config)
-	case backend.BackendBrew:
-		b, err = backend.NewBrewBackend(config)
-	case backend.BackendDpkg:
-		b, err = backend.NewDpkgBackend(config)
-	case backend.BackendApt:
-		b, err = backend.NewAptBackend(config)
-	case backend.BackendApk:
-		b, err = backend.NewApkBackend(config)
-	case backend.BackendDnf:
-		b, err = backend.NewDnfBackend(config)
-	case backend.BackendChoco:
-		b, err = backend.NewChocoBackend(config)
-	case backend.BackendPacman:
-		b, err = backend.NewPacmanBackend(config)
-	case backend.BackendZypper:
-		b, err = backend.NewZypperBackend(config)
-	case backend.BackendWinget:
-		b, err = backend.NewWingetBackend(config)
-	case backend.BackendAuto:
+	if backendType == backend.BackendAuto {
 		b, err = autoDetectBackend(config)
 		if err == nil {
 			reg = registry.New(config.CachePath)
 		}
-	default:
-		return nil, fmt.Errorf("unsupported backend type: %s", backendType)
+	} else {
+		b, err = newBackend(backendType, config)
 	}
 
 	if err != nil {
@@ -125,6 +113,40 @@ func NewManager(backendType backend.BackendType, config *backend.Config) (*Manag
 	}, nil
 }
 
+// newBackend creates a backend of the given (non-auto) type
+func newBackend(backendType backend.BackendType, config *backend.Config) (backend.Backend, error) {
+	switch backendType {
+	case backend.BackendNix:
+		return backend.NewNixBackend(config)
+	case backend.BackendGuix:
+		return backend.NewGuixBackend(config)
+	case backend.BackendBrew:
+		return backend.NewBrewBackend(config)
+	case backend.BackendDpkg:
+		return backend.NewDpkgBackend(config)
+	case backend.BackendApt:
+		return backend.NewAptBackend(config)
+	case backend.BackendApk:
+		return backend.NewApkBackend(config)
+	case backend.BackendDnf:
+		return backend.NewDnfBackend(config)
+	case backend.BackendEL:
+		return backend.NewELBackend(config)
+	case backend.BackendChoco:
+		return backend.NewChocoBackend(config)
+	case backend.BackendPacman:
+		return backend.NewPacmanBackend(config)
+	case backend.BackendMSYS2:
+		return backend.NewMSYS2Backend(config)
+	case backend.BackendZypper:
+		return backend.NewZypperBackend(config)
+	case backend.BackendWinget:
+		return backend.NewWingetBackend(config)
+	default:
+		return nil, fmt.Errorf("unsupported backend type: %s", backendType)
+	}
+}
+
 // autoDetectBackend detects the best backend for the current system
 func autoDetectBackend(config *backend.Config) (backend.Backend, error) {
 	if runtime.GOOS == "darwin" {
@@ -149,39 +171,19 @@ func autoDetectBackend(config *backend.Config) (backend.Backend, error) {
 	}
 
 	if runtime.GOOS == "linux" {
-		if isAlpine() {
-			b, err := backend.NewApkBackend(config)
-			if err == nil {
-				return b, nil
-			}
-		}
-
-		if isFedora() {
-			b, err := backend.NewDnfBackend(config)
-			if err == nil {
-				return b, nil
-			}
-		}
-
-		if isArchLinux() {
-			b, err := backend.NewPacmanBackend(config)
-			if err == nil {
-				return b, nil
+		// Match the host distribution (via /etc/os-release) so downloaded
+		// libraries are built against the same glibc and ABI
+		distro, err := backend.DetectDistro()
+		if err == nil {
+			if config.Debug && config.Logger != nil {
+				config.Logger.Printf("Detected distribution '%s' -> %s (release: %s)", distro.ID, distro.Backend, distro.Release)
 			}
-		}
-
-		if isOpenSUSE() {
-			b, err := backend.NewZypperBackend(config)
-			if err == nil {
-				return b, nil
-			}
-		}
-
-		if isUbuntu() {
-			b, err := backend.NewAptBackend(config)
+			b, err := newBackend(distro.Backend, config)
 			if err == nil {
 				return b, nil
 			}
+		} else if config.Debug && config.Logger != nil {
+			config.Logger.Printf("Distribution detection failed: %v", err)
 		}
 
 		b, err := backend.NewDpkgBackend(config)
@@ -205,64 +207,6 @@ func autoDetectBackend(config *backend.Config) (backend.Backend, error) {
 	return nil, fmt.Errorf("no suitable package manager backend found")
 }
 
-func isFedora() bool {
-	data, err := os.ReadFile("/etc/os-release")
-	if err != nil {
-		if _, err := os.Stat("/etc/fedora-release"); err == nil {
-			return true
-		}
-		return false
-	}
-	content := strings.ToLower(string(data))
-	return strings.Contains(content, "fedora") || strings.Contains(content, "rhel") || strings.Contains(content, "centos")
-}
-
-func isAlpine() bool {
-	data, err := os.ReadFile("/etc/os-release")
-	if err != nil {
-		if _, err := os.Stat("/etc/alpine-release"); err == nil {
-			return true
-		}
-		return false
-	}
-	content := strings.ToLower(string(data))
-	return strings.Contains(content, "alpine")
-}
-
-func isArchLinux() bool {
-	if _, err := os.Stat("/etc/arch-release"); err == nil {
-		return true
-	}
-
-	data, err := os.ReadFile("/etc/os-release")
-	if err != nil {
-		return false
-	}
-	content := strings.ToLower(string(data))
-	return strings.Contains(content, "arch") || strings.Contains(content, "manjaro")
-}
-
-func isOpenSUSE() bool {
-	data, err := os.ReadFile("/etc/os-release")
-	if err != nil {
-		if _, err := os.Stat("/etc/SuSE-release"); err == nil {
-			return true
-		}
-		return false
-	}
-	content := strings.ToLower(string(data))
-	return strings.Contains(content, "opensuse") || strings.Contains(content, "sles")
-}
-
-func isUbuntu() bool {
-	data, err := os.ReadFile("/etc/os-release")
-	if err != nil {
-		return false
-	}
-	content := strings.ToLower(string(data))
-	return strings.Contains(content, "ubuntu") && !strings.Contains(content, "debian")
-}
-
 // Download downloads and installs a package
 func (m *Manager) Download(ctx context.Context, pkg *backend.Package, opts *backend.DownloadOptions) error {
 	if pkg == nil {
@@ -298,7 +242,39 @@ func (m *Manager) Download(ctx context.Context, pkg *backend.Package, opts *back
 		resolvedPkg.Name = resolved
 	}
 
-	return m.backend.Download(ctx, &resolvedPkg, opts)
+	if err := m.backend.Download(ctx, &resolvedPkg, opts); err != nil {
+		return err
+	}
+
+	if (m.config.Relocate || m.config.SetInterpreter) && *opts.Extract {
+		return m.relocate()
+	}
+	return nil
+}
+
+// relocate points the RUNPATH (and optionally the interpreter) of the
+// environment's ELF files at its own libraries. Files that cannot be
+// patched are reported as warnings; the install itself has succeeded.
+func (m *Manager) relocate() error {
+	report, err := env.New(m.config.InstallPath, m.backend.Name()).RelocateBinaries(m.config.SetInterpreter)
+	if err != nil {
+		return fmt.Errorf("relocating binaries: %w", err)
+	}
+	if report == nil {
+		return nil
+	}
+
+	logger := m.config.Logger
+	if logger == nil {
+		logger = log.New(os.Stderr, "", 0)
+	}
+	for path, err := range report.Failed {
+		logger.Printf("Warning: could not relocate %s: %v", path, err)
+	}
+	if m.config.Debug && m.config.Logger != nil {
+		m.config.Logger.Printf("Relocated %d of %d ELF files in %s", len(report.Patched), report.Scanned, m.config.InstallPath)
+	}
+	return nil
 }
 
 // GetInfo retrieves information about a package
@@ -326,6 +302,45 @@ func (m *Manager) Search(ctx context.Context, query string) ([]*backend.PackageI
 	return m.backend.Search(ctx, query)
 }
 
+// FindFileOwners returns the packages that ship a file path (or bare file
+// name). Only backends with file metadata support it (dnf, el, zypper).
+func (m *Manager) FindFileOwners(ctx context.Context, path string) ([]*backend.PackageInfo, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+
+	finder, ok := m.backend.(backend.FileOwnerFinder)
+	if !ok {
+		return nil, fmt.Errorf("backend %s cannot look up file owners", m.backend.Name())
+	}
+	return finder.FindFileOwners(ctx, path)
+}
+
+// Remove removes an installed package. Only backends that keep packages in
+// separate directories support it (nix, guix, brew).
+func (m *Manager) Remove(ctx context.Context, pkg *backend.Package) error {
+	if pkg == nil || pkg.Name == "" {
+		return fmt.Errorf("package name is required")
+	}
+
+	remover, ok := m.backend.(backend.Remover)
+	if !ok {
+		return fmt.Errorf("backend %s cannot remove packages", m.backend.Name())
+	}
+
+	// In auto mode, resolve through registry like Download
+	resolvedPkg := *pkg
+	if m.registry != nil {
+		resolved, err := m.registry.Resolve(pkg.Name, m.backend.Name())
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+		resolvedPkg.Name = resolved
+	}
+
+	return remover.Remove(ctx, &resolvedPkg)
+}
+
 // GetRegistryEntry retrieves the full registry entry for a package.
 // This is useful for accessing metadata like the 'libs' field.
 // Returns an error if not in auto mode or if the package is not found.